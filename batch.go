@@ -0,0 +1,175 @@
+package main
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// InstanceAttribute represents a per-instance attribute buffered by the
+// effects of a batch.
+type InstanceAttribute struct {
+	Location uint32
+	Size     int32
+}
+
+type batchEntry struct {
+	effect    *Effect
+	instances []float32
+}
+
+// Batch represents a shape drawn for every live effect in a single instanced
+// draw. Each instance reads the attributes buffered by its effect, followed
+// by the origin and start time of the effect. Instances are drawn in the
+// order their effects were added, so older effects are drawn further back.
+type Batch struct {
+	vertices   *render.VertexBuffer
+	indices    *render.IndexBuffer
+	instances  *render.VertexBuffer
+	renderable *render.Renderable
+	count      int32
+	stride     int
+	primcount  int32
+	entries    []batchEntry
+	dirty      bool
+}
+
+// NewBatch instantiates and returns a new batch of the shape described by
+// the vec3 vertex positions and indices, read from location 0. The origin
+// and start time of each effect are read from the provided locations.
+func NewBatch(positions []float32, indices []uint16, attributes []InstanceAttribute, origin uint32, start uint32) (*Batch, error) {
+	attributes = append(attributes,
+		InstanceAttribute{Location: origin, Size: 2},
+		InstanceAttribute{Location: start, Size: 1})
+	stride := 0
+	for _, attribute := range attributes {
+		stride += int(attribute.Size)
+	}
+	b := &Batch{
+		vertices:   &render.VertexBuffer{},
+		indices:    &render.IndexBuffer{},
+		instances:  &render.VertexBuffer{},
+		renderable: &render.Renderable{},
+		count:      int32(len(indices)),
+		stride:     stride,
+	}
+	err := b.vertices.BufferFloat32(positions)
+	if err != nil {
+		b.Destroy()
+		return nil, err
+	}
+	err = b.indices.BufferUint16(indices)
+	if err != nil {
+		b.Destroy()
+		return nil, err
+	}
+	// allocate a single instance so the pointers reference a buffer
+	err = b.instances.AllocateBuffer(stride * 4)
+	if err != nil {
+		b.Destroy()
+		return nil, err
+	}
+	b.renderable.SetVertexBuffer(b.vertices)
+	b.renderable.SetIndexBuffer(b.indices)
+	b.renderable.SetPointer(0, &render.AttributePointer{
+		Type: gl.FLOAT,
+		Size: 3,
+	})
+	offset := 0
+	locations := make([]uint32, len(attributes))
+	for i, attribute := range attributes {
+		b.renderable.SetPointer(attribute.Location, &render.AttributePointer{
+			Type:       gl.FLOAT,
+			Size:       attribute.Size,
+			ByteStride: int32(stride * 4),
+			ByteOffset: offset * 4,
+			Buffer:     b.instances,
+		})
+		locations[i] = attribute.Location
+		offset += int(attribute.Size)
+	}
+	b.renderable.SetInstancedAttributes(locations)
+	err = b.renderable.Upload()
+	if err != nil {
+		b.Destroy()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Add adds the instances of an effect, interleaved in the order of the
+// attributes of the batch, with the origin and start time of the effect. A
+// batch without attributes of its own adds a single instance per effect.
+func (b *Batch) Add(effect *Effect, instances []float32, origin mgl32.Vec2, start float32) {
+	components := b.stride - 3
+	var data []float32
+	if components == 0 {
+		// a single instance without attributes of its own
+		data = []float32{origin[0], origin[1], start}
+	} else {
+		data = make([]float32, 0, len(instances)/components*b.stride)
+		for i := 0; i+components <= len(instances); i += components {
+			data = append(data, instances[i:i+components]...)
+			data = append(data, origin[0], origin[1], start)
+		}
+	}
+	b.entries = append(b.entries, batchEntry{
+		effect:    effect,
+		instances: data,
+	})
+	b.dirty = true
+}
+
+// Remove removes the instances of an effect.
+func (b *Batch) Remove(effect *Effect) {
+	j := 0
+	for _, entry := range b.entries {
+		if entry.effect != effect {
+			b.entries[j] = entry
+			j++
+		}
+	}
+	if j != len(b.entries) {
+		b.entries = b.entries[:j]
+		b.dirty = true
+	}
+}
+
+// Renderable returns the renderable drawing every instance of the batch,
+// uploading the instances if they changed. It returns nil if the batch has
+// no instances.
+func (b *Batch) Renderable() (*render.Renderable, error) {
+	if b.dirty {
+		var data []float32
+		for _, entry := range b.entries {
+			data = append(data, entry.instances...)
+		}
+		if len(data) > 0 {
+			err := b.instances.BufferFloat32(data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		b.primcount = int32(len(data) / b.stride)
+		b.renderable.SetDrawElementsInstanced(
+			gl.TRIANGLES,
+			b.count,
+			gl.UNSIGNED_SHORT,
+			0,
+			b.primcount)
+		b.dirty = false
+	}
+	if b.primcount == 0 {
+		return nil, nil
+	}
+	return b.renderable, nil
+}
+
+// Destroy deallocates the batch.
+func (b *Batch) Destroy() {
+	b.renderable.Destroy()
+	b.vertices.Destroy()
+	b.indices.Destroy()
+	b.instances.Destroy()
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/shape"
+)
+
+// layers of the effects, drawn in order
+const (
+	layerShockwaves = iota
+	layerSmoke
+	layerExplosions
+	layerTrails
+)
+
+const (
+	explosionSize = 4
+	smokeSize     = 10
+	// effectLifetime is the number of seconds an effect is drawn for
+	effectLifetime = 3.0
+)
+
+// Effect represents an animated effect. Its explosion, smoke and shockwave
+// are instances of the batches shared by every effect.
+type Effect struct {
+	Sparks   *render.Renderable
+	Trails   *render.Renderable
+	Time     time.Time
+	Position mgl32.Vec2
+	buffers  []*render.VertexBuffer
+}
+
+// NewEffect instantiates and returns a new effect at the provided position,
+// starting at the provided time, and adds its instances to the batches.
+func NewEffect(position mgl32.Vec2, now time.Time) (*Effect, error) {
+	effect := &Effect{
+		Time:     now,
+		Position: position,
+	}
+	var err error
+	var buffer *render.VertexBuffer
+	effect.Sparks, buffer, err = createSparks(60, 250, 2)
+	if err != nil {
+		return nil, err
+	}
+	effect.buffers = append(effect.buffers, buffer)
+	effect.Trails, buffer, err = createTrails(12, 120)
+	if err != nil {
+		effect.Destroy()
+		return nil, err
+	}
+	effect.buffers = append(effect.buffers, buffer)
+	// explosions, smoke and shockwaves are instances of shared batches
+	start := float32(now.Sub(startTime).Seconds())
+	explosionBatch.Add(effect, createExplosion(200, 20, 200, explosionSize), position, start)
+	smokeBatch.Add(effect, createSmoke(200, 20, 140, smokeSize), position, start)
+	shockwaveBatch.Add(effect, nil, position, start)
+	return effect, nil
+}
+
+// Expired returns whether the effect is over at the provided time.
+func (e *Effect) Expired(now time.Time) bool {
+	return now.Sub(e.Time).Seconds() >= effectLifetime
+}
+
+// Destroy removes the instances of the effect from the batches and
+// deallocates its sparks and trails.
+func (e *Effect) Destroy() {
+	explosionBatch.Remove(e)
+	smokeBatch.Remove(e)
+	shockwaveBatch.Remove(e)
+	if e.Sparks != nil {
+		e.Sparks.Destroy()
+	}
+	if e.Trails != nil {
+		e.Trails.Destroy()
+	}
+	for _, buffer := range e.buffers {
+		buffer.Destroy()
+	}
+	e.buffers = nil
+}
+
+// Queue adds the draws of the sparks and trails of the effect at the
+// provided time value to the queue. Older effects are further back.
+func (e *Effect) Queue(queue *render.Queue, now time.Time) {
+	// time relative to start of effect
+	t := float32(now.Sub(e.Time).Seconds())
+	// model matrix
+	model := mgl32.Translate3D(e.Position[0], e.Position[1], 0.0)
+	queueCommands(queue, trailTechnique, layerTrails, t,
+		drawTrails(
+			e.Trails,
+			mgl32.Vec4{1.0, 0.7, 0.4, 0.6},
+			model,
+			t))
+	queueCommands(queue, sparkTechnique, layerTrails, t,
+		drawSparks(
+			e.Sparks,
+			mgl32.Vec4{1.0, 0.8, 0.5, 0.9},
+			model,
+			t))
+}
+
+func queueCommands(queue *render.Queue, technique *render.Technique, layer int, depth float32, commands []*render.Command) {
+	for _, command := range commands {
+		queue.Add(technique, command, layer, depth)
+	}
+}
+
+// queueBatches adds a single instanced draw per batched layer to the queue,
+// at the provided time since the application started.
+func queueBatches(queue *render.Queue, time float32) error {
+	shockwaves, err := shockwaveBatch.Renderable()
+	if err != nil {
+		return err
+	}
+	if shockwaves != nil {
+		queueCommands(queue, shockwaveTechnique, layerShockwaves, 0,
+			drawShockwave(
+				shockwaves,
+				mgl32.Vec4{1.0, 0.98, 0.96, 0.2},
+				mgl32.Ident4(),
+				time))
+	}
+	smoke, err := smokeBatch.Renderable()
+	if err != nil {
+		return err
+	}
+	if smoke != nil {
+		queueCommands(queue, smokeTechnique, layerSmoke, 0,
+			drawSmoke(
+				smoke,
+				mgl32.Vec4{0.41, 0.4, 0.39, 0.2},
+				mgl32.Ident4(),
+				time))
+	}
+	explosions, err := explosionBatch.Renderable()
+	if err != nil {
+		return err
+	}
+	if explosions != nil {
+		queueCommands(queue, explosionTechnique, layerExplosions, 0,
+			drawExplosion(
+				explosions,
+				mgl32.Vec4{0.8, 0.4, 0.2, 0.8},
+				mgl32.Ident4(),
+				time))
+	}
+	return nil
+}
+
+func createParticleBatch(positions []float32, indices []uint16) (*Batch, error) {
+	// the offset, velocity and size of each particle are read per instance
+	return NewBatch(
+		positions,
+		indices,
+		[]InstanceAttribute{
+			{Location: 1, Size: 2},
+			{Location: 2, Size: 2},
+			{Location: 3, Size: 1},
+		},
+		5,
+		6)
+}
+
+func createBatches() error {
+	var err error
+	positions, indices := shape.Quad(explosionSize, true, false)
+	explosionBatch, err = createParticleBatch(positions, indices)
+	if err != nil {
+		return err
+	}
+	positions, indices = shape.Circle(smokeSize, 64, true, false)
+	smokeBatch, err = createParticleBatch(positions, indices)
+	if err != nil {
+		return err
+	}
+	positions, indices = shape.Circle(1.0, 64, true, false)
+	shockwaveBatch, err = NewBatch(positions, indices, nil, 1, 2)
+	return err
+}
+
+// interleaveParticles interleaves the offset, velocity and size of each
+// particle.
+func interleaveParticles(offsets []float32, velocities []float32, sizes []float32) []float32 {
+	particles := make([]float32, 0, len(offsets)+len(velocities)+len(sizes))
+	for i := range sizes {
+		particles = append(particles,
+			offsets[i*2], offsets[i*2+1],
+			velocities[i*2], velocities[i*2+1],
+			sizes[i])
+	}
+	return particles
+}
+
+func createExplosion(num int, radius float32, force float32, size float32) []float32 {
+	offsets := make([]float32, 2*num)
+	for i := 0; i < num; i++ {
+		offset := randVec2().Mul(rand.Float32() * radius)
+		offsets[i*2] = offset[0]
+		offsets[i*2+1] = offset[1]
+	}
+	velocities := make([]float32, 2*num)
+	direction := mgl32.Vec2{0, force * 2}
+	for i := 0; i < num; i++ {
+		velocity := randVec2().Mul(rand.Float32() * force).Add(direction)
+		velocities[i*2] = velocity[0]
+		velocities[i*2+1] = velocity[1]
+	}
+	sizes := make([]float32, num)
+	for i := 0; i < num; i++ {
+		// size
+		sizes[i] = rand.Float32() * size
+	}
+	return interleaveParticles(offsets, velocities, sizes)
+}
+
+func createSparks(num int, force float32, size float32) (*render.Renderable, *render.VertexBuffer, error) {
+	// one point per spark, interleaved velocity and size
+	vertices := make([]float32, 3*num)
+	direction := mgl32.Vec2{0, force}
+	for i := 0; i < num; i++ {
+		velocity := randVec2().Mul(((rand.Float32() * 0.5) + 0.5) * force).Add(direction)
+		vertices[i*3] = velocity[0]
+		vertices[i*3+1] = velocity[1]
+		vertices[i*3+2] = ((rand.Float32() * 0.5) + 0.5) * size
+	}
+	vb := &render.VertexBuffer{}
+	err := vb.BufferFloat32(vertices)
+	if err != nil {
+		return nil, nil, err
+	}
+	renderable := &render.Renderable{}
+	renderable.SetVertexBuffer(vb)
+	renderable.SetPointer(0, &render.AttributePointer{
+		Type:       gl.FLOAT,
+		Size:       2,
+		ByteStride: 3 * 4,
+		ByteOffset: 0,
+	})
+	renderable.SetPointer(1, &render.AttributePointer{
+		Type:       gl.FLOAT,
+		Size:       1,
+		ByteStride: 3 * 4,
+		ByteOffset: 2 * 4,
+	})
+	renderable.SetDrawArrays(gl.POINTS, 0, int32(num))
+	err = renderable.Upload()
+	if err != nil {
+		renderable.Destroy()
+		vb.Destroy()
+		return nil, nil, err
+	}
+	return renderable, vb, nil
+}
+
+func createTrails(num int, length float32) (*render.Renderable, *render.VertexBuffer, error) {
+	// four control points per curve, bending away from a straight line
+	vertices := make([]float32, 8*num)
+	for i := 0; i < num; i++ {
+		direction := randVec2().Add(mgl32.Vec2{0, 1}).Normalize()
+		bend := mgl32.Vec2{-direction[1], direction[0]}.Mul((rand.Float32()*2 - 1) * length * 0.5)
+		end := direction.Mul(((rand.Float32() * 0.5) + 0.5) * length)
+		points := []mgl32.Vec2{
+			{0, 0},
+			end.Mul(0.33).Add(bend),
+			end.Mul(0.66).Add(bend),
+			end,
+		}
+		for j, point := range points {
+			vertices[i*8+j*2] = point[0]
+			vertices[i*8+j*2+1] = point[1]
+		}
+	}
+	vb := &render.VertexBuffer{}
+	err := vb.BufferFloat32(vertices)
+	if err != nil {
+		return nil, nil, err
+	}
+	renderable := &render.Renderable{}
+	renderable.SetVertexBuffer(vb)
+	renderable.SetPointer(0, &render.AttributePointer{
+		Type:       gl.FLOAT,
+		Size:       2,
+		ByteOffset: 0,
+	})
+	renderable.SetDrawArrays(gl.PATCHES, 0, int32(4*num))
+	renderable.SetPatchVertices(4)
+	err = renderable.Upload()
+	if err != nil {
+		renderable.Destroy()
+		vb.Destroy()
+		return nil, nil, err
+	}
+	return renderable, vb, nil
+}
+
+func createSmoke(num int, radius float32, force float32, size float32) []float32 {
+	offsets := make([]float32, 2*num)
+	for i := 0; i < num; i++ {
+		offset := randVec2().Mul(rand.Float32() * radius)
+		offsets[i*2] = offset[0]
+		offsets[i*2+1] = offset[1]
+	}
+	velocities := make([]float32, 2*num)
+	direction := mgl32.Vec2{0, force * 1.5}
+	for i := 0; i < num; i++ {
+		velocity := randVec2().Mul(rand.Float32() * force).Add(direction)
+		velocities[i*2] = velocity[0]
+		velocities[i*2+1] = velocity[1]
+	}
+	sizes := make([]float32, num)
+	for i := 0; i < num; i++ {
+		// size
+		sizes[i] = ((rand.Float32() * 0.5) + 0.5) * size
+	}
+	return interleaveParticles(offsets, velocities, sizes)
+}
+
+func drawExplosion(renderable *render.Renderable, color mgl32.Vec4, model mgl32.Mat4, time float32) []*render.Command {
+	command := &render.Command{}
+	command.Uniform("uModel", model)
+	command.Uniform("uColor", color)
+	gravity := mgl32.Vec2{0, -200}
+	command.Uniform("uGravity", gravity)
+	command.Uniform("uTime", time)
+	command.Renderable(renderable)
+	return []*render.Command{
+		command,
+	}
+}
+
+func drawSmoke(renderable *render.Renderable, color mgl32.Vec4, model mgl32.Mat4, time float32) []*render.Command {
+	command := &render.Command{}
+	command.Uniform("uModel", model)
+	command.Uniform("uColor", color)
+	rise := mgl32.Vec2{0, 10}
+	command.Uniform("uRise", rise)
+	command.Uniform("uTime", time)
+	command.Renderable(renderable)
+	return []*render.Command{
+		command,
+	}
+}
+
+func drawShockwave(renderable *render.Renderable, color mgl32.Vec4, model mgl32.Mat4, time float32) []*render.Command {
+	command := &render.Command{}
+	command.Uniform("uModel", model)
+	command.Uniform("uColor", color)
+	command.Uniform("uForce", float32(150.0))
+	command.Uniform("uTime", time)
+	command.Renderable(renderable)
+	return []*render.Command{
+		command,
+	}
+}
+
+func drawSparks(renderable *render.Renderable, color mgl32.Vec4, model mgl32.Mat4, time float32) []*render.Command {
+	command := &render.Command{}
+	command.Uniform("uModel", model)
+	command.Uniform("uColor", color)
+	gravity := mgl32.Vec2{0, -300}
+	command.Uniform("uGravity", gravity)
+	command.Uniform("uTime", time)
+	command.Renderable(renderable)
+	return []*render.Command{
+		command,
+	}
+}
+
+func drawTrails(renderable *render.Renderable, color mgl32.Vec4, model mgl32.Mat4, time float32) []*render.Command {
+	command := &render.Command{}
+	command.Uniform("uModel", model)
+	command.Uniform("uColor", color)
+	command.Uniform("uSegments", float32(32))
+	command.Uniform("uTime", time)
+	command.Renderable(renderable)
+	return []*render.Command{
+		command,
+	}
+}
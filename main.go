@@ -1,7 +1,11 @@
 package main
 
 import (
+	"flag"
+	"math"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"runtime"
 	"time"
 
@@ -10,65 +14,53 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/unchartedsoftware/plog"
 
+	"github.com/kbirk/cauldron/particle"
+	"github.com/kbirk/cauldron/post"
 	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/trace"
+	"github.com/kbirk/cauldron/render/window"
+	"github.com/kbirk/cauldron/resources"
 	"github.com/kbirk/cauldron/shape"
 )
 
 const (
-	windowWidth  = 1200
-	windowHeight = 800
+	windowWidth   = 1200
+	windowHeight  = 800
+	cameraBinding = 0
+	emberCount    = 400
+	emberLifetime = 4.0
 )
 
 var (
-	viewport           *render.Viewport
 	camera             *render.Transform
 	explosionTechnique *render.Technique
 	smokeTechnique     *render.Technique
 	shockwaveTechnique *render.Technique
+	sparkTechnique     *render.Technique
+	trailTechnique     *render.Technique
+	emberTechnique     *render.Technique
+	fadeTechnique      *render.Technique
+	compositeTechnique *render.Technique
+	feedback           [2]*render.FrameBuffer
+	scene              *render.FrameBuffer
+	postStack          *post.Stack
+	queue              *render.Queue
+	explosionBatch     *Batch
+	smokeBatch         *Batch
+	shockwaveBatch     *Batch
+	startTime          time.Time
+	shaderWatcher      *render.ShaderWatcher
+	cameraBuffer       *render.UniformBuffer
 	effects            []*Effect
 	projection         mgl32.Mat4
 	view               mgl32.Mat4
 )
 
-// Effect represents an animated effect.
-type Effect struct {
-	Explosion *render.Renderable
-	Smoke     *render.Renderable
-	Shockwave *render.Renderable
-	Time      time.Time
-	Position  mgl32.Vec2
-}
-
-// Draw renders the effect based on the provided time value.
-func (e *Effect) Draw(now time.Time) {
-	// time relative to start of effect
-	t := float32(now.Sub(e.Time).Seconds())
-	// model matrix
-	model := mgl32.Translate3D(e.Position[0], e.Position[1], 0.0)
-	shockwaveTechnique.Draw(
-		drawShockwave(
-			e.Shockwave,
-			mgl32.Vec4{1.0, 0.98, 0.96, 0.2},
-			projection,
-			view,
-			model,
-			t))
-	smokeTechnique.Draw(
-		drawSmoke(
-			e.Smoke,
-			mgl32.Vec4{0.41, 0.4, 0.39, 0.2},
-			projection,
-			view,
-			model,
-			t))
-	explosionTechnique.Draw(
-		drawExplosion(
-			e.Explosion,
-			mgl32.Vec4{0.8, 0.4, 0.2, 0.8},
-			projection,
-			view,
-			model,
-			t))
+// CameraUniforms represents the values of the camera uniform block shared by
+// all shaders.
+type CameraUniforms struct {
+	Projection mgl32.Mat4 `uniform:"uProjection"`
+	View       mgl32.Mat4 `uniform:"uView"`
 }
 
 func init() {
@@ -76,74 +68,181 @@ func init() {
 	runtime.LockOSThread()
 }
 
-func newFlatTechnique(viewport *render.Viewport) (*render.Technique, error) {
+func newFlatTechnique() (*render.Technique, error) {
 	// create shader
 	shader, err := render.NewVertFragShader(
-		"resources/shaders/flat.vert",
-		"resources/shaders/flat.frag")
+		"shaders/flat.vert",
+		"shaders/flat.frag")
 	if err != nil {
 		return nil, err
 	}
+	shaderWatcher.Watch(shader)
 	// create technique
 	technique := render.NewTechnique()
 	technique.Enable(gl.BLEND)
 	technique.Shader(shader)
+	technique.UniformBuffer(cameraBuffer)
 	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-	technique.Viewport(viewport)
 	return technique, nil
 }
 
-func newExplosionTechnique(viewport *render.Viewport) (*render.Technique, error) {
-	// create shader
-	shader, err := render.NewVertFragShader(
-		"resources/shaders/particle.vert",
-		"resources/shaders/particle.frag")
+func newParticleLibrary() *render.ShaderLibrary {
+	// explosion and smoke are permutations of the particle shader
+	library := render.NewShaderLibrary(
+		"shaders/particle.vert",
+		"shaders/particle.frag")
+	shaderWatcher.WatchLibrary(library)
+	return library
+}
+
+func newExplosionTechnique(library *render.ShaderLibrary) (*render.Technique, error) {
+	// compile the permutation up front to report errors on startup
+	_, err := library.Variant(nil)
 	if err != nil {
 		return nil, err
 	}
 	// create technique
 	technique := render.NewTechnique()
 	technique.Enable(gl.BLEND)
-	technique.Shader(shader)
+	technique.ShaderLibrary(library)
+	technique.UniformBuffer(cameraBuffer)
 	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE)
-	technique.Viewport(viewport)
 	return technique, nil
 }
 
-func newSmokeTechnique(viewport *render.Viewport) (*render.Technique, error) {
+func newSmokeTechnique(library *render.ShaderLibrary) (*render.Technique, error) {
+	defines := map[string]string{
+		"RISE": "",
+		"FADE": "",
+	}
+	// compile the permutation up front to report errors on startup
+	_, err := library.Variant(defines)
+	if err != nil {
+		return nil, err
+	}
+	// create technique
+	technique := render.NewTechnique()
+	technique.Enable(gl.BLEND)
+	technique.ShaderLibrary(library)
+	technique.UniformBuffer(cameraBuffer)
+	technique.Variant(defines)
+	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	return technique, nil
+}
+
+func newShockwaveTechnique() (*render.Technique, error) {
 	// create shader
 	shader, err := render.NewVertFragShader(
-		"resources/shaders/smoke.vert",
-		"resources/shaders/smoke.frag")
+		"shaders/shockwave.vert",
+		"shaders/shockwave.frag")
 	if err != nil {
 		return nil, err
 	}
+	shaderWatcher.Watch(shader)
 	// create technique
 	technique := render.NewTechnique()
 	technique.Enable(gl.BLEND)
 	technique.Shader(shader)
+	technique.UniformBuffer(cameraBuffer)
 	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-	technique.Viewport(viewport)
 	return technique, nil
 }
 
-func newShockwaveTechnique(viewport *render.Viewport) (*render.Technique, error) {
+func newSparkTechnique() (*render.Technique, error) {
+	// sparks are expanded from points into quads by the geometry stage
+	shader, err := render.NewShader(map[uint32]string{
+		gl.VERTEX_SHADER:   "shaders/spark.vert",
+		gl.GEOMETRY_SHADER: "shaders/spark.geom",
+		gl.FRAGMENT_SHADER: "shaders/spark.frag",
+	})
+	if err != nil {
+		return nil, err
+	}
+	shaderWatcher.Watch(shader)
+	// create technique
+	technique := render.NewTechnique()
+	technique.Enable(gl.BLEND)
+	technique.Shader(shader)
+	technique.UniformBuffer(cameraBuffer)
+	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+	return technique, nil
+}
+
+func newTrailTechnique() (*render.Technique, error) {
+	// trails are cubic bezier curves subdivided by the tessellation stages
+	shader, err := render.NewShader(map[uint32]string{
+		gl.VERTEX_SHADER:          "shaders/trail.vert",
+		gl.TESS_CONTROL_SHADER:    "shaders/trail.tesc",
+		gl.TESS_EVALUATION_SHADER: "shaders/trail.tese",
+		gl.FRAGMENT_SHADER:        "shaders/trail.frag",
+	})
+	if err != nil {
+		return nil, err
+	}
+	shaderWatcher.Watch(shader)
+	// create technique
+	technique := render.NewTechnique()
+	technique.Enable(gl.BLEND)
+	technique.Shader(shader)
+	technique.UniformBuffer(cameraBuffer)
+	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+	return technique, nil
+}
+
+func newEmberTechnique(library *render.ShaderLibrary) (*render.Technique, error) {
+	defines := map[string]string{
+		"SIMULATED": "",
+	}
+	// compile the permutation up front to report errors on startup
+	_, err := library.Variant(defines)
+	if err != nil {
+		return nil, err
+	}
+	// create technique
+	technique := render.NewTechnique()
+	technique.Enable(gl.BLEND)
+	technique.ShaderLibrary(library)
+	technique.UniformBuffer(cameraBuffer)
+	technique.Variant(defines)
+	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+	return technique, nil
+}
+
+func newTextureTechnique() (*render.Technique, error) {
 	// create shader
 	shader, err := render.NewVertFragShader(
-		"resources/shaders/shockwave.vert",
-		"resources/shaders/shockwave.frag")
+		"shaders/texture.vert",
+		"shaders/texture.frag")
 	if err != nil {
 		return nil, err
 	}
-	// create technique
+	shaderWatcher.Watch(shader)
+	// create technique, textures hold premultiplied colors
 	technique := render.NewTechnique()
 	technique.Enable(gl.BLEND)
 	technique.Shader(shader)
-	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-	technique.Viewport(viewport)
+	technique.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
 	return technique, nil
 }
 
+// createFrameBuffer returns a framebuffer with a color texture of half float
+// components, holding colors brighter than white until they are tone mapped.
+func createFrameBuffer(width int, height int) (*render.FrameBuffer, error) {
+	texture, err := render.NewFloatTexture(uint32(width), uint32(height), &render.TextureParams{
+		MinFilter: gl.LINEAR,
+		MagFilter: gl.LINEAR,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fbo := render.NewFrameBuffer()
+	err = fbo.AttachTexture(gl.COLOR_ATTACHMENT0, texture)
+	if err != nil {
+		return nil, err
+	}
+	return fbo, nil
+}
+
 func randVec2() mgl32.Vec2 {
 	return mgl32.Vec2{
 		(rand.Float32()*2 - 1),
@@ -151,118 +250,51 @@ func randVec2() mgl32.Vec2 {
 	}.Normalize()
 }
 
-func createParticleRenderable(positions []float32, indices []uint16, offsets []float32, velocities []float32, sizes []float32) *render.Renderable {
-	// create vertexbuffer
-	vb := &render.VertexBuffer{}
-	vb.AllocateBuffer((len(positions) + len(offsets) + len(velocities) + len(sizes)) * 4)
-	vb.BufferSubFloat32(positions, 0)
-	vb.BufferSubFloat32(offsets, len(positions)*4)
-	vb.BufferSubFloat32(velocities, (len(positions)+len(offsets))*4)
-	vb.BufferSubFloat32(sizes, (len(positions)+len(offsets)+len(velocities))*4)
-	// create indexbuffer
-	ib := &render.IndexBuffer{}
-	ib.BufferUint16(indices)
-	// create renderable
-	renderable := &render.Renderable{}
-	renderable.SetVertexBuffer(vb)
-	renderable.SetIndexBuffer(ib)
-	renderable.SetPointer(0, &render.AttributePointer{
-		Type:       gl.FLOAT,
-		Size:       3,
-		ByteOffset: 0,
-	})
-	renderable.SetPointer(1, &render.AttributePointer{
-		Type:       gl.FLOAT,
-		Size:       2,
-		ByteOffset: len(positions) * 4,
-	})
-	renderable.SetPointer(2, &render.AttributePointer{
-		Type:       gl.FLOAT,
-		Size:       2,
-		ByteOffset: (len(positions) + len(offsets)) * 4,
-	})
-	renderable.SetPointer(3, &render.AttributePointer{
-		Type:       gl.FLOAT,
-		Size:       1,
-		ByteOffset: (len(positions) + len(offsets) + len(velocities)) * 4,
-	})
-	renderable.SetInstancedAttributes([]uint32{
-		1, 2, 3,
-	})
-	renderable.SetDrawElementsInstanced(
-		gl.TRIANGLES,
-		int32(len(indices)),
-		gl.UNSIGNED_SHORT,
-		0,
-		int32(len(offsets)/2))
-	renderable.Upload()
-	return renderable
-}
-
-func createExplosion(num int, radius float32, force float32, size float32) *render.Renderable {
+func createEmbers(num int, origin mgl32.Vec2, size float32) (*particle.System, error) {
+	// embers are stepped on the GPU and persist between frames
+	step, err := particle.NewStepShader("shaders/particle_step.vert")
+	if err != nil {
+		return nil, err
+	}
+	shaderWatcher.Watch(step)
+	// stagger the emission of the embers over their lifetime
+	particles := make([]particle.Particle, num)
+	for i := range particles {
+		particles[i] = particle.Particle{
+			Position: origin,
+			Velocity: randVec2().Add(mgl32.Vec2{0, 3}).Normalize().Mul(60),
+			Age:      -rand.Float32() * emberLifetime,
+			Size:     ((rand.Float32() * 0.5) + 0.5) * size,
+		}
+	}
 	positions, indices := shape.Quad(size, true, false)
-	offsets := make([]float32, 2*num)
-	for i := 0; i < num; i++ {
-		offset := randVec2().Mul(rand.Float32() * radius)
-		offsets[i*2] = offset[0]
-		offsets[i*2+1] = offset[1]
-	}
-	velocities := make([]float32, 2*num)
-	direction := mgl32.Vec2{0, force * 2}
-	for i := 0; i < num; i++ {
-		velocity := randVec2().Mul(rand.Float32() * force).Add(direction)
-		velocities[i*2] = velocity[0]
-		velocities[i*2+1] = velocity[1]
-	}
-	sizes := make([]float32, num)
-	for i := 0; i < num; i++ {
-		// size
-		sizes[i] = rand.Float32() * size
-	}
-	return createParticleRenderable(
-		positions,
-		indices,
-		offsets,
-		velocities,
-		sizes)
-}
-
-func createSmoke(num int, radius float32, force float32, size float32) *render.Renderable {
-	positions, indices := shape.Circle(size, 64, true, false)
-	offsets := make([]float32, 2*num)
-	for i := 0; i < num; i++ {
-		offset := randVec2().Mul(rand.Float32() * radius)
-		offsets[i*2] = offset[0]
-		offsets[i*2+1] = offset[1]
-	}
-	velocities := make([]float32, 2*num)
-	direction := mgl32.Vec2{0, force * 1.5}
-	for i := 0; i < num; i++ {
-		velocity := randVec2().Mul(rand.Float32() * force).Add(direction)
-		velocities[i*2] = velocity[0]
-		velocities[i*2+1] = velocity[1]
-	}
-	sizes := make([]float32, num)
-	for i := 0; i < num; i++ {
-		// size
-		sizes[i] = ((rand.Float32() * 0.5) + 0.5) * size
-	}
-	return createParticleRenderable(
-		positions,
-		indices,
-		offsets,
-		velocities,
-		sizes)
+	embers, err := particle.NewSystem(step, particles, positions, indices)
+	if err != nil {
+		return nil, err
+	}
+	embers.Uniform("uLifetime", float32(emberLifetime))
+	embers.Uniform("uOrigin", origin)
+	embers.Uniform("uSpeed", float32(80))
+	embers.Uniform("uSpread", float32(1.2))
+	embers.Uniform("uGravity", mgl32.Vec2{0, 4})
+	embers.Uniform("uDrag", float32(0.3))
+	return embers, nil
 }
 
-func createQuad(size float32) *render.Renderable {
+func createQuad(size float32) (*render.Renderable, error) {
 	vertices, indices := shape.Quad(size, true, true)
 	// create vertexbuffer
 	vb := &render.VertexBuffer{}
-	vb.BufferFloat32(vertices)
+	err := vb.BufferFloat32(vertices)
+	if err != nil {
+		return nil, err
+	}
 	// create indexbuffer
 	ib := &render.IndexBuffer{}
-	ib.BufferUint16(indices)
+	err = ib.BufferUint16(indices)
+	if err != nil {
+		return nil, err
+	}
 	// create renderable
 	quad := &render.Renderable{}
 	quad.SetVertexBuffer(vb)
@@ -280,45 +312,17 @@ func createQuad(size float32) *render.Renderable {
 		ByteOffset: 3 * 4,
 	})
 	quad.SetDrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_SHORT, 0)
-	quad.Upload()
-	return quad
-}
-
-func createCircle(radius float32, segments int) *render.Renderable {
-	vertices, indices := shape.Circle(radius, segments, true, true)
-	// create vertexbuffer
-	vb := &render.VertexBuffer{}
-	vb.BufferFloat32(vertices)
-	// create indexbuffer
-	ib := &render.IndexBuffer{}
-	ib.BufferUint16(indices)
-	// create renderable
-	circle := &render.Renderable{}
-	circle.SetVertexBuffer(vb)
-	circle.SetIndexBuffer(ib)
-	circle.SetPointer(0, &render.AttributePointer{
-		Type:       gl.FLOAT,
-		Size:       3,
-		ByteStride: (3 + 2) * 4,
-		ByteOffset: 0,
-	})
-	circle.SetPointer(1, &render.AttributePointer{
-		Type:       gl.FLOAT,
-		Size:       2,
-		ByteStride: (3 + 2) * 4,
-		ByteOffset: 3 * 4,
-	})
-	circle.SetDrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_SHORT, 0)
-	circle.Upload()
-	return circle
+	err = quad.Upload()
+	if err != nil {
+		return nil, err
+	}
+	return quad, nil
 }
 
-func drawFlat(renderable *render.Renderable, color mgl32.Vec4, projection, view, model mgl32.Mat4) []*render.Command {
+func drawFlat(renderable *render.Renderable, color mgl32.Vec4, model mgl32.Mat4) []*render.Command {
 	command := &render.Command{}
-	command.Uniform("uProjection", &projection[0])
-	command.Uniform("uView", &view[0])
-	command.Uniform("uModel", &model[0])
-	command.Uniform("uColor", &color[0])
+	command.Uniform("uModel", model)
+	command.Uniform("uColor", color)
 	command.Renderable(renderable)
 	return []*render.Command{
 		command,
@@ -326,7 +330,9 @@ func drawFlat(renderable *render.Renderable, color mgl32.Vec4, projection, view,
 }
 
 func drawFBO(renderable *render.Renderable, fbo *render.FrameBuffer, opacity float32) []*render.Command {
+	texture, _ := fbo.Texture(gl.COLOR_ATTACHMENT0)
 	command := &render.Command{}
+	command.Uniform("uTexture", texture)
 	command.Uniform("uOpacity", opacity)
 	command.Renderable(renderable)
 	return []*render.Command{
@@ -334,44 +340,11 @@ func drawFBO(renderable *render.Renderable, fbo *render.FrameBuffer, opacity flo
 	}
 }
 
-func drawExplosion(renderable *render.Renderable, color mgl32.Vec4, projection, view, model mgl32.Mat4, time float32) []*render.Command {
-	command := &render.Command{}
-	command.Uniform("uProjection", &projection[0])
-	command.Uniform("uView", &view[0])
-	command.Uniform("uModel", &model[0])
-	command.Uniform("uColor", &color[0])
-	gravity := mgl32.Vec2{0, -200}
-	command.Uniform("uGravity", &gravity[0])
-	command.Uniform("uTime", time)
-	command.Renderable(renderable)
-	return []*render.Command{
-		command,
-	}
-}
-
-func drawSmoke(renderable *render.Renderable, color mgl32.Vec4, projection, view, model mgl32.Mat4, time float32) []*render.Command {
+func drawEmbers(renderable *render.Renderable, color mgl32.Vec4, model mgl32.Mat4) []*render.Command {
 	command := &render.Command{}
-	command.Uniform("uProjection", &projection[0])
-	command.Uniform("uView", &view[0])
-	command.Uniform("uModel", &model[0])
-	command.Uniform("uColor", &color[0])
-	rise := mgl32.Vec2{0, 10}
-	command.Uniform("uRise", &rise[0])
-	command.Uniform("uTime", time)
-	command.Renderable(renderable)
-	return []*render.Command{
-		command,
-	}
-}
-
-func drawShockwave(renderable *render.Renderable, color mgl32.Vec4, projection, view, model mgl32.Mat4, time float32) []*render.Command {
-	command := &render.Command{}
-	command.Uniform("uProjection", &projection[0])
-	command.Uniform("uView", &view[0])
-	command.Uniform("uModel", &model[0])
-	command.Uniform("uColor", &color[0])
-	command.Uniform("uForce", float32(150.0))
-	command.Uniform("uTime", time)
+	command.Uniform("uModel", model)
+	command.Uniform("uColor", color)
+	command.Uniform("uLifetime", float32(emberLifetime))
 	command.Renderable(renderable)
 	return []*render.Command{
 		command,
@@ -382,95 +355,262 @@ func handleKey(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, m
 	if key == glfw.KeyEscape && action == glfw.Press {
 		w.SetShouldClose(true)
 	}
+	if action != glfw.Press {
+		return
+	}
+	// toggle post-processing effects
+	switch key {
+	case glfw.Key1:
+		postStack.Blur.Enabled = !postStack.Blur.Enabled
+		log.Infof("blur: %t", postStack.Blur.Enabled)
+	case glfw.Key2:
+		postStack.Bloom.Enabled = !postStack.Bloom.Enabled
+		log.Infof("bloom: %t", postStack.Bloom.Enabled)
+	case glfw.Key3:
+		postStack.Vignette.Enabled = !postStack.Vignette.Enabled
+		log.Infof("vignette: %t", postStack.Vignette.Enabled)
+	case glfw.Key4:
+		postStack.ToneMap.Enabled = !postStack.ToneMap.Enabled
+		log.Infof("tone mapping: %t", postStack.ToneMap.Enabled)
+	}
 }
 
 func handleMouseButton(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey) {
 	if action == glfw.Press {
 		x, y := w.GetCursorPos()
 		_, height := w.GetSize()
-		effects = append(effects, &Effect{
-			Explosion: createExplosion(200, 20, 200, 4),
-			Smoke:     createSmoke(200, 20, 140, 10),
-			Shockwave: createCircle(1.0, 64),
-			Position:  mgl32.Vec2{float32(x), float32(float64(height) - y)},
-			Time:      time.Now(),
-		})
+		effect, err := NewEffect(mgl32.Vec2{float32(x), float32(float64(height) - y)}, time.Now())
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		effects = append(effects, effect)
 	}
 }
 
 func handleResize(w *glfw.Window, width int, height int) {
 	log.Info("resize")
-	viewport.Width = int32(width)
-	viewport.Height = int32(height)
+	// viewports and post-processing follow the framebuffers, only the scene
+	// and trails need resizing
+	for _, fbo := range []*render.FrameBuffer{scene, feedback[0], feedback[1]} {
+		err := fbo.Resize(uint32(width), uint32(height))
+		if err != nil {
+			log.Error(err)
+		}
+	}
 }
 
-// draw copy framebuffer color texture to a new texture
-// clear framebuffer color texture
-// draw texture at reduced opacity to FrameBuffer
-// draw
+// setAssets reads assets from the provided directory on disk, falling back to
+// the embedded defaults for files it does not have.
+func setAssets(dir string) {
+	if dir == "" {
+		render.SetAssets(resources.FileSystem())
+		return
+	}
+	render.SetAssets(render.Overlay(render.Dir(dir), resources.FileSystem()))
+}
 
 func main() {
 
-	// init glfw
-	err := glfw.Init()
-	if err != nil {
-		log.Error("Failed to initialize glfw:", err)
+	// replay a recorded trace instead
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		err := replay(os.Args[2:])
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		return
 	}
-	defer glfw.Terminate()
 
-	// set window hints
-	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 4)
-	glfw.WindowHint(glfw.ContextVersionMinor, 1)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	// parse flags
+	traceFile := flag.String("trace", "", "record all frames to the provided trace file")
+	logStats := flag.Bool("stats", false, "log render statistics every second")
+	shaderCache := flag.String("shadercache", filepath.Join(os.TempDir(), "cauldron", "shaders"), "directory of cached program binaries, empty to disable")
+	assetDir := flag.String("assets", "resources", "directory of assets overriding the embedded defaults, empty to only use the embedded defaults")
+	blur := flag.Bool("blur", false, "blur the scene, toggled with 1")
+	bloom := flag.Bool("bloom", true, "bloom the bright parts of the scene, toggled with 2")
+	vignette := flag.Bool("vignette", true, "darken the corners of the scene, toggled with 3")
+	toneMap := flag.Bool("tonemap", true, "tone map the scene, toggled with 4")
+	flag.Parse()
+
+	// read assets from disk, falling back to the embedded defaults
+	setAssets(*assetDir)
 
 	// create window
-	window, err := glfw.CreateWindow(windowWidth, windowHeight, "cauldron", nil, nil)
+	window, err := window.New(windowWidth, windowHeight, "cauldron")
 	if err != nil {
-		log.Error("Failed to create window:", err)
+		log.Error(err)
 		return
 	}
-	window.MakeContextCurrent()
+	defer window.Destroy()
 	window.SetKeyCallback(handleKey)
 	window.SetMouseButtonCallback(handleMouseButton)
 	window.SetFramebufferSizeCallback(handleResize)
 
-	// init glow
-	err = gl.Init()
+	// make context current and init glow
+	err = render.InitContext(window)
 	if err != nil {
-		log.Error("Failed to init glow:", err)
+		log.Error(err)
 		return
 	}
 
 	// log opengl version
-	version := gl.GoStr(gl.GetString(gl.VERSION))
+	version := render.CurrentBackend().GetString(gl.VERSION)
 	log.Info("OpenGL version", version)
 
-	// create viewport
-	viewportWidth, viewportHeight := window.GetFramebufferSize()
-	viewport = &render.Viewport{
-		X:      0,
-		Y:      0,
-		Width:  int32(viewportWidth),
-		Height: int32(viewportHeight),
+	// record frames, this must happen before any render objects are created
+	var recorder *trace.Recorder
+	if *traceFile != "" {
+		file, err := os.Create(*traceFile)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		defer file.Close()
+		width, height := window.FramebufferSize()
+		recorder, err = trace.NewRecorder(render.CurrentBackend(), file, width, height)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		render.SetBackend(recorder)
+		log.Infof("recording trace to `%s`", *traceFile)
+	}
+
+	// load linked programs from the cache, traces must link from source
+	if *shaderCache != "" && recorder == nil {
+		cache, err := render.NewProgramCache(*shaderCache)
+		if err != nil {
+			log.Warnf("failed to create shader cache: %v", err)
+		} else {
+			render.SetProgramCache(cache)
+		}
 	}
 
 	// create camera
 	camera = render.NewTransform()
 
+	// reload shaders when their source files change
+	shaderWatcher = render.NewShaderWatcher(500 * time.Millisecond)
+
+	// camera uniforms are uploaded once per frame and shared by all shaders
+	render.SetUniformBlockBinding("Camera", cameraBinding)
+	cameraBuffer = render.NewUniformBuffer(
+		render.NewUniformBlockDescriptor("Camera", []render.UniformDescriptor{
+			{Name: "uProjection", Type: gl.FLOAT_MAT4},
+			{Name: "uView", Type: gl.FLOAT_MAT4},
+		}),
+		cameraBinding)
+
 	// create techniques
-	explosionTechnique, err = newExplosionTechnique(viewport)
+	particleLibrary := newParticleLibrary()
+	explosionTechnique, err = newExplosionTechnique(particleLibrary)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	smokeTechnique, err = newSmokeTechnique(particleLibrary)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	shockwaveTechnique, err = newShockwaveTechnique()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	sparkTechnique, err = newSparkTechnique()
 	if err != nil {
 		log.Error(err)
 		return
 	}
-	smokeTechnique, err = newSmokeTechnique(viewport)
+	trailTechnique, err = newTrailTechnique()
 	if err != nil {
 		log.Error(err)
 		return
 	}
-	shockwaveTechnique, err = newShockwaveTechnique(viewport)
+	emberTechnique, err = newEmberTechnique(particleLibrary)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	// the scene is cleared by the first pass drawn to it
+	emberTechnique.ClearColor(0.1, 0.1, 0.1, 1.0)
+	emberTechnique.Clear(gl.COLOR_BUFFER_BIT|gl.DEPTH_BUFFER_BIT, render.ClearOncePerFrame)
+	fadeTechnique, err = newTextureTechnique()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	fadeTechnique.Clear(gl.COLOR_BUFFER_BIT, render.ClearEveryDraw)
+	compositeTechnique, err = newTextureTechnique()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	// the scene is drawn into a framebuffer and post-processed to the screen
+	framebufferWidth, framebufferHeight := window.FramebufferSize()
+	scene, err = createFrameBuffer(framebufferWidth, framebufferHeight)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	defer scene.Destroy()
+	for _, technique := range []*render.Technique{
+		emberTechnique,
+		explosionTechnique,
+		smokeTechnique,
+		shockwaveTechnique,
+		compositeTechnique,
+	} {
+		technique.FrameBuffer(scene)
+	}
+	postStack, err = post.NewStack(scene)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	defer postStack.Destroy()
+	postStack.Watch(shaderWatcher)
+	postStack.Blur.Enabled = *blur
+	postStack.Bloom.Enabled = *bloom
+	postStack.Vignette.Enabled = *vignette
+	postStack.ToneMap.Enabled = *toneMap
+
+	// effects are queued and drawn sorted by layer, blended layers are
+	// drawn back to front
+	queue = render.NewQueue()
+	queue.Transparent(layerShockwaves, true)
+	queue.Transparent(layerSmoke, true)
+
+	// explosions, smoke and shockwaves of every live effect are drawn in a
+	// single instanced draw per layer
+	err = createBatches()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	defer explosionBatch.Destroy()
+	defer smokeBatch.Destroy()
+	defer shockwaveBatch.Destroy()
+	defer func() {
+		for _, effect := range effects {
+			effect.Destroy()
+		}
+	}()
+
+	// sparks and trails leave fading trails by drawing into ping-pong
+	// framebuffers that each frame starts from the faded previous one
+	for i := range feedback {
+		feedback[i], err = createFrameBuffer(framebufferWidth, framebufferHeight)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		defer feedback[i].Destroy()
+	}
+	screenQuad, err := createQuad(2)
 	if err != nil {
 		log.Error(err)
 		return
@@ -486,37 +626,131 @@ func main() {
 	// view matrix
 	view = camera.ViewMatrix()
 
-	// Configure global settings
-	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+	// create embers rising from the bottom of the window
+	embers, err := createEmbers(emberCount, mgl32.Vec2{float32(width) / 2, 0}, 3)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	defer embers.Destroy()
 
 	// frame loop
+	startTime = time.Now()
+	lastStats := startTime
+	lastFrame := startTime
 	for !window.ShouldClose() {
 
+		// reset render statistics
+		render.ResetStats()
+
 		// poll events
 		glfw.PollEvents()
 
-		// clear buffer
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+		// reload modified shaders
+		for _, err := range shaderWatcher.Poll() {
+			log.Error(err)
+		}
+
+		// start the frame, passes clearing once per frame clear again
+		render.DefaultContext().BeginFrame()
+
+		// update camera uniforms
+		err := cameraBuffer.SetStruct(&CameraUniforms{
+			Projection: projection,
+			View:       view,
+		})
+		if err != nil {
+			log.Error(err)
+		}
 
 		// grab current time
 		now := time.Now()
+		// clamp the step so a stalled frame does not scatter the embers
+		delta := float32(math.Min(now.Sub(lastFrame).Seconds(), 0.1))
+		lastFrame = now
+
+		// step embers, with a gusting wind
+		elapsed := now.Sub(startTime).Seconds()
+		embers.Uniform("uWind", mgl32.Vec2{float32(math.Sin(elapsed*0.7) * 30), 0})
+		err = embers.Step(delta)
+		if err != nil {
+			log.Error(err)
+		}
+
+		// draw embers
+		err = emberTechnique.Draw(
+			drawEmbers(
+				embers.Renderable(),
+				mgl32.Vec4{1.0, 0.5, 0.2, 0.8},
+				mgl32.Ident4()))
+		if err != nil {
+			log.Error(err)
+		}
+
+		// fade the previous trails into the current target
+		previous, current := feedback[0], feedback[1]
+		feedback[0], feedback[1] = current, previous
+		fadeTechnique.FrameBuffer(current)
+		sparkTechnique.FrameBuffer(current)
+		trailTechnique.FrameBuffer(current)
+		err = fadeTechnique.Draw(drawFBO(screenQuad, previous, 0.85))
+		if err != nil {
+			log.Error(err)
+		}
 
 		// draw animations
+		err = queueBatches(queue, float32(elapsed))
+		if err != nil {
+			log.Error(err)
+		}
 		for _, effect := range effects {
-			effect.Draw(now)
+			effect.Queue(queue, now)
+		}
+		err = queue.Flush()
+		if err != nil {
+			log.Error(err)
+		}
+
+		// composite the trails over the scene
+		err = compositeTechnique.Draw(drawFBO(screenQuad, current, 1.0))
+		if err != nil {
+			log.Error(err)
+		}
+
+		// post-process the scene to the screen
+		err = postStack.Draw()
+		if err != nil {
+			log.Error(err)
 		}
 
 		// remove stale effects
 		j := 0
 		for i := 0; i < len(effects); i++ {
-			if now.Sub(effects[i].Time).Seconds() < 3.0 {
+			if !effects[i].Expired(now) {
 				effects[j] = effects[i]
 				j++
+				continue
 			}
+			effects[i].Destroy()
 		}
 		effects = effects[:j]
 
+		// log render statistics
+		if *logStats && now.Sub(lastStats).Seconds() >= 1.0 {
+			log.Infof("%+v", render.FrameStats())
+			lastStats = now
+		}
+
 		// swap buffers
 		window.SwapBuffers()
+
+		// end recorded frame
+		if recorder != nil {
+			err := recorder.EndFrame()
+			if err != nil {
+				log.Error(err)
+				return
+			}
+		}
 	}
 }
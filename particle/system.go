@@ -0,0 +1,183 @@
+package particle
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+const (
+	// stride is the number of floats per particle.
+	stride = 6
+)
+
+var (
+	// varyings lists the outputs of the step shader in the layout of a
+	// particle.
+	varyings = []string{
+		"vPosition",
+		"vVelocity",
+		"vAge",
+		"vSize",
+	}
+	// layout is the layout of a particle read by the step shader.
+	layout = []*render.AttributePointer{
+		{Index: 0, Type: gl.FLOAT, Size: 2, ByteStride: stride * 4, ByteOffset: 0},
+		{Index: 1, Type: gl.FLOAT, Size: 2, ByteStride: stride * 4, ByteOffset: 2 * 4},
+		{Index: 2, Type: gl.FLOAT, Size: 1, ByteStride: stride * 4, ByteOffset: 4 * 4},
+		{Index: 3, Type: gl.FLOAT, Size: 1, ByteStride: stride * 4, ByteOffset: 5 * 4},
+	}
+)
+
+// Particle represents the state of a single particle. A particle with a
+// negative age has not been emitted yet.
+type Particle struct {
+	Position mgl32.Vec2
+	Velocity mgl32.Vec2
+	Age      float32
+	Size     float32
+}
+
+// NewStepShader instantiates a new shader stepping particles from the
+// provided vertex shader file or GLSL. The shader reads the position,
+// velocity, age and size of a particle from locations 0 to 3 and writes the
+// stepped particle to vPosition, vVelocity, vAge and vSize.
+func NewStepShader(vert string) (*render.Shader, error) {
+	return render.NewFeedbackShader(map[uint32]string{
+		gl.VERTEX_SHADER: vert,
+	}, varyings)
+}
+
+// System represents particles whose state persists on the GPU between
+// frames. Each step runs the step shader over every particle with transform
+// feedback, and the stepped particles are drawn as instances of a shape
+// without leaving the GPU.
+type System struct {
+	feedback    *render.TransformFeedback
+	vertices    *render.VertexBuffer
+	indices     *render.IndexBuffer
+	renderables [2]*render.Renderable
+}
+
+// NewSystem instantiates and returns a new particle system stepped by the
+// provided step shader. Each particle is drawn as an instance of the shape
+// described by the vec3 vertex positions and indices. The shape is read
+// from location 0, and the position, velocity, size and age of the particle
+// from locations 1 to 4.
+func NewSystem(step *render.Shader, particles []Particle, vertices []float32, indices []uint16) (*System, error) {
+	if len(particles) == 0 {
+		return nil, fmt.Errorf("particle system has no particles")
+	}
+	feedback, err := render.NewTransformFeedback(step, layout)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]float32, 0, len(particles)*stride)
+	for _, p := range particles {
+		data = append(data,
+			p.Position[0], p.Position[1],
+			p.Velocity[0], p.Velocity[1],
+			p.Age,
+			p.Size)
+	}
+	err = feedback.BufferFloat32(data)
+	if err != nil {
+		feedback.Destroy()
+		return nil, err
+	}
+	s := &System{
+		feedback: feedback,
+		vertices: &render.VertexBuffer{},
+		indices:  &render.IndexBuffer{},
+	}
+	err = s.vertices.BufferFloat32(vertices)
+	if err != nil {
+		s.Destroy()
+		return nil, err
+	}
+	err = s.indices.BufferUint16(indices)
+	if err != nil {
+		s.Destroy()
+		return nil, err
+	}
+	// draw the instances from either buffer, whichever holds the last step
+	for i, buffer := range feedback.Buffers() {
+		renderable := &render.Renderable{}
+		renderable.SetVertexBuffer(s.vertices)
+		renderable.SetIndexBuffer(s.indices)
+		renderable.SetPointer(0, &render.AttributePointer{
+			Type: gl.FLOAT,
+			Size: 3,
+		})
+		renderable.SetPointer(1, instancePointer(buffer, 2, 0))
+		renderable.SetPointer(2, instancePointer(buffer, 2, 2))
+		renderable.SetPointer(3, instancePointer(buffer, 1, 5))
+		renderable.SetPointer(4, instancePointer(buffer, 1, 4))
+		renderable.SetInstancedAttributes([]uint32{
+			1, 2, 3, 4,
+		})
+		renderable.SetDrawElementsInstanced(
+			gl.TRIANGLES,
+			int32(len(indices)),
+			gl.UNSIGNED_SHORT,
+			0,
+			feedback.Count())
+		err := renderable.Upload()
+		if err != nil {
+			s.Destroy()
+			return nil, err
+		}
+		s.renderables[i] = renderable
+	}
+	return s, nil
+}
+
+// instancePointer returns a pointer reading a component of every particle
+// of the provided buffer.
+func instancePointer(buffer *render.VertexBuffer, size int32, offset int) *render.AttributePointer {
+	return &render.AttributePointer{
+		Type:       gl.FLOAT,
+		Size:       size,
+		ByteStride: stride * 4,
+		ByteOffset: offset * 4,
+		Buffer:     buffer,
+	}
+}
+
+// Uniform sets a uniform of the step shader to be buffered for every step.
+func (s *System) Uniform(name string, value interface{}) {
+	s.feedback.Uniform(name, value)
+}
+
+// Step advances every particle by the provided number of seconds, buffered
+// to the uDelta uniform of the step shader.
+func (s *System) Step(delta float32) error {
+	s.feedback.Uniform("uDelta", delta)
+	return s.feedback.Step()
+}
+
+// Renderable returns the renderable drawing the particles of the last step.
+func (s *System) Renderable() *render.Renderable {
+	return s.renderables[s.feedback.Current()]
+}
+
+// Count returns the number of particles.
+func (s *System) Count() int32 {
+	return s.feedback.Count()
+}
+
+// Destroy deallocates the particle system.
+func (s *System) Destroy() {
+	for i, renderable := range s.renderables {
+		if renderable != nil {
+			renderable.Destroy()
+			s.renderables[i] = nil
+		}
+	}
+	s.vertices.Destroy()
+	s.indices.Destroy()
+	s.feedback.Destroy()
+}
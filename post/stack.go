@@ -0,0 +1,368 @@
+package post
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/shape"
+)
+
+const (
+	// bloomScale is the size of the bloom textures relative to the screen.
+	bloomScale = 0.5
+)
+
+// Blur represents a separable Gaussian blur of the image.
+type Blur struct {
+	Enabled bool
+	// Sigma is the standard deviation of the kernel in pixels.
+	Sigma float32
+}
+
+// Bloom represents a glow around the parts of the image brighter than a
+// threshold, blurred at half resolution and added back to the image.
+type Bloom struct {
+	Enabled bool
+	// Threshold is the brightness above which pixels bloom.
+	Threshold float32
+	// Intensity scales the bloom added to the image.
+	Intensity float32
+	// Sigma is the standard deviation of the blur in half resolution pixels.
+	Sigma float32
+}
+
+// Vignette represents a darkening of the image towards its corners.
+type Vignette struct {
+	Enabled bool
+	// Radius is the distance from the center where darkening starts, with
+	// the corners at one.
+	Radius float32
+	// Softness is the distance over which the darkening reaches its strength.
+	Softness float32
+	// Strength is the darkening at the corners, from zero to one.
+	Strength float32
+}
+
+// ToneMap represents an exposure of the image followed by exponential tone
+// mapping, rolling highlights off instead of clipping them to white.
+type ToneMap struct {
+	Enabled bool
+	// Exposure scales the color before tone mapping.
+	Exposure float32
+}
+
+// Stack represents a chain of full-screen effects applied to the color
+// texture of a framebuffer and drawn to the default framebuffer. Effects are
+// toggled and configured through its fields between draws.
+type Stack struct {
+	Blur     Blur
+	Bloom    Bloom
+	Vignette Vignette
+	ToneMap  ToneMap
+
+	source    *render.FrameBuffer
+	vertices  *render.VertexBuffer
+	indices   *render.IndexBuffer
+	quad      *render.Renderable
+	blur      *render.Technique
+	bright    *render.Technique
+	composite *render.Technique
+	library   *render.ShaderLibrary
+	shaders   []*render.Shader
+	graph     *render.Graph
+	blurred   bool
+	bloomed   bool
+}
+
+// NewStack instantiates and returns a new stack over the color texture
+// attached to gl.COLOR_ATTACHMENT0 of the provided framebuffer, which should
+// be a float texture so tone mapping is the only conversion to the range of
+// the default framebuffer. Bloom, vignette and tone mapping are enabled by
+// default.
+func NewStack(source *render.FrameBuffer) (*Stack, error) {
+	s := &Stack{
+		Blur: Blur{
+			Sigma: 2,
+		},
+		Bloom: Bloom{
+			Enabled:   true,
+			Threshold: 0.6,
+			Intensity: 1.2,
+			Sigma:     4,
+		},
+		Vignette: Vignette{
+			Enabled:  true,
+			Radius:   0.6,
+			Softness: 0.6,
+			Strength: 0.5,
+		},
+		ToneMap: ToneMap{
+			Enabled:  true,
+			Exposure: 1.5,
+		},
+		source: source,
+	}
+	err := s.create()
+	if err != nil {
+		s.Destroy()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Stack) create() error {
+	// full-screen quad in clip space
+	vertices, indices := shape.Quad(2, true, true)
+	s.vertices = &render.VertexBuffer{}
+	err := s.vertices.BufferFloat32(vertices)
+	if err != nil {
+		return err
+	}
+	s.indices = &render.IndexBuffer{}
+	err = s.indices.BufferUint16(indices)
+	if err != nil {
+		return err
+	}
+	s.quad = &render.Renderable{}
+	s.quad.SetVertexBuffer(s.vertices)
+	s.quad.SetIndexBuffer(s.indices)
+	s.quad.SetPointer(0, &render.AttributePointer{
+		Type:       gl.FLOAT,
+		Size:       3,
+		ByteStride: (3 + 2) * 4,
+		ByteOffset: 0,
+	})
+	s.quad.SetPointer(1, &render.AttributePointer{
+		Type:       gl.FLOAT,
+		Size:       2,
+		ByteStride: (3 + 2) * 4,
+		ByteOffset: 3 * 4,
+	})
+	s.quad.SetDrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_SHORT, 0)
+	err = s.quad.Upload()
+	if err != nil {
+		return err
+	}
+	// create shaders
+	blur, err := render.NewVertFragShader(
+		"shaders/texture.vert",
+		"shaders/post/blur.frag")
+	if err != nil {
+		return err
+	}
+	s.shaders = append(s.shaders, blur)
+	bright, err := render.NewVertFragShader(
+		"shaders/texture.vert",
+		"shaders/post/bright.frag")
+	if err != nil {
+		return err
+	}
+	s.shaders = append(s.shaders, bright)
+	// the composite is permuted by the enabled effects
+	s.library = render.NewShaderLibrary(
+		"shaders/texture.vert",
+		"shaders/post/composite.frag")
+	_, err = s.library.Variant(s.defines())
+	if err != nil {
+		return err
+	}
+	// create techniques, every pass overwrites its whole target
+	s.blur = render.NewTechnique()
+	s.blur.Shader(blur)
+	s.bright = render.NewTechnique()
+	s.bright.Shader(bright)
+	s.composite = render.NewTechnique()
+	s.composite.ShaderLibrary(s.library)
+	return nil
+}
+
+// Watch reloads the shaders of the stack with the provided watcher.
+func (s *Stack) Watch(watcher *render.ShaderWatcher) {
+	for _, shader := range s.shaders {
+		watcher.Watch(shader)
+	}
+	watcher.WatchLibrary(s.library)
+}
+
+// Draw applies the enabled effects to the source and draws the result to
+// the default framebuffer.
+func (s *Stack) Draw() error {
+	s.update()
+	return s.graph.Execute()
+}
+
+// update rebuilds the graph if an effect with passes was toggled and selects
+// the composite of the enabled effects.
+func (s *Stack) update() {
+	if s.graph == nil || s.blurred != s.Blur.Enabled || s.bloomed != s.Bloom.Enabled {
+		s.build()
+	}
+	s.composite.Variant(s.defines())
+}
+
+// build creates the graph of the passes of the enabled effects.
+func (s *Stack) build() {
+	if s.graph != nil {
+		s.graph.Destroy()
+	}
+	s.graph = render.NewGraph()
+	s.blurred = s.Blur.Enabled
+	s.bloomed = s.Bloom.Enabled
+	// the empty name refers to the source
+	image := ""
+	// intermediate images keep the range of the source, the composite tone
+	// maps them to the default framebuffer
+	for _, name := range []string{"blur horizontal", "blurred"} {
+		s.graph.DeclareTexture(name, &render.TransientTexture{
+			Float: true,
+		})
+	}
+	if s.Blur.Enabled {
+		s.graph.AddPass("blur horizontal", s.blur, s.drawBlur(image, true, &s.Blur.Sigma)).
+			Read(inputs(image)...).
+			Write("blur horizontal")
+		s.graph.AddPass("blur vertical", s.blur, s.drawBlur("blur horizontal", false, &s.Blur.Sigma)).
+			Read("blur horizontal").
+			Write("blurred")
+		image = "blurred"
+	}
+	if s.Bloom.Enabled {
+		for _, name := range []string{"bright", "bloom horizontal", "bloom"} {
+			s.graph.DeclareTexture(name, &render.TransientTexture{
+				Scale: bloomScale,
+				Float: true,
+			})
+		}
+		s.graph.AddPass("bright", s.bright, s.drawBright(image)).
+			Read(inputs(image)...).
+			Write("bright")
+		s.graph.AddPass("bloom horizontal", s.blur, s.drawBlur("bright", true, &s.Bloom.Sigma)).
+			Read("bright").
+			Write("bloom horizontal")
+		s.graph.AddPass("bloom vertical", s.blur, s.drawBlur("bloom horizontal", false, &s.Bloom.Sigma)).
+			Read("bloom horizontal").
+			Write("bloom")
+	}
+	composite := s.graph.AddPass("composite", s.composite, s.drawComposite(image)).
+		Read(inputs(image)...).
+		Write(render.BackBuffer)
+	if s.Bloom.Enabled {
+		composite.Read("bloom")
+	}
+}
+
+func (s *Stack) defines() map[string]string {
+	defines := make(map[string]string)
+	if s.Bloom.Enabled {
+		defines["BLOOM"] = ""
+	}
+	if s.ToneMap.Enabled {
+		defines["TONEMAP"] = ""
+	}
+	if s.Vignette.Enabled {
+		defines["VIGNETTE"] = ""
+	}
+	return defines
+}
+
+// texture returns the named input, or the source for the empty name.
+func (s *Stack) texture(textures map[string]*render.Texture, name string) *render.Texture {
+	if name == "" {
+		texture, _ := s.source.Texture(gl.COLOR_ATTACHMENT0)
+		return texture
+	}
+	return textures[name]
+}
+
+func (s *Stack) command(texture *render.Texture) *render.Command {
+	command := &render.Command{}
+	command.Uniform("uTexture", texture)
+	command.Renderable(s.quad)
+	return command
+}
+
+func (s *Stack) drawBlur(name string, horizontal bool, sigma *float32) render.PassFunc {
+	return func(textures map[string]*render.Texture) []*render.Command {
+		texture := s.texture(textures, name)
+		// step one texel along the direction of the pass
+		direction := mgl32.Vec2{0, 1 / float32(texture.Height())}
+		if horizontal {
+			direction = mgl32.Vec2{1 / float32(texture.Width()), 0}
+		}
+		command := s.command(texture)
+		command.Uniform("uDirection", direction)
+		command.Uniform("uSigma", *sigma)
+		return []*render.Command{
+			command,
+		}
+	}
+}
+
+func (s *Stack) drawBright(name string) render.PassFunc {
+	return func(textures map[string]*render.Texture) []*render.Command {
+		command := s.command(s.texture(textures, name))
+		command.Uniform("uThreshold", s.Bloom.Threshold)
+		return []*render.Command{
+			command,
+		}
+	}
+}
+
+func (s *Stack) drawComposite(name string) render.PassFunc {
+	return func(textures map[string]*render.Texture) []*render.Command {
+		command := s.command(s.texture(textures, name))
+		if s.Bloom.Enabled {
+			command.Uniform("uBloom", textures["bloom"])
+			command.Uniform("uIntensity", s.Bloom.Intensity)
+		}
+		if s.ToneMap.Enabled {
+			command.Uniform("uExposure", s.ToneMap.Exposure)
+		}
+		if s.Vignette.Enabled {
+			command.Uniform("uRadius", s.Vignette.Radius)
+			command.Uniform("uSoftness", s.Vignette.Softness)
+			command.Uniform("uStrength", s.Vignette.Strength)
+		}
+		return []*render.Command{
+			command,
+		}
+	}
+}
+
+// inputs returns the graph textures read for the named image, none for the
+// source.
+func inputs(name string) []string {
+	if name == "" {
+		return nil
+	}
+	return []string{name}
+}
+
+// Destroy deallocates the stack. The source is not destroyed.
+func (s *Stack) Destroy() {
+	if s.graph != nil {
+		s.graph.Destroy()
+		s.graph = nil
+	}
+	if s.quad != nil {
+		s.quad.Destroy()
+		s.quad = nil
+	}
+	if s.vertices != nil {
+		s.vertices.Destroy()
+		s.vertices = nil
+	}
+	if s.indices != nil {
+		s.indices.Destroy()
+		s.indices = nil
+	}
+	for _, shader := range s.shaders {
+		shader.Destroy()
+	}
+	s.shaders = nil
+	if s.library != nil {
+		s.library.Destroy()
+		s.library = nil
+	}
+}
@@ -0,0 +1,378 @@
+package post
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/software"
+	"github.com/kbirk/cauldron/resources"
+)
+
+const (
+	testSize = 32
+)
+
+var (
+	textureUniform = render.UniformDescriptor{Name: "uTexture", Type: gl.SAMPLER_2D, Count: 1}
+)
+
+// draw represents a command issued through the stack backend.
+type draw struct {
+	fragment string
+	textures []uint32
+}
+
+// stackBackend represents a software backend that keeps the fragment source
+// and the textures of every command issued through it.
+type stackBackend struct {
+	*software.Backend
+	shaders  map[uint32]string
+	programs map[uint32]string
+	program  uint32
+	draws    []draw
+}
+
+func (b *stackBackend) CreateShader(typ uint32, source string) (uint32, error) {
+	shader, err := b.Backend.CreateShader(typ, source)
+	if err == nil && typ == gl.FRAGMENT_SHADER {
+		b.shaders[shader] = source
+	}
+	return shader, err
+}
+
+func (b *stackBackend) AttachShader(program uint32, shader uint32) {
+	source, ok := b.shaders[shader]
+	if ok {
+		b.programs[program] = source
+	}
+	b.Backend.AttachShader(program, shader)
+}
+
+func (b *stackBackend) BeginTechnique(marker *render.TechniqueMarker) {
+	b.program = marker.Program
+}
+
+func (b *stackBackend) BeginCommand(marker *render.CommandMarker) {
+	var textures []uint32
+	for _, texture := range marker.Textures {
+		textures = append(textures, texture.Texture)
+	}
+	b.draws = append(b.draws, draw{
+		fragment: b.programs[b.program],
+		textures: textures,
+	})
+}
+
+// processFile returns the source of the asset preprocessed with the defines.
+func processFile(t *testing.T, file string, defines map[string]string) string {
+	source, err := render.DefaultPreprocessor.WithDefines(defines).ProcessFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source.Text
+}
+
+// compositeDefines returns every combination of the composite defines.
+func compositeDefines() []map[string]string {
+	var combinations []map[string]string
+	for i := 0; i < 8; i++ {
+		defines := make(map[string]string)
+		for j, name := range []string{"BLOOM", "TONEMAP", "VIGNETTE"} {
+			if i&(1<<uint(j)) != 0 {
+				defines[name] = ""
+			}
+		}
+		combinations = append(combinations, defines)
+	}
+	return combinations
+}
+
+// compositeUniforms returns the uniforms of the composite with the defines.
+func compositeUniforms(defines map[string]string) []render.UniformDescriptor {
+	uniforms := []render.UniformDescriptor{textureUniform}
+	if _, ok := defines["BLOOM"]; ok {
+		uniforms = append(uniforms,
+			render.UniformDescriptor{Name: "uBloom", Type: gl.SAMPLER_2D, Count: 1},
+			render.UniformDescriptor{Name: "uIntensity", Type: gl.FLOAT, Count: 1})
+	}
+	if _, ok := defines["TONEMAP"]; ok {
+		uniforms = append(uniforms,
+			render.UniformDescriptor{Name: "uExposure", Type: gl.FLOAT, Count: 1})
+	}
+	if _, ok := defines["VIGNETTE"]; ok {
+		uniforms = append(uniforms,
+			render.UniformDescriptor{Name: "uRadius", Type: gl.FLOAT, Count: 1},
+			render.UniformDescriptor{Name: "uSoftness", Type: gl.FLOAT, Count: 1},
+			render.UniformDescriptor{Name: "uStrength", Type: gl.FLOAT, Count: 1})
+	}
+	return uniforms
+}
+
+// fragmentPort returns a port of a post shader declaring the uniforms, the
+// stack is tested by the passes it issues rather than the pixels they write.
+func fragmentPort(uniforms ...render.UniformDescriptor) *software.FragmentShader {
+	return &software.FragmentShader{
+		Uniforms: uniforms,
+		Varyings: 2,
+		Main: func(uniforms *software.Uniforms, varyings []float32) mgl32.Vec4 {
+			return mgl32.Vec4{varyings[0], varyings[1], 0, 1}
+		},
+	}
+}
+
+// newStackBackend installs a software backend with ports of the post shaders
+// for every permutation of the composite.
+func newStackBackend(t *testing.T) *stackBackend {
+	b := &stackBackend{
+		Backend:  software.NewBackend(testSize, testSize),
+		shaders:  make(map[uint32]string),
+		programs: make(map[uint32]string),
+	}
+	vertex := &software.VertexShader{
+		Attributes: []render.AttributeDescriptor{
+			{Name: "aPosition", Type: gl.FLOAT_VEC3, Count: 1, Location: 0},
+			{Name: "aTexCoord", Type: gl.FLOAT_VEC2, Count: 1, Location: 1},
+		},
+		Varyings: 2,
+		Main: func(uniforms *software.Uniforms, attributes []mgl32.Vec4, varyings []float32) mgl32.Vec4 {
+			varyings[0] = attributes[1][0]
+			varyings[1] = attributes[1][1]
+			return mgl32.Vec4{attributes[0][0], attributes[0][1], attributes[0][2], 1}
+		},
+	}
+	render.SetAssets(resources.FileSystem())
+	b.RegisterVertexShader(processFile(t, "shaders/texture.vert", nil), vertex)
+	b.RegisterFragmentShader(processFile(t, "shaders/post/blur.frag", nil), fragmentPort(
+		textureUniform,
+		render.UniformDescriptor{Name: "uDirection", Type: gl.FLOAT_VEC2, Count: 1},
+		render.UniformDescriptor{Name: "uSigma", Type: gl.FLOAT, Count: 1}))
+	b.RegisterFragmentShader(processFile(t, "shaders/post/bright.frag", nil), fragmentPort(
+		textureUniform,
+		render.UniformDescriptor{Name: "uThreshold", Type: gl.FLOAT, Count: 1}))
+	for _, defines := range compositeDefines() {
+		b.RegisterVertexShader(processFile(t, "shaders/texture.vert", defines), vertex)
+		b.RegisterFragmentShader(processFile(t, "shaders/post/composite.frag", defines),
+			fragmentPort(compositeUniforms(defines)...))
+	}
+	render.SetBackend(b)
+	return b
+}
+
+// newSource returns a framebuffer with a float color texture.
+func newSource(t *testing.T) (*render.FrameBuffer, *render.Texture) {
+	texture, err := render.NewFloatTexture(testSize, testSize, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := render.NewFrameBuffer()
+	err = source.AttachTexture(gl.COLOR_ATTACHMENT0, texture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source, texture
+}
+
+// drawStack draws the stack at the test size, there is no default
+// framebuffer to size the graph by.
+func drawStack(t *testing.T, b *stackBackend, s *Stack) {
+	s.update()
+	s.graph.Viewport(&render.Viewport{
+		Width:  testSize,
+		Height: testSize,
+	})
+	b.draws = nil
+	err := s.graph.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// graphTexture returns the id of the named texture of the graph.
+func graphTexture(t *testing.T, s *Stack, name string) uint32 {
+	texture, ok := s.graph.Texture(name)
+	if !ok {
+		t.Fatalf("expected graph texture `%s`", name)
+	}
+	return texture.ID()
+}
+
+func containsTexture(textures []uint32, texture uint32) bool {
+	for _, id := range textures {
+		if id == texture {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStackPasses(t *testing.T) {
+	tests := []struct {
+		name   string
+		blur   bool
+		bloom  bool
+		passes []string
+		// image is the graph texture the composite reads, the source if
+		// empty
+		image string
+	}{
+		{
+			name:   "no effects",
+			passes: []string{"composite"},
+		},
+		{
+			name:   "blur",
+			blur:   true,
+			passes: []string{"blur horizontal", "blur vertical", "composite"},
+			image:  "blurred",
+		},
+		{
+			name:   "bloom",
+			bloom:  true,
+			passes: []string{"bright", "bloom horizontal", "bloom vertical", "composite"},
+		},
+		{
+			name:  "blur and bloom",
+			blur:  true,
+			bloom: true,
+			passes: []string{
+				"blur horizontal", "blur vertical",
+				"bright", "bloom horizontal", "bloom vertical",
+				"composite",
+			},
+			image: "blurred",
+		},
+	}
+	for _, test := range tests {
+		b := newStackBackend(t)
+		source, texture := newSource(t)
+		s, err := NewStack(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Blur.Enabled = test.blur
+		s.Bloom.Enabled = test.bloom
+		drawStack(t, b, s)
+
+		passes, err := s.graph.Passes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(passes, test.passes) {
+			t.Errorf("%s: expected passes %q, got %q", test.name, test.passes, passes)
+		}
+		if len(b.draws) != len(test.passes) {
+			t.Fatalf("%s: expected %d draws, got %d", test.name, len(test.passes), len(b.draws))
+		}
+		composite := b.draws[len(b.draws)-1]
+		image := texture.ID()
+		if test.image != "" {
+			image = graphTexture(t, s, test.image)
+		}
+		if !containsTexture(composite.textures, image) {
+			t.Errorf("%s: expected the composite to read texture %d, got %v", test.name, image, composite.textures)
+		}
+		if test.bloom {
+			for _, name := range []string{"bright", "bloom horizontal", "bloom"} {
+				bloom, _ := s.graph.Texture(name)
+				if bloom.Width() != testSize/2 || bloom.Height() != testSize/2 {
+					t.Errorf("%s: expected `%s` at half scale, got %dx%d",
+						test.name, name, bloom.Width(), bloom.Height())
+				}
+			}
+			bloom := graphTexture(t, s, "bloom")
+			if !containsTexture(composite.textures, bloom) {
+				t.Errorf("%s: expected the composite to read bloom texture %d, got %v",
+					test.name, bloom, composite.textures)
+			}
+		} else if len(composite.textures) != 1 {
+			t.Errorf("%s: expected the composite to read one texture, got %v", test.name, composite.textures)
+		}
+		s.Destroy()
+		source.Destroy()
+		texture.Destroy()
+	}
+}
+
+func TestStackRebuild(t *testing.T) {
+	b := newStackBackend(t)
+	source, texture := newSource(t)
+	defer source.Destroy()
+	defer texture.Destroy()
+	s, err := NewStack(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Destroy()
+	s.Bloom.Enabled = false
+
+	tests := []struct {
+		name    string
+		toggle  func()
+		rebuilt bool
+	}{
+		{
+			name:   "unchanged",
+			toggle: func() {},
+		},
+		{
+			name:    "blur enabled",
+			toggle:  func() { s.Blur.Enabled = true },
+			rebuilt: true,
+		},
+		{
+			name:    "bloom enabled",
+			toggle:  func() { s.Bloom.Enabled = true },
+			rebuilt: true,
+		},
+		{
+			// the vignette only permutes the composite
+			name:   "vignette disabled",
+			toggle: func() { s.Vignette.Enabled = false },
+		},
+		{
+			name:    "blur disabled",
+			toggle:  func() { s.Blur.Enabled = false },
+			rebuilt: true,
+		},
+	}
+	drawStack(t, b, s)
+	for _, test := range tests {
+		graph := s.graph
+		test.toggle()
+		drawStack(t, b, s)
+		if (s.graph != graph) != test.rebuilt {
+			t.Errorf("%s: expected rebuilt to be %t", test.name, test.rebuilt)
+		}
+	}
+}
+
+func TestStackCompositeVariant(t *testing.T) {
+	b := newStackBackend(t)
+	source, texture := newSource(t)
+	defer source.Destroy()
+	defer texture.Destroy()
+	s, err := NewStack(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Destroy()
+
+	for _, defines := range compositeDefines() {
+		_, s.Bloom.Enabled = defines["BLOOM"]
+		_, s.ToneMap.Enabled = defines["TONEMAP"]
+		_, s.Vignette.Enabled = defines["VIGNETTE"]
+		if !reflect.DeepEqual(s.defines(), defines) {
+			t.Errorf("expected defines %v, got %v", defines, s.defines())
+		}
+		drawStack(t, b, s)
+		composite := b.draws[len(b.draws)-1]
+		expected := processFile(t, "shaders/post/composite.frag", defines)
+		if composite.fragment != expected {
+			t.Errorf("expected the composite compiled with %v, got:\n%s", defines, composite.fragment)
+		}
+	}
+}
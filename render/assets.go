@@ -0,0 +1,154 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+var (
+	assets FileSystem = Dir("")
+)
+
+// FileSystem represents a read-only tree of asset files, in the style of
+// io/fs.FS. Names are slash separated.
+type FileSystem interface {
+	Open(name string) (File, error)
+}
+
+// File represents an open asset file.
+type File interface {
+	io.ReadCloser
+	Stat() (os.FileInfo, error)
+}
+
+// SetAssets sets the file system shaders, included files and textures are
+// read from. By default files are read from disk relative to the working
+// directory.
+func SetAssets(fsys FileSystem) {
+	assets = fsys
+}
+
+// Assets returns the file system assets are read from.
+func Assets() FileSystem {
+	return assets
+}
+
+// ReadAsset reads the asset file with the provided name.
+func ReadAsset(name string) ([]byte, error) {
+	file, err := assets.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// assetModTime returns the modification time of an asset file, or the zero
+// time if it does not exist.
+func assetModTime(name string) time.Time {
+	file, err := assets.Open(name)
+	if err != nil {
+		// a missing file is picked up once it is written again
+		return time.Time{}
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Dir represents the files on disk under a directory. An empty directory
+// reads names as paths relative to the working directory.
+type Dir string
+
+// Open opens the file with the provided name under the directory.
+func (d Dir) Open(name string) (File, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// MapFile represents the contents of an in-memory file.
+type MapFile struct {
+	Data    []byte
+	ModTime time.Time
+}
+
+// MapFS represents in-memory files keyed by name.
+type MapFS map[string]*MapFile
+
+// Open opens the file with the provided name.
+func (m MapFS) Open(name string) (File, error) {
+	file, ok := m[path.Clean(name)]
+	if !ok {
+		return nil, &os.PathError{
+			Op:   "open",
+			Path: name,
+			Err:  os.ErrNotExist,
+		}
+	}
+	return &mapFile{
+		Reader: bytes.NewReader(file.Data),
+		info: &mapFileInfo{
+			name: path.Base(name),
+			file: file,
+		},
+	}, nil
+}
+
+type mapFile struct {
+	*bytes.Reader
+	info *mapFileInfo
+}
+
+func (f *mapFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *mapFile) Close() error {
+	return nil
+}
+
+type mapFileInfo struct {
+	name string
+	file *MapFile
+}
+
+func (i *mapFileInfo) Name() string       { return i.name }
+func (i *mapFileInfo) Size() int64        { return int64(len(i.file.Data)) }
+func (i *mapFileInfo) Mode() os.FileMode  { return 0444 }
+func (i *mapFileInfo) ModTime() time.Time { return i.file.ModTime }
+func (i *mapFileInfo) IsDir() bool        { return false }
+func (i *mapFileInfo) Sys() interface{}   { return nil }
+
+// overlay represents a stack of file systems.
+type overlay []FileSystem
+
+// Overlay returns a file system that opens each file from the first of the
+// provided file systems that has it, so earlier file systems override
+// individual files of later ones.
+func Overlay(layers ...FileSystem) FileSystem {
+	return overlay(layers)
+}
+
+func (o overlay) Open(name string) (File, error) {
+	for _, layer := range o {
+		file, err := layer.Open(name)
+		if err == nil {
+			return file, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, &os.PathError{
+		Op:   "open",
+		Path: name,
+		Err:  os.ErrNotExist,
+	}
+}
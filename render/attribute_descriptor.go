@@ -0,0 +1,15 @@
+package render
+
+// AttributeDescriptor represents a single active vertex attribute of a shader.
+type AttributeDescriptor struct {
+	Name     string
+	Type     uint32
+	Count    int32
+	Location int32
+}
+
+type attributesByLocation []*AttributeDescriptor
+
+func (a attributesByLocation) Len() int           { return len(a) }
+func (a attributesByLocation) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a attributesByLocation) Less(i, j int) bool { return a[i].Location < a[j].Location }
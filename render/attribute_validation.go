@@ -0,0 +1,148 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// attributeProblem represents a single difference between the attribute
+// pointers of a renderable and the active attributes of a shader.
+type attributeProblem struct {
+	message string
+	// fatal is true if the attribute reads undefined values, rather than
+	// values GL pads, truncates or ignores.
+	fatal bool
+}
+
+// ValidateRenderable checks the attribute pointers of the renderable against
+// the active vertex attributes of the shader. It reports attributes without a
+// pointer, pointers no attribute reads, and pointers whose type or size does
+// not match the attribute.
+func (s *Shader) ValidateRenderable(renderable *Renderable) error {
+	problems := s.attributeProblems(renderable)
+	if len(problems) == 0 {
+		return nil
+	}
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.message
+	}
+	return s.attributeError(renderable, messages)
+}
+
+// validateRenderable checks the renderable before it is drawn. Only
+// attributes that would read undefined values fail, the others are defined
+// by GL and are not reported. The result is cached until the renderable is
+// uploaded again or the program is relinked.
+func (s *Shader) validateRenderable(renderable *Renderable) error {
+	generation, ok := renderable.validated[s]
+	if ok && generation == s.generation {
+		return nil
+	}
+	var messages []string
+	for _, problem := range s.attributeProblems(renderable) {
+		if problem.fatal {
+			messages = append(messages, problem.message)
+		}
+	}
+	if len(messages) > 0 {
+		return s.attributeError(renderable, messages)
+	}
+	if renderable.validated == nil {
+		renderable.validated = make(map[*Shader]uint64)
+	}
+	renderable.validated[s] = s.generation
+	return nil
+}
+
+func (s *Shader) attributeProblems(renderable *Renderable) []attributeProblem {
+	var problems []attributeProblem
+	read := make(map[uint32]bool)
+	for _, attribute := range s.Attributes() {
+		columns, components, integer := attributeLayout(attribute.Type)
+		// matrices read one location per column
+		for i := 0; i < columns*int(attribute.Count); i++ {
+			index := uint32(attribute.Location) + uint32(i)
+			read[index] = true
+			pointer, ok := renderable.pointers[index]
+			if !ok {
+				message := fmt.Sprintf("attribute `%s` (%s at location %d) has no pointer",
+					attribute.Name, glslTypeName(attribute.Type), attribute.Location)
+				if columns > 1 {
+					message += fmt.Sprintf(" for the column at location %d", index)
+				}
+				problems = append(problems, attributeProblem{
+					message: message,
+					fatal:   true,
+				})
+				continue
+			}
+			if integer {
+				problems = append(problems, attributeProblem{
+					message: fmt.Sprintf("attribute `%s` has integer type %s but pointer %d is converted to floats",
+						attribute.Name, glslTypeName(attribute.Type), index),
+					fatal: true,
+				})
+			} else if int(pointer.Size) != components {
+				problems = append(problems, attributeProblem{
+					message: fmt.Sprintf("attribute `%s` is a %s but pointer %d has %d components",
+						attribute.Name, glslTypeName(attribute.Type), index, pointer.Size),
+				})
+			}
+		}
+	}
+	indices := make([]int, 0, len(renderable.pointers))
+	for index := range renderable.pointers {
+		indices = append(indices, int(index))
+	}
+	sort.Ints(indices)
+	for _, index := range indices {
+		if !read[uint32(index)] {
+			problems = append(problems, attributeProblem{
+				message: fmt.Sprintf("pointer %d is not read by any attribute", index),
+			})
+		}
+	}
+	return problems
+}
+
+func (s *Shader) attributeError(renderable *Renderable, messages []string) error {
+	return &Error{
+		Op:      "ValidateRenderable",
+		Object:  renderable.id,
+		Message: fmt.Sprintf("renderable does not match shader %d: %s", s.id, strings.Join(messages, ", ")),
+	}
+}
+
+// attributeLayout returns the number of locations, the components per
+// location, and whether the attribute is an integer type.
+func attributeLayout(typ uint32) (int, int, bool) {
+	switch typ {
+	case gl.FLOAT:
+		return 1, 1, false
+	case gl.FLOAT_VEC2:
+		return 1, 2, false
+	case gl.FLOAT_VEC3:
+		return 1, 3, false
+	case gl.FLOAT_VEC4:
+		return 1, 4, false
+	case gl.FLOAT_MAT2:
+		return 2, 2, false
+	case gl.FLOAT_MAT3:
+		return 3, 3, false
+	case gl.FLOAT_MAT4:
+		return 4, 4, false
+	case gl.INT, gl.UNSIGNED_INT:
+		return 1, 1, true
+	case gl.INT_VEC2, gl.UNSIGNED_INT_VEC2:
+		return 1, 2, true
+	case gl.INT_VEC3, gl.UNSIGNED_INT_VEC3:
+		return 1, 3, true
+	case gl.INT_VEC4, gl.UNSIGNED_INT_VEC4:
+		return 1, 4, true
+	}
+	return 1, 4, false
+}
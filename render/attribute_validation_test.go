@@ -0,0 +1,214 @@
+package render_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/software"
+	"github.com/kbirk/cauldron/shape"
+)
+
+const (
+	attributeFrag = `#version 410
+
+out vec4 oColor;
+
+void main() {
+	oColor = vec4(1);
+}
+`
+)
+
+// attributeVert returns the source of a vertex shader declaring the
+// provided attributes.
+func attributeVert(attributes []render.AttributeDescriptor) string {
+	source := "#version 410\n\n"
+	for _, attribute := range attributes {
+		typ := "vec3"
+		if attribute.Type == gl.INT {
+			typ = "int"
+		}
+		source += fmt.Sprintf("layout(location=%d) in %s %s;\n", attribute.Location, typ, attribute.Name)
+	}
+	return source + "\nvoid main() {\n\tgl_Position = vec4(aPosition, 1);\n}\n"
+}
+
+// registerAttributePorts registers a Go port of the vertex shader declaring
+// the provided attributes and of the fragment shader, and writes both to the
+// provided file system.
+func registerAttributePorts(b *recordingBackend, fsys render.MapFS, attributes []render.AttributeDescriptor) {
+	vert := attributeVert(attributes)
+	fsys["attributes.vert"] = &render.MapFile{Data: []byte(vert)}
+	fsys["attributes.frag"] = &render.MapFile{Data: []byte(attributeFrag)}
+	b.RegisterVertexShader(vert, &software.VertexShader{
+		Attributes: attributes,
+		Main: func(uniforms *software.Uniforms, attributes []mgl32.Vec4, varyings []float32) mgl32.Vec4 {
+			return attributes[0]
+		},
+	})
+	b.RegisterFragmentShader(attributeFrag, &software.FragmentShader{
+		Main: func(uniforms *software.Uniforms, varyings []float32) mgl32.Vec4 {
+			return mgl32.Vec4{1, 1, 1, 1}
+		},
+	})
+}
+
+// newPointerQuad returns a quad with a position pointer at location 0 and
+// the provided additional pointers, all reading the positions.
+func newPointerQuad(t *testing.T, pointers map[uint32]*render.AttributePointer) *render.Renderable {
+	positions, indices := shape.Quad(8, true, false)
+	vertices := &render.VertexBuffer{}
+	err := vertices.BufferFloat32(positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements := &render.IndexBuffer{}
+	err = elements.BufferUint16(indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quad := &render.Renderable{}
+	quad.SetVertexBuffer(vertices)
+	quad.SetIndexBuffer(elements)
+	quad.SetPointer(0, &render.AttributePointer{
+		Type: gl.FLOAT,
+		Size: 3,
+	})
+	for index, pointer := range pointers {
+		quad.SetPointer(index, pointer)
+	}
+	quad.SetDrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_SHORT, 0)
+	err = quad.Upload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return quad
+}
+
+func TestValidateRenderable(t *testing.T) {
+	position := render.AttributeDescriptor{Name: "aPosition", Type: gl.FLOAT_VEC3, Count: 1, Location: 0}
+	tests := []struct {
+		name       string
+		attributes []render.AttributeDescriptor
+		pointers   map[uint32]*render.AttributePointer
+		// drawErr is expected from drawing, validateErr from
+		// ValidateRenderable
+		drawErr     string
+		validateErr string
+	}{
+		{
+			name:       "matching pointers",
+			attributes: []render.AttributeDescriptor{position},
+		},
+		{
+			name: "missing attribute",
+			attributes: []render.AttributeDescriptor{
+				position,
+				{Name: "aNormal", Type: gl.FLOAT_VEC3, Count: 1, Location: 1},
+			},
+			drawErr:     "attribute `aNormal` (vec3 at location 1) has no pointer",
+			validateErr: "attribute `aNormal` (vec3 at location 1) has no pointer",
+		},
+		{
+			name:       "unused pointer",
+			attributes: []render.AttributeDescriptor{position},
+			pointers: map[uint32]*render.AttributePointer{
+				1: {Type: gl.FLOAT, Size: 3},
+			},
+			validateErr: "pointer 1 is not read by any attribute",
+		},
+		{
+			name: "int attribute fed a float pointer",
+			attributes: []render.AttributeDescriptor{
+				position,
+				{Name: "aIndex", Type: gl.INT, Count: 1, Location: 1},
+			},
+			pointers: map[uint32]*render.AttributePointer{
+				1: {Type: gl.FLOAT, Size: 1},
+			},
+			drawErr:     "attribute `aIndex` has integer type int but pointer 1 is converted to floats",
+			validateErr: "attribute `aIndex` has integer type int but pointer 1 is converted to floats",
+		},
+	}
+	for _, test := range tests {
+		b := newRecordingBackend(t)
+		fsys := render.MapFS{}
+		registerAttributePorts(b, fsys, test.attributes)
+		render.SetAssets(fsys)
+		shader, err := render.NewVertFragShader("attributes.vert", "attributes.frag")
+		render.SetAssets(render.Dir(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		quad := newPointerQuad(t, test.pointers)
+		technique := render.NewTechnique()
+		technique.Shader(shader)
+		command := &render.Command{}
+		command.Renderable(quad)
+
+		b.reset()
+		err = technique.Draw([]*render.Command{command})
+		expectError(t, test.name+" draw", err, test.drawErr)
+		draws := filterCalls(b.calls, "DrawElements")
+		if test.drawErr == "" && len(draws) != 1 {
+			t.Errorf("%s: expected the renderable to be drawn, got %q", test.name, draws)
+		}
+		if test.drawErr != "" && len(draws) != 0 {
+			t.Errorf("%s: expected the renderable not to be drawn, got %q", test.name, draws)
+		}
+		expectError(t, test.name+" validate", shader.ValidateRenderable(quad), test.validateErr)
+	}
+}
+
+func TestValidateRenderableAfterReload(t *testing.T) {
+	b := newRecordingBackend(t)
+	fsys := render.MapFS{}
+	position := render.AttributeDescriptor{Name: "aPosition", Type: gl.FLOAT_VEC3, Count: 1, Location: 0}
+	registerAttributePorts(b, fsys, []render.AttributeDescriptor{position})
+	render.SetAssets(fsys)
+	defer render.SetAssets(render.Dir(""))
+	shader, err := render.NewVertFragShader("attributes.vert", "attributes.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quad := newPointerQuad(t, nil)
+	technique := render.NewTechnique()
+	technique.Shader(shader)
+	command := &render.Command{}
+	command.Renderable(quad)
+	err = technique.Draw([]*render.Command{command})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the reloaded program reads an attribute the quad has no pointer for,
+	// so the cached validation must not be reused
+	registerAttributePorts(b, fsys, []render.AttributeDescriptor{
+		position,
+		{Name: "aNormal", Type: gl.FLOAT_VEC3, Count: 1, Location: 1},
+	})
+	err = shader.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = technique.Draw([]*render.Command{command})
+	expectError(t, "reloaded", err, "attribute `aNormal` (vec3 at location 1) has no pointer")
+}
+
+// expectError fails the test unless the error contains the expected message,
+// or is nil if no message is expected.
+func expectError(t *testing.T, context string, err error, expected string) {
+	if expected == "" {
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", context, err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("%s: expected error containing %q, got %v", context, expected, err)
+	}
+}
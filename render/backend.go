@@ -0,0 +1,132 @@
+package render
+
+import (
+	"unsafe"
+)
+
+var (
+	backend Backend = wrapBackend(&GLBackend{})
+)
+
+// Backend represents the graphics API that owns all object creation, state
+// changes and draw calls issued by the render package.
+type Backend interface {
+	// queries
+	GetError() uint32
+	GetString(name uint32) string
+
+	// state
+	Enable(state uint32)
+	Disable(state uint32)
+	BlendFunc(sfactor uint32, dfactor uint32)
+	CullFace(mode uint32)
+	DepthMask(flag bool)
+	DepthFunc(xfunc uint32)
+	BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32, dstAlpha uint32)
+	BlendEquationSeparate(modeRGB uint32, modeAlpha uint32)
+	BlendColor(red float32, green float32, blue float32, alpha float32)
+	ColorMask(red bool, green bool, blue bool, alpha bool)
+	StencilFunc(xfunc uint32, ref int32, mask uint32)
+	StencilOp(sfail uint32, dpfail uint32, dppass uint32)
+	StencilMask(mask uint32)
+	Scissor(x int32, y int32, width int32, height int32)
+	PolygonOffset(factor float32, units float32)
+	LineWidth(width float32)
+	PolygonMode(face uint32, mode uint32)
+	Viewport(x int32, y int32, width int32, height int32)
+	ClearColor(red float32, green float32, blue float32, alpha float32)
+	ClearDepth(depth float32)
+	ClearStencil(s int32)
+	Clear(mask uint32)
+
+	// shaders
+	CreateShader(typ uint32, source string) (uint32, error)
+	DeleteShader(shader uint32)
+	CreateProgram() uint32
+	AttachShader(program uint32, shader uint32)
+	DetachShader(program uint32, shader uint32)
+	TransformFeedbackVaryings(program uint32, varyings []string, bufferMode uint32)
+	LinkProgram(program uint32) error
+	GetProgramBinary(program uint32) (uint32, []byte, error)
+	ProgramBinary(program uint32, format uint32, binary []byte) error
+	UseProgram(program uint32)
+	DeleteProgram(program uint32)
+	ActiveAttributes(program uint32) []*AttributeDescriptor
+	ActiveUniforms(program uint32) []*UniformDescriptor
+	ActiveUniformBlocks(program uint32) []*UniformBlockDescriptor
+	UniformBlockBinding(program uint32, index uint32, binding uint32)
+
+	// uniforms
+	Uniform1i(location int32, value int32)
+	Uniform1ui(location int32, value uint32)
+	Uniform1f(location int32, value float32)
+	Uniform1iv(location int32, count int32, value *int32)
+	Uniform1uiv(location int32, count int32, value *uint32)
+	Uniform1fv(location int32, count int32, value *float32)
+	Uniform2fv(location int32, count int32, value *float32)
+	Uniform3fv(location int32, count int32, value *float32)
+	Uniform4fv(location int32, count int32, value *float32)
+	UniformMatrix3fv(location int32, count int32, value *float32)
+	UniformMatrix4fv(location int32, count int32, value *float32)
+
+	// buffers
+	CreateBuffer() uint32
+	BindBuffer(target uint32, buffer uint32)
+	BufferData(target uint32, size int, data unsafe.Pointer, usage uint32)
+	BufferSubData(target uint32, offset int, size int, data unsafe.Pointer)
+	BindBufferBase(target uint32, index uint32, buffer uint32)
+	DeleteBuffer(buffer uint32)
+
+	// vertex arrays
+	CreateVertexArray() uint32
+	BindVertexArray(array uint32)
+	EnableVertexAttribArray(index uint32)
+	VertexAttribPointer(index uint32, size int32, typ uint32, normalized bool, stride int32, offset int)
+	VertexAttribDivisor(index uint32, divisor uint32)
+	DeleteVertexArray(array uint32)
+
+	// textures
+	CreateTexture() uint32
+	ActiveTexture(unit uint32)
+	BindTexture(target uint32, texture uint32)
+	TexParameteri(target uint32, pname uint32, param int32)
+	TexImage2D(target uint32, level int32, internalFormat int32, width int32, height int32, format uint32, typ uint32, data unsafe.Pointer)
+	GenerateMipmap(target uint32)
+	DeleteTexture(texture uint32)
+
+	// framebuffers
+	CreateFramebuffer() uint32
+	BindFramebuffer(target uint32, framebuffer uint32)
+	FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32)
+	CheckFramebufferStatus(target uint32) uint32
+	DrawBuffers(buffers []uint32)
+	DeleteFramebuffer(framebuffer uint32)
+
+	// draw calls
+	PatchParameteri(pname uint32, value int32)
+	BeginTransformFeedback(primitiveMode uint32)
+	EndTransformFeedback()
+	DrawArrays(mode uint32, first int32, count int32)
+	DrawArraysInstanced(mode uint32, first int32, count int32, primcount int32)
+	DrawElements(mode uint32, count int32, typ uint32, byteOffset int)
+	DrawElementsInstanced(mode uint32, count int32, typ uint32, byteOffset int, primcount int32)
+
+	// markers
+	BeginTechnique(marker *TechniqueMarker)
+	EndTechnique()
+	BeginCommand(marker *CommandMarker)
+	EndCommand()
+}
+
+// SetBackend sets the backend used by the render package. This must be
+// called before any render objects are created.
+func SetBackend(b Backend) {
+	backend = wrapBackend(b)
+	// cached state belongs to the previous backend
+	defaultContext.reset()
+}
+
+// CurrentBackend returns the backend used by the render package.
+func CurrentBackend() Backend {
+	return unwrapBackend(backend)
+}
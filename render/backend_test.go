@@ -0,0 +1,232 @@
+package render_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/software"
+	"github.com/kbirk/cauldron/shape"
+)
+
+const (
+	testWidth  = 32
+	testHeight = 32
+)
+
+// recordingBackend represents a software backend that records the state
+// changes, binds, uniforms and draws issued through it.
+type recordingBackend struct {
+	*software.Backend
+	calls        []string
+	vertexArrays []uint32
+}
+
+// newRecordingBackend installs and returns a recording backend with the
+// default shaders registered.
+func newRecordingBackend(t *testing.T) *recordingBackend {
+	b := &recordingBackend{
+		Backend: software.NewBackend(testWidth, testHeight),
+	}
+	err := b.RegisterDefaultShaders("../resources/shaders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	render.SetBackend(b)
+	return b
+}
+
+func (b *recordingBackend) record(name string, args ...interface{}) {
+	call := name
+	for _, arg := range args {
+		call += fmt.Sprintf(" %v", arg)
+	}
+	b.calls = append(b.calls, call)
+}
+
+// reset forgets the recorded calls.
+func (b *recordingBackend) reset() {
+	b.calls = nil
+}
+
+func (b *recordingBackend) Enable(state uint32) {
+	b.record("Enable", state)
+	b.Backend.Enable(state)
+}
+
+func (b *recordingBackend) Disable(state uint32) {
+	b.record("Disable", state)
+	b.Backend.Disable(state)
+}
+
+func (b *recordingBackend) BlendFunc(sfactor uint32, dfactor uint32) {
+	b.record("BlendFunc", sfactor, dfactor)
+	b.Backend.BlendFunc(sfactor, dfactor)
+}
+
+func (b *recordingBackend) CullFace(mode uint32) {
+	b.record("CullFace", mode)
+	b.Backend.CullFace(mode)
+}
+
+func (b *recordingBackend) DepthMask(flag bool) {
+	b.record("DepthMask", flag)
+	b.Backend.DepthMask(flag)
+}
+
+func (b *recordingBackend) DepthFunc(xfunc uint32) {
+	b.record("DepthFunc", xfunc)
+	b.Backend.DepthFunc(xfunc)
+}
+
+func (b *recordingBackend) Viewport(x int32, y int32, width int32, height int32) {
+	b.record("Viewport", x, y, width, height)
+	b.Backend.Viewport(x, y, width, height)
+}
+
+func (b *recordingBackend) Clear(mask uint32) {
+	b.record("Clear", mask)
+	b.Backend.Clear(mask)
+}
+
+func (b *recordingBackend) CreateProgram() uint32 {
+	b.record("CreateProgram")
+	return b.Backend.CreateProgram()
+}
+
+func (b *recordingBackend) UseProgram(program uint32) {
+	b.record("UseProgram")
+	b.Backend.UseProgram(program)
+}
+
+func (b *recordingBackend) Uniform1iv(location int32, count int32, value *int32) {
+	b.record("Uniform1iv", count, *value)
+	b.Backend.Uniform1iv(location, count, value)
+}
+
+func (b *recordingBackend) Uniform1fv(location int32, count int32, value *float32) {
+	b.record("Uniform1fv", count)
+	b.Backend.Uniform1fv(location, count, value)
+}
+
+func (b *recordingBackend) Uniform4fv(location int32, count int32, value *float32) {
+	b.record("Uniform4fv", count)
+	b.Backend.Uniform4fv(location, count, value)
+}
+
+func (b *recordingBackend) UniformMatrix4fv(location int32, count int32, value *float32) {
+	b.record("UniformMatrix4fv", count)
+	b.Backend.UniformMatrix4fv(location, count, value)
+}
+
+func (b *recordingBackend) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	b.record("BindBufferBase", index)
+	b.Backend.BindBufferBase(target, index, buffer)
+}
+
+func (b *recordingBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	b.record("BufferData", size)
+	b.Backend.BufferData(target, size, data, usage)
+}
+
+func (b *recordingBackend) CreateVertexArray() uint32 {
+	array := b.Backend.CreateVertexArray()
+	b.vertexArrays = append(b.vertexArrays, array)
+	return array
+}
+
+func (b *recordingBackend) BindVertexArray(array uint32) {
+	b.record("BindVertexArray", array)
+	b.Backend.BindVertexArray(array)
+}
+
+func (b *recordingBackend) ActiveTexture(unit uint32) {
+	b.record("ActiveTexture", unit)
+	b.Backend.ActiveTexture(unit)
+}
+
+func (b *recordingBackend) BindTexture(target uint32, texture uint32) {
+	b.record("BindTexture", texture)
+	b.Backend.BindTexture(target, texture)
+}
+
+func (b *recordingBackend) TexImage2D(target uint32, level int32, internalFormat int32, width int32, height int32, format uint32, typ uint32, data unsafe.Pointer) {
+	b.record("TexImage2D", internalFormat, width, height, typ)
+	b.Backend.TexImage2D(target, level, internalFormat, width, height, format, typ, data)
+}
+
+func (b *recordingBackend) BindFramebuffer(target uint32, framebuffer uint32) {
+	b.record("BindFramebuffer", framebuffer)
+	b.Backend.BindFramebuffer(target, framebuffer)
+}
+
+func (b *recordingBackend) DrawElements(mode uint32, count int32, typ uint32, byteOffset int) {
+	b.record("DrawElements", count)
+	b.Backend.DrawElements(mode, count, typ, byteOffset)
+}
+
+func (b *recordingBackend) DrawElementsInstanced(mode uint32, count int32, typ uint32, byteOffset int, primcount int32) {
+	b.record("DrawElementsInstanced", count, primcount)
+	b.Backend.DrawElementsInstanced(mode, count, typ, byteOffset, primcount)
+}
+
+// newQuad returns a renderable of a quad of the provided size centered on
+// the origin.
+func newQuad(t *testing.T, size float32) *render.Renderable {
+	positions, indices := shape.Quad(size, true, false)
+	vertices := &render.VertexBuffer{}
+	err := vertices.BufferFloat32(positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements := &render.IndexBuffer{}
+	err = elements.BufferUint16(indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quad := &render.Renderable{}
+	quad.SetVertexBuffer(vertices)
+	quad.SetIndexBuffer(elements)
+	quad.SetPointer(0, &render.AttributePointer{
+		Type: gl.FLOAT,
+		Size: 3,
+	})
+	quad.SetDrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_SHORT, 0)
+	err = quad.Upload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return quad
+}
+
+// filterCalls returns the recorded calls to the named backend methods.
+func filterCalls(calls []string, names ...string) []string {
+	var filtered []string
+	for _, call := range calls {
+		for _, name := range names {
+			if call == name || strings.HasPrefix(call, name+" ") {
+				filtered = append(filtered, call)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// expectCalls fails the test unless the recorded calls match.
+func expectCalls(t *testing.T, context string, calls []string, expected []string) {
+	if len(calls) != len(expected) {
+		t.Errorf("%s: expected calls %q, got %q", context, expected, calls)
+		return
+	}
+	for i := range calls {
+		if calls[i] != expected[i] {
+			t.Errorf("%s: expected calls %q, got %q", context, expected, calls)
+			return
+		}
+	}
+}
@@ -1,13 +1,23 @@
 package render
 
+import (
+	"sort"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
 // Command represents a render command.
 type Command struct {
 	uniforms   map[string]interface{}
 	textures   map[uint32]*Texture
+	buffers    []*UniformBuffer
 	renderable *Renderable
 }
 
-// Uniform sets a uniform to be buffered.
+// Uniform sets a uniform to be buffered. Supported values are float32, int,
+// int32, uint32, bool, the mgl32 vector and matrix types, slices of those for
+// array uniforms, and textures for samplers. Textures are bound to the units
+// following those set with Texture.
 func (c *Command) Uniform(name string, value interface{}) {
 	if c.uniforms == nil {
 		c.uniforms = make(map[string]interface{})
@@ -23,23 +33,96 @@ func (c *Command) Texture(location uint32, texture *Texture) {
 	c.textures[location] = texture
 }
 
+// UniformBuffer adds a uniform buffer to be bound.
+func (c *Command) UniformBuffer(buffer *UniformBuffer) {
+	c.buffers = append(c.buffers, buffer)
+}
+
 // Renderable sets a renderable to be drawn.
 func (c *Command) Renderable(renderable *Renderable) {
 	c.renderable = renderable
 }
 
-// Execute executes the render command.
-func (c *Command) Execute(shader *Shader) {
+// Execute executes the render command against the default context.
+func (c *Command) Execute(shader *Shader) error {
+	return c.execute(defaultContext, shader)
+}
+
+func (c *Command) execute(ctx *Context, shader *Shader) error {
+	// upload buffers first so the marker names them
+	for _, buffer := range c.buffers {
+		err := buffer.Upload()
+		if err != nil {
+			return err
+		}
+	}
+	backend.BeginCommand(c.marker(shader))
+	err := c.issue(ctx, shader)
+	backend.EndCommand()
+	return err
+}
+
+func (c *Command) issue(ctx *Context, shader *Shader) error {
 	// bind textures
 	for location, texture := range c.textures {
 		texture.Bind(location)
+		stats.TextureBinds++
+		err := checkError(texture.id)
+		if err != nil {
+			return err
+		}
+	}
+	// bind uniform buffers
+	for _, buffer := range c.buffers {
+		err := ctx.bindUniformBuffer(buffer)
+		if err != nil {
+			return err
+		}
+	}
+	// set uniforms in order so textures are bound to the same units
+	unit := c.firstFreeUnit()
+	names := make([]string, 0, len(c.uniforms))
+	for name := range c.uniforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := c.uniforms[name]
+		texture, ok := value.(*Texture)
+		if ok {
+			texture.Bind(gl.TEXTURE0 + unit)
+			stats.TextureBinds++
+			err := checkError(texture.id)
+			if err != nil {
+				return err
+			}
+			value = int32(unit)
+			unit++
+		}
+		err := shader.SetUniform(name, value)
+		if err != nil {
+			return err
+		}
 	}
-	// set uniforms
-	for name, value := range c.uniforms {
-		shader.SetUniform(name, value)
+	// check the vertex attributes match the shader
+	err := shader.validateRenderable(c.renderable)
+	if err != nil {
+		return err
 	}
 	// draw
 	c.renderable.Bind()
 	c.renderable.Draw()
 	c.renderable.Unbind()
+	return checkError(c.renderable.id)
+}
+
+// firstFreeUnit returns the texture unit following those set with Texture.
+func (c *Command) firstFreeUnit() uint32 {
+	unit := uint32(0)
+	for location := range c.textures {
+		if location-gl.TEXTURE0 >= unit {
+			unit = location - gl.TEXTURE0 + 1
+		}
+	}
+	return unit
 }
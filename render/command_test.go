@@ -0,0 +1,163 @@
+package render_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/software"
+)
+
+const (
+	texturedVert = `#version 410
+
+layout(location=0) in vec3 aPosition;
+
+layout(std140) uniform Camera {
+	mat4 uProjection;
+	mat4 uView;
+};
+
+uniform mat4 uModel;
+
+void main() {
+	gl_Position = uProjection * uView * uModel * vec4(aPosition, 1);
+}
+`
+	texturedFrag = `#version 410
+
+uniform sampler2D uTexture;
+uniform vec4 uColor;
+
+out vec4 oColor;
+
+void main() {
+	oColor = uColor * texture(uTexture, vec2(0.5));
+}
+`
+)
+
+var (
+	cameraUniforms = []render.UniformDescriptor{
+		{Name: "uProjection", Type: gl.FLOAT_MAT4, Count: 1},
+		{Name: "uView", Type: gl.FLOAT_MAT4, Count: 1},
+	}
+)
+
+// newTexturedShader returns a shader sampling a texture, with a Go port
+// registered on the software backend that ignores the texture.
+func newTexturedShader(t *testing.T, b *recordingBackend) *render.Shader {
+	render.SetAssets(render.MapFS{
+		"textured.vert": &render.MapFile{Data: []byte(texturedVert)},
+		"textured.frag": &render.MapFile{Data: []byte(texturedFrag)},
+	})
+	defer render.SetAssets(render.Dir(""))
+	b.RegisterVertexShader(texturedVert, &software.VertexShader{
+		Attributes: []render.AttributeDescriptor{
+			{Name: "aPosition", Type: gl.FLOAT_VEC3, Count: 1, Location: 0},
+		},
+		Uniforms: []render.UniformDescriptor{
+			{Name: "uModel", Type: gl.FLOAT_MAT4, Count: 1},
+		},
+		Blocks: []software.UniformBlock{
+			{Name: "Camera", Uniforms: cameraUniforms},
+		},
+		Main: func(uniforms *software.Uniforms, attributes []mgl32.Vec4, varyings []float32) mgl32.Vec4 {
+			mvp := uniforms.Mat4("uProjection").Mul4(uniforms.Mat4("uView")).Mul4(uniforms.Mat4("uModel"))
+			return mvp.Mul4x1(attributes[0].Vec3().Vec4(1))
+		},
+	})
+	b.RegisterFragmentShader(texturedFrag, &software.FragmentShader{
+		Uniforms: []render.UniformDescriptor{
+			{Name: "uTexture", Type: gl.SAMPLER_2D, Count: 1},
+			{Name: "uColor", Type: gl.FLOAT_VEC4, Count: 1},
+		},
+		Main: func(uniforms *software.Uniforms, varyings []float32) mgl32.Vec4 {
+			return uniforms.Vec4("uColor")
+		},
+	})
+	shader, err := render.NewVertFragShader("textured.vert", "textured.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shader
+}
+
+func TestCommandBindOrder(t *testing.T) {
+	b := newRecordingBackend(t)
+	shader := newTexturedShader(t, b)
+	quad := newQuad(t, 8)
+	mask, err := render.NewRGBATexture(nil, 4, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	texture, err := render.NewRGBATexture(nil, 4, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	camera := render.NewUniformBuffer(render.NewUniformBlockDescriptor("Camera", cameraUniforms), 2)
+	technique := render.NewTechnique()
+	technique.Shader(shader)
+
+	command := &render.Command{}
+	command.Texture(gl.TEXTURE0, mask)
+	command.UniformBuffer(camera)
+	command.Uniform("uTexture", texture)
+	command.Uniform("uModel", mgl32.Ident4())
+	command.Uniform("uColor", mgl32.Vec4{1, 1, 1, 1})
+	command.Renderable(quad)
+
+	b.reset()
+	err = technique.Draw([]*render.Command{command})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// explicit textures, then uniform buffers, then uniforms by name with
+	// sampled textures on the following units, then the draw
+	expectCalls(t, "command", filterCalls(b.calls,
+		"ActiveTexture",
+		"BindTexture",
+		"BindBufferBase",
+		"Uniform1iv",
+		"Uniform4fv",
+		"UniformMatrix4fv",
+		"BindVertexArray",
+		"DrawElements"),
+		[]string{
+			fmt.Sprintf("ActiveTexture %d", gl.TEXTURE0),
+			fmt.Sprintf("BindTexture %d", mask.ID()),
+			"BindBufferBase 2",
+			"Uniform4fv 1",
+			"UniformMatrix4fv 1",
+			fmt.Sprintf("ActiveTexture %d", gl.TEXTURE1),
+			fmt.Sprintf("BindTexture %d", texture.ID()),
+			"Uniform1iv 1 1",
+			fmt.Sprintf("BindVertexArray %d", b.vertexArrays[0]),
+			"DrawElements 6",
+			"BindVertexArray 0",
+		})
+
+	// a second draw binds the textures again, but skips the bound uniform
+	// buffer and the unchanged uniforms
+	b.reset()
+	err = technique.Draw([]*render.Command{command})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, "repeated command", filterCalls(b.calls,
+		"ActiveTexture",
+		"BindTexture",
+		"BindBufferBase",
+		"Uniform1iv",
+		"Uniform4fv",
+		"UniformMatrix4fv"),
+		[]string{
+			fmt.Sprintf("ActiveTexture %d", gl.TEXTURE0),
+			fmt.Sprintf("BindTexture %d", mask.ID()),
+			fmt.Sprintf("ActiveTexture %d", gl.TEXTURE1),
+			fmt.Sprintf("BindTexture %d", texture.ID()),
+		})
+}
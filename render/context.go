@@ -0,0 +1,301 @@
+package render
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+var (
+	defaultContext = NewContext(nil)
+	// capabilities lists the states disabled after an invalidation unless a
+	// technique enables them.
+	capabilities = []uint32{
+		gl.BLEND,
+		gl.COLOR_LOGIC_OP,
+		gl.CULL_FACE,
+		gl.DEPTH_CLAMP,
+		gl.DEPTH_TEST,
+		gl.FRAMEBUFFER_SRGB,
+		gl.LINE_SMOOTH,
+		gl.POLYGON_OFFSET_FILL,
+		gl.POLYGON_OFFSET_LINE,
+		gl.POLYGON_OFFSET_POINT,
+		gl.POLYGON_SMOOTH,
+		gl.PRIMITIVE_RESTART,
+		gl.PROGRAM_POINT_SIZE,
+		gl.RASTERIZER_DISCARD,
+		gl.SAMPLE_ALPHA_TO_COVERAGE,
+		gl.SCISSOR_TEST,
+		gl.STENCIL_TEST,
+	}
+	// uniformEpoch is bumped when any context is invalidated, shaders forget
+	// the uniform values they uploaded in an earlier epoch.
+	uniformEpoch uint64
+)
+
+// boundUniformBuffer represents the uniform buffer bound to a binding point.
+// The buffer object is kept alongside the buffer as it changes when the
+// buffer is destroyed and uploaded again.
+type boundUniformBuffer struct {
+	buffer *UniformBuffer
+	id     uint32
+}
+
+// Context represents the render state of an OpenGL context. Techniques draw
+// against a context, which caches the state they apply so redundant changes
+// are skipped. Each OpenGL context requires its own Context, and a context
+// must be invalidated whenever code outside of the render package changes
+// its state.
+type Context struct {
+	blendFunc      *blendFunc
+	blendEquation  *blendEquation
+	blendColor     *blendColor
+	colorMask      *colorMask
+	cullFace       *cullFace
+	depthMask      *depthMask
+	depthFunc      *depthFunc
+	stencilFunc    *stencilFunc
+	stencilOp      *stencilOp
+	stencilMask    *stencilMask
+	scissor        *scissor
+	polygonOffset  *polygonOffset
+	lineWidth      *lineWidth
+	polygonMode    *polygonMode
+	clearColor     *clearColor
+	clearDepth     *clearDepth
+	clearStencil   *clearStencil
+	viewport       *Viewport
+	shader         *Shader
+	program        uint32
+	frameBuffer    *FrameBuffer
+	frameBufferID  uint32
+	enables        map[uint32]bool
+	unknownEnables bool
+	uniformBuffers map[uint32]boundUniformBuffer
+	cleared        map[*Technique]bool
+	provider       ContextProvider
+}
+
+// NewContext instantiates and returns a new context for an OpenGL context
+// in its initial state. The provider sizes the viewport of techniques
+// drawing to the default framebuffer, it may be nil if they all set one.
+func NewContext(provider ContextProvider) *Context {
+	c := &Context{}
+	c.reset()
+	c.provider = provider
+	return c
+}
+
+// DefaultContext returns the context used by Technique.Draw and
+// TransformFeedback.Step. Its provider is set by InitContext.
+func DefaultContext() *Context {
+	return defaultContext
+}
+
+// Invalidate forgets all cached state, so the next draw applies every state
+// of its technique and uploads every uniform of its commands. It should be called after foreign OpenGL code has run
+// on the context, and before drawing with the render package again.
+func (c *Context) Invalidate() {
+	c.reset()
+	// enabled states are unknown, rather than the initial state
+	c.unknownEnables = true
+	// foreign code may also have set the uniforms of any program
+	uniformEpoch++
+}
+
+// BeginFrame starts a new frame, techniques that clear once per frame clear
+// their target again on their next draw.
+func (c *Context) BeginFrame() {
+	c.cleared = make(map[*Technique]bool)
+}
+
+// reset assumes the initial state of an OpenGL context.
+func (c *Context) reset() {
+	*c = Context{
+		enables:        make(map[uint32]bool),
+		uniformBuffers: make(map[uint32]boundUniformBuffer),
+		cleared:        c.cleared,
+		provider:       c.provider,
+	}
+	if c.cleared == nil {
+		c.cleared = make(map[*Technique]bool)
+	}
+}
+
+// targetViewport returns the viewport covering the whole of the provided
+// framebuffer, or of the default framebuffer if it is nil. It returns nil
+// if the size is unknown.
+func (c *Context) targetViewport(frameBuffer *FrameBuffer) *Viewport {
+	var width, height int
+	if frameBuffer != nil {
+		w, h := frameBuffer.Size()
+		width, height = int(w), int(h)
+	} else if c.provider != nil {
+		width, height = c.provider.FramebufferSize()
+	}
+	if width == 0 || height == 0 {
+		return nil
+	}
+	return &Viewport{
+		Width:  int32(width),
+		Height: int32(height),
+	}
+}
+
+// Draw renders all commands using the provided technique. Drawing stops at
+// the first command that fails.
+func (c *Context) Draw(technique *Technique, commands []*Command) error {
+	err := technique.selectVariant()
+	if err != nil {
+		return err
+	}
+	// upload buffers first so the marker names them
+	for _, buffer := range technique.buffers {
+		err := buffer.Upload()
+		if err != nil {
+			return withDraw(err, technique, nil)
+		}
+	}
+	backend.BeginTechnique(technique.marker(c))
+	err = c.draw(technique, commands)
+	backend.EndTechnique()
+	return err
+}
+
+func (c *Context) draw(technique *Technique, commands []*Command) error {
+	technique.setup(c)
+	err := checkError(technique.shader.id)
+	if err != nil {
+		return withDraw(err, technique, nil)
+	}
+	for _, buffer := range technique.buffers {
+		err := c.bindUniformBuffer(buffer)
+		if err != nil {
+			return withDraw(err, technique, nil)
+		}
+	}
+	for _, command := range commands {
+		err := command.execute(c, technique.shader)
+		if err != nil {
+			return withDraw(err, technique, command)
+		}
+	}
+	return nil
+}
+
+// Execute draws the live passes of the provided graph in order.
+func (c *Context) Execute(graph *Graph) error {
+	return graph.execute(c)
+}
+
+// Flush draws and removes every entry of the provided queue, in sorted
+// order. Drawing stops at the first command that fails.
+func (c *Context) Flush(queue *Queue) error {
+	defer queue.Reset()
+	for _, entry := range queue.entries {
+		err := entry.technique.selectVariant()
+		if err != nil {
+			return err
+		}
+	}
+	queue.sort()
+	// draw runs of the same technique together
+	start := 0
+	for i := 1; i <= len(queue.entries); i++ {
+		if i < len(queue.entries) && queue.entries[i].technique == queue.entries[start].technique {
+			continue
+		}
+		commands := make([]*Command, 0, i-start)
+		for _, entry := range queue.entries[start:i] {
+			commands = append(commands, entry.command)
+		}
+		err := c.Draw(queue.entries[start].technique, commands)
+		if err != nil {
+			return err
+		}
+		start = i
+	}
+	return nil
+}
+
+// Step runs a step of the provided transform feedback.
+func (c *Context) Step(feedback *TransformFeedback) error {
+	if feedback.count == 0 {
+		return nil
+	}
+	c.useShader(feedback.shader)
+	return feedback.step(c)
+}
+
+// useShader uses the shader unless its program is already in use.
+func (c *Context) useShader(shader *Shader) {
+	if c.shader == shader && c.program == shader.id {
+		stats.SkippedStateChanges++
+		return
+	}
+	shader.Use()
+	c.shader = shader
+	c.program = shader.id
+	stats.ShaderSwitches++
+}
+
+// enable enables the state unless it is already enabled.
+func (c *Context) enable(state uint32) {
+	if c.enables[state] {
+		countStateChange(false)
+		return
+	}
+	backend.Enable(state)
+	c.enables[state] = true
+	countStateChange(true)
+}
+
+// disable disables the state unless it is already disabled, states that were
+// never enabled are disabled unless the enabled states are unknown.
+func (c *Context) disable(state uint32) {
+	enabled, ok := c.enables[state]
+	if !enabled && (ok || !c.unknownEnables) {
+		countStateChange(false)
+		return
+	}
+	backend.Disable(state)
+	c.enables[state] = false
+	countStateChange(true)
+}
+
+// bindFrameBuffer binds the framebuffer, or the default framebuffer if it
+// is nil, unless it is already bound.
+func (c *Context) bindFrameBuffer(frameBuffer *FrameBuffer) {
+	var id uint32
+	if frameBuffer != nil {
+		id = frameBuffer.id
+	}
+	if c.frameBuffer == frameBuffer && c.frameBufferID == id {
+		countStateChange(false)
+		return
+	}
+	backend.BindFramebuffer(gl.FRAMEBUFFER, id)
+	c.frameBuffer = frameBuffer
+	c.frameBufferID = id
+	countStateChange(true)
+}
+
+// bindUniformBuffer uploads any modified values and binds the buffer unless
+// it is already bound to its binding point.
+func (c *Context) bindUniformBuffer(buffer *UniformBuffer) error {
+	err := buffer.Upload()
+	if err != nil {
+		return err
+	}
+	bound, ok := c.uniformBuffers[buffer.binding]
+	if ok && bound.buffer == buffer && bound.id == buffer.id {
+		countStateChange(false)
+		return nil
+	}
+	countStateChange(true)
+	backend.BindBufferBase(gl.UNIFORM_BUFFER, buffer.binding, buffer.id)
+	c.uniformBuffers[buffer.binding] = boundUniformBuffer{
+		buffer: buffer,
+		id:     buffer.id,
+	}
+	return checkError(buffer.id)
+}
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// ContextProvider represents a source of an OpenGL context and its default
+// framebuffer.
+type ContextProvider interface {
+	// MakeCurrent makes the context current on the calling thread.
+	MakeCurrent() error
+	// FramebufferSize returns the size of the default framebuffer in pixels.
+	FramebufferSize() (int, int)
+	// SwapBuffers presents the default framebuffer.
+	SwapBuffers()
+	// Destroy releases the context and its default framebuffer.
+	Destroy()
+}
+
+// InitContext makes the provided context current and loads the OpenGL
+// function pointers. It must be called from the thread that will issue all
+// subsequent render calls.
+func InitContext(provider ContextProvider) error {
+	err := provider.MakeCurrent()
+	if err != nil {
+		return err
+	}
+	err = gl.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init glow: %v", err)
+	}
+	// debug builds route driver messages into returned errors
+	enableDebugOutput()
+	// size viewports following the default framebuffer
+	defaultContext.provider = provider
+	return nil
+}
@@ -0,0 +1,544 @@
+//go:build debug
+// +build debug
+
+package render
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/unchartedsoftware/plog"
+)
+
+// Debug is true when the render package is built with the `debug` tag, in
+// which case every backend call is checked for errors.
+const Debug = true
+
+const (
+	// a lost context may report errors indefinitely, so bound the number of
+	// error flags drained after each call
+	maxErrorFlags = 8
+)
+
+var (
+	debug         *debugBackend
+	debugMessages []string
+)
+
+// debugBackend checks for errors after every call to the wrapped backend and
+// holds the first failure until it is claimed by checkError. Failures of
+// calls that act on bound objects are attributed to the last bound object.
+type debugBackend struct {
+	Backend
+	err         *Error
+	program     uint32
+	buffer      uint32
+	vertexArray uint32
+	texture     uint32
+	framebuffer uint32
+}
+
+func wrapBackend(b Backend) Backend {
+	debug = &debugBackend{
+		Backend: b,
+	}
+	return debug
+}
+
+func unwrapBackend(b Backend) Backend {
+	d, ok := b.(*debugBackend)
+	if ok {
+		return d.Backend
+	}
+	return b
+}
+
+// checkError returns and clears the first failure since the last check,
+// noting the provided object being checked.
+func checkError(object uint32) error {
+	err := debug.err
+	if err == nil {
+		return nil
+	}
+	debug.err = nil
+	err.Checked = object
+	return err
+}
+
+// enableDebugOutput routes KHR_debug messages into the errors returned by
+// checkError, if the driver supports it.
+func enableDebugOutput() {
+	if !hasExtension("GL_KHR_debug") {
+		log.Warn("GL_KHR_debug is not supported, falling back to glGetError")
+		return
+	}
+	// synchronous output delivers messages within the offending call
+	gl.Enable(gl.DEBUG_OUTPUT)
+	gl.Enable(gl.DEBUG_OUTPUT_SYNCHRONOUS)
+	gl.DebugMessageCallback(func(source uint32, typ uint32, id uint32, severity uint32, length int32, message string, userParam unsafe.Pointer) {
+		if typ == gl.DEBUG_TYPE_ERROR {
+			debugMessages = append(debugMessages, message)
+			return
+		}
+		if severity == gl.DEBUG_SEVERITY_HIGH || severity == gl.DEBUG_SEVERITY_MEDIUM {
+			log.Warnf("GL debug message: %s", message)
+		}
+	}, nil)
+}
+
+func hasExtension(name string) bool {
+	var count int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := int32(0); i < count; i++ {
+		if gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i))) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *debugBackend) check(op string, object uint32) {
+	code := d.Backend.GetError()
+	for i := 0; i < maxErrorFlags && d.Backend.GetError() != gl.NO_ERROR; i++ {
+	}
+	messages := debugMessages
+	debugMessages = nil
+	if code == gl.NO_ERROR && len(messages) == 0 {
+		return
+	}
+	// only the first failure is kept, later ones are usually a consequence
+	if d.err != nil {
+		return
+	}
+	d.err = &Error{
+		Op:      op,
+		Object:  object,
+		Code:    code,
+		Message: strings.Join(messages, "; "),
+	}
+}
+
+func (d *debugBackend) GetString(name uint32) string {
+	str := d.Backend.GetString(name)
+	d.check("GetString", 0)
+	return str
+}
+
+func (d *debugBackend) Enable(state uint32) {
+	d.Backend.Enable(state)
+	d.check("Enable", 0)
+}
+
+func (d *debugBackend) Disable(state uint32) {
+	d.Backend.Disable(state)
+	d.check("Disable", 0)
+}
+
+func (d *debugBackend) BlendFunc(sfactor uint32, dfactor uint32) {
+	d.Backend.BlendFunc(sfactor, dfactor)
+	d.check("BlendFunc", 0)
+}
+
+func (d *debugBackend) CullFace(mode uint32) {
+	d.Backend.CullFace(mode)
+	d.check("CullFace", 0)
+}
+
+func (d *debugBackend) DepthMask(flag bool) {
+	d.Backend.DepthMask(flag)
+	d.check("DepthMask", 0)
+}
+
+func (d *debugBackend) DepthFunc(xfunc uint32) {
+	d.Backend.DepthFunc(xfunc)
+	d.check("DepthFunc", 0)
+}
+
+func (d *debugBackend) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32, dstAlpha uint32) {
+	d.Backend.BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha)
+	d.check("BlendFuncSeparate", 0)
+}
+
+func (d *debugBackend) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
+	d.Backend.BlendEquationSeparate(modeRGB, modeAlpha)
+	d.check("BlendEquationSeparate", 0)
+}
+
+func (d *debugBackend) BlendColor(red float32, green float32, blue float32, alpha float32) {
+	d.Backend.BlendColor(red, green, blue, alpha)
+	d.check("BlendColor", 0)
+}
+
+func (d *debugBackend) ColorMask(red bool, green bool, blue bool, alpha bool) {
+	d.Backend.ColorMask(red, green, blue, alpha)
+	d.check("ColorMask", 0)
+}
+
+func (d *debugBackend) StencilFunc(xfunc uint32, ref int32, mask uint32) {
+	d.Backend.StencilFunc(xfunc, ref, mask)
+	d.check("StencilFunc", 0)
+}
+
+func (d *debugBackend) StencilOp(sfail uint32, dpfail uint32, dppass uint32) {
+	d.Backend.StencilOp(sfail, dpfail, dppass)
+	d.check("StencilOp", 0)
+}
+
+func (d *debugBackend) StencilMask(mask uint32) {
+	d.Backend.StencilMask(mask)
+	d.check("StencilMask", 0)
+}
+
+func (d *debugBackend) Scissor(x int32, y int32, width int32, height int32) {
+	d.Backend.Scissor(x, y, width, height)
+	d.check("Scissor", 0)
+}
+
+func (d *debugBackend) PolygonOffset(factor float32, units float32) {
+	d.Backend.PolygonOffset(factor, units)
+	d.check("PolygonOffset", 0)
+}
+
+func (d *debugBackend) LineWidth(width float32) {
+	d.Backend.LineWidth(width)
+	d.check("LineWidth", 0)
+}
+
+func (d *debugBackend) PolygonMode(face uint32, mode uint32) {
+	d.Backend.PolygonMode(face, mode)
+	d.check("PolygonMode", 0)
+}
+
+func (d *debugBackend) Viewport(x int32, y int32, width int32, height int32) {
+	d.Backend.Viewport(x, y, width, height)
+	d.check("Viewport", 0)
+}
+
+func (d *debugBackend) ClearColor(red float32, green float32, blue float32, alpha float32) {
+	d.Backend.ClearColor(red, green, blue, alpha)
+	d.check("ClearColor", 0)
+}
+
+func (d *debugBackend) ClearDepth(depth float32) {
+	d.Backend.ClearDepth(depth)
+	d.check("ClearDepth", 0)
+}
+
+func (d *debugBackend) ClearStencil(s int32) {
+	d.Backend.ClearStencil(s)
+	d.check("ClearStencil", 0)
+}
+
+func (d *debugBackend) Clear(mask uint32) {
+	d.Backend.Clear(mask)
+	d.check("Clear", 0)
+}
+
+func (d *debugBackend) CreateShader(typ uint32, source string) (uint32, error) {
+	shader, err := d.Backend.CreateShader(typ, source)
+	d.check("CreateShader", shader)
+	return shader, err
+}
+
+func (d *debugBackend) DeleteShader(shader uint32) {
+	d.Backend.DeleteShader(shader)
+	d.check("DeleteShader", shader)
+}
+
+func (d *debugBackend) CreateProgram() uint32 {
+	program := d.Backend.CreateProgram()
+	d.check("CreateProgram", program)
+	return program
+}
+
+func (d *debugBackend) AttachShader(program uint32, shader uint32) {
+	d.Backend.AttachShader(program, shader)
+	d.check("AttachShader", program)
+}
+
+func (d *debugBackend) DetachShader(program uint32, shader uint32) {
+	d.Backend.DetachShader(program, shader)
+	d.check("DetachShader", program)
+}
+
+func (d *debugBackend) TransformFeedbackVaryings(program uint32, varyings []string, bufferMode uint32) {
+	d.Backend.TransformFeedbackVaryings(program, varyings, bufferMode)
+	d.check("TransformFeedbackVaryings", program)
+}
+
+func (d *debugBackend) LinkProgram(program uint32) error {
+	err := d.Backend.LinkProgram(program)
+	d.check("LinkProgram", program)
+	return err
+}
+
+func (d *debugBackend) GetProgramBinary(program uint32) (uint32, []byte, error) {
+	format, binary, err := d.Backend.GetProgramBinary(program)
+	d.check("GetProgramBinary", program)
+	return format, binary, err
+}
+
+func (d *debugBackend) ProgramBinary(program uint32, format uint32, binary []byte) error {
+	err := d.Backend.ProgramBinary(program, format, binary)
+	d.check("ProgramBinary", program)
+	return err
+}
+
+func (d *debugBackend) UseProgram(program uint32) {
+	d.Backend.UseProgram(program)
+	d.program = program
+	d.check("UseProgram", program)
+}
+
+func (d *debugBackend) DeleteProgram(program uint32) {
+	d.Backend.DeleteProgram(program)
+	d.check("DeleteProgram", program)
+}
+
+func (d *debugBackend) ActiveAttributes(program uint32) []*AttributeDescriptor {
+	attributes := d.Backend.ActiveAttributes(program)
+	d.check("ActiveAttributes", program)
+	return attributes
+}
+
+func (d *debugBackend) ActiveUniforms(program uint32) []*UniformDescriptor {
+	uniforms := d.Backend.ActiveUniforms(program)
+	d.check("ActiveUniforms", program)
+	return uniforms
+}
+
+func (d *debugBackend) ActiveUniformBlocks(program uint32) []*UniformBlockDescriptor {
+	blocks := d.Backend.ActiveUniformBlocks(program)
+	d.check("ActiveUniformBlocks", program)
+	return blocks
+}
+
+func (d *debugBackend) UniformBlockBinding(program uint32, index uint32, binding uint32) {
+	d.Backend.UniformBlockBinding(program, index, binding)
+	d.check("UniformBlockBinding", program)
+}
+
+func (d *debugBackend) Uniform1i(location int32, value int32) {
+	d.Backend.Uniform1i(location, value)
+	d.check("Uniform1i", d.program)
+}
+
+func (d *debugBackend) Uniform1ui(location int32, value uint32) {
+	d.Backend.Uniform1ui(location, value)
+	d.check("Uniform1ui", d.program)
+}
+
+func (d *debugBackend) Uniform1f(location int32, value float32) {
+	d.Backend.Uniform1f(location, value)
+	d.check("Uniform1f", d.program)
+}
+
+func (d *debugBackend) Uniform1iv(location int32, count int32, value *int32) {
+	d.Backend.Uniform1iv(location, count, value)
+	d.check("Uniform1iv", d.program)
+}
+
+func (d *debugBackend) Uniform1uiv(location int32, count int32, value *uint32) {
+	d.Backend.Uniform1uiv(location, count, value)
+	d.check("Uniform1uiv", d.program)
+}
+
+func (d *debugBackend) Uniform1fv(location int32, count int32, value *float32) {
+	d.Backend.Uniform1fv(location, count, value)
+	d.check("Uniform1fv", d.program)
+}
+
+func (d *debugBackend) Uniform2fv(location int32, count int32, value *float32) {
+	d.Backend.Uniform2fv(location, count, value)
+	d.check("Uniform2fv", d.program)
+}
+
+func (d *debugBackend) Uniform3fv(location int32, count int32, value *float32) {
+	d.Backend.Uniform3fv(location, count, value)
+	d.check("Uniform3fv", d.program)
+}
+
+func (d *debugBackend) Uniform4fv(location int32, count int32, value *float32) {
+	d.Backend.Uniform4fv(location, count, value)
+	d.check("Uniform4fv", d.program)
+}
+
+func (d *debugBackend) UniformMatrix3fv(location int32, count int32, value *float32) {
+	d.Backend.UniformMatrix3fv(location, count, value)
+	d.check("UniformMatrix3fv", d.program)
+}
+
+func (d *debugBackend) UniformMatrix4fv(location int32, count int32, value *float32) {
+	d.Backend.UniformMatrix4fv(location, count, value)
+	d.check("UniformMatrix4fv", d.program)
+}
+
+func (d *debugBackend) CreateBuffer() uint32 {
+	buffer := d.Backend.CreateBuffer()
+	d.check("CreateBuffer", buffer)
+	return buffer
+}
+
+func (d *debugBackend) BindBuffer(target uint32, buffer uint32) {
+	d.Backend.BindBuffer(target, buffer)
+	d.buffer = buffer
+	d.check("BindBuffer", buffer)
+}
+
+func (d *debugBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	d.Backend.BufferData(target, size, data, usage)
+	d.check("BufferData", d.buffer)
+}
+
+func (d *debugBackend) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	d.Backend.BufferSubData(target, offset, size, data)
+	d.check("BufferSubData", d.buffer)
+}
+
+func (d *debugBackend) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	d.Backend.BindBufferBase(target, index, buffer)
+	d.check("BindBufferBase", buffer)
+}
+
+func (d *debugBackend) DeleteBuffer(buffer uint32) {
+	d.Backend.DeleteBuffer(buffer)
+	d.check("DeleteBuffer", buffer)
+}
+
+func (d *debugBackend) CreateVertexArray() uint32 {
+	array := d.Backend.CreateVertexArray()
+	d.check("CreateVertexArray", array)
+	return array
+}
+
+func (d *debugBackend) BindVertexArray(array uint32) {
+	d.Backend.BindVertexArray(array)
+	d.vertexArray = array
+	d.check("BindVertexArray", array)
+}
+
+func (d *debugBackend) EnableVertexAttribArray(index uint32) {
+	d.Backend.EnableVertexAttribArray(index)
+	d.check("EnableVertexAttribArray", d.vertexArray)
+}
+
+func (d *debugBackend) VertexAttribPointer(index uint32, size int32, typ uint32, normalized bool, stride int32, offset int) {
+	d.Backend.VertexAttribPointer(index, size, typ, normalized, stride, offset)
+	d.check("VertexAttribPointer", d.vertexArray)
+}
+
+func (d *debugBackend) VertexAttribDivisor(index uint32, divisor uint32) {
+	d.Backend.VertexAttribDivisor(index, divisor)
+	d.check("VertexAttribDivisor", d.vertexArray)
+}
+
+func (d *debugBackend) DeleteVertexArray(array uint32) {
+	d.Backend.DeleteVertexArray(array)
+	d.check("DeleteVertexArray", array)
+}
+
+func (d *debugBackend) CreateTexture() uint32 {
+	texture := d.Backend.CreateTexture()
+	d.check("CreateTexture", texture)
+	return texture
+}
+
+func (d *debugBackend) ActiveTexture(unit uint32) {
+	d.Backend.ActiveTexture(unit)
+	d.check("ActiveTexture", 0)
+}
+
+func (d *debugBackend) BindTexture(target uint32, texture uint32) {
+	d.Backend.BindTexture(target, texture)
+	d.texture = texture
+	d.check("BindTexture", texture)
+}
+
+func (d *debugBackend) TexParameteri(target uint32, pname uint32, param int32) {
+	d.Backend.TexParameteri(target, pname, param)
+	d.check("TexParameteri", d.texture)
+}
+
+func (d *debugBackend) TexImage2D(target uint32, level int32, internalFormat int32, width int32, height int32, format uint32, typ uint32, data unsafe.Pointer) {
+	d.Backend.TexImage2D(target, level, internalFormat, width, height, format, typ, data)
+	d.check("TexImage2D", d.texture)
+}
+
+func (d *debugBackend) GenerateMipmap(target uint32) {
+	d.Backend.GenerateMipmap(target)
+	d.check("GenerateMipmap", d.texture)
+}
+
+func (d *debugBackend) DeleteTexture(texture uint32) {
+	d.Backend.DeleteTexture(texture)
+	d.check("DeleteTexture", texture)
+}
+
+func (d *debugBackend) CreateFramebuffer() uint32 {
+	framebuffer := d.Backend.CreateFramebuffer()
+	d.check("CreateFramebuffer", framebuffer)
+	return framebuffer
+}
+
+func (d *debugBackend) BindFramebuffer(target uint32, framebuffer uint32) {
+	d.Backend.BindFramebuffer(target, framebuffer)
+	d.framebuffer = framebuffer
+	d.check("BindFramebuffer", framebuffer)
+}
+
+func (d *debugBackend) FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	d.Backend.FramebufferTexture2D(target, attachment, textarget, texture, level)
+	d.check("FramebufferTexture2D", d.framebuffer)
+}
+
+func (d *debugBackend) CheckFramebufferStatus(target uint32) uint32 {
+	status := d.Backend.CheckFramebufferStatus(target)
+	d.check("CheckFramebufferStatus", d.framebuffer)
+	return status
+}
+
+func (d *debugBackend) DrawBuffers(buffers []uint32) {
+	d.Backend.DrawBuffers(buffers)
+	d.check("DrawBuffers", d.framebuffer)
+}
+
+func (d *debugBackend) DeleteFramebuffer(framebuffer uint32) {
+	d.Backend.DeleteFramebuffer(framebuffer)
+	d.check("DeleteFramebuffer", framebuffer)
+}
+
+func (d *debugBackend) PatchParameteri(pname uint32, value int32) {
+	d.Backend.PatchParameteri(pname, value)
+	d.check("PatchParameteri", 0)
+}
+
+func (d *debugBackend) BeginTransformFeedback(primitiveMode uint32) {
+	d.Backend.BeginTransformFeedback(primitiveMode)
+	d.check("BeginTransformFeedback", d.program)
+}
+
+func (d *debugBackend) EndTransformFeedback() {
+	d.Backend.EndTransformFeedback()
+	d.check("EndTransformFeedback", d.program)
+}
+
+func (d *debugBackend) DrawArrays(mode uint32, first int32, count int32) {
+	d.Backend.DrawArrays(mode, first, count)
+	d.check("DrawArrays", d.vertexArray)
+}
+
+func (d *debugBackend) DrawArraysInstanced(mode uint32, first int32, count int32, primcount int32) {
+	d.Backend.DrawArraysInstanced(mode, first, count, primcount)
+	d.check("DrawArraysInstanced", d.vertexArray)
+}
+
+func (d *debugBackend) DrawElements(mode uint32, count int32, typ uint32, byteOffset int) {
+	d.Backend.DrawElements(mode, count, typ, byteOffset)
+	d.check("DrawElements", d.vertexArray)
+}
+
+func (d *debugBackend) DrawElementsInstanced(mode uint32, count int32, typ uint32, byteOffset int, primcount int32) {
+	d.Backend.DrawElementsInstanced(mode, count, typ, byteOffset, primcount)
+	d.check("DrawElementsInstanced", d.vertexArray)
+}
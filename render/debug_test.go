@@ -0,0 +1,57 @@
+//go:build debug
+// +build debug
+
+package render
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// failingBackend represents a backend where binding a texture fails.
+type failingBackend struct {
+	Backend
+	code uint32
+}
+
+func (b *failingBackend) GetError() uint32 {
+	code := b.code
+	b.code = gl.NO_ERROR
+	return code
+}
+
+func (b *failingBackend) BindTexture(target uint32, texture uint32) {
+	b.code = gl.INVALID_OPERATION
+}
+
+func (b *failingBackend) UseProgram(program uint32) {
+}
+
+func TestCheckErrorKeepsFailingObject(t *testing.T) {
+	previous := debug
+	defer func() {
+		debug = previous
+	}()
+	d := wrapBackend(&failingBackend{}).(*debugBackend)
+	d.BindTexture(gl.TEXTURE_2D, 7)
+	d.UseProgram(3)
+	err := checkError(3)
+	e, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if e.Op != "BindTexture" || e.Object != 7 || e.Checked != 3 {
+		t.Errorf("expected BindTexture on object 7 checked at 3, got %s on object %d checked at %d",
+			e.Op,
+			e.Object,
+			e.Checked)
+	}
+	expected := "BindTexture failed on object 7, found while checking object 3: GL_INVALID_OPERATION"
+	if e.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, e.Error())
+	}
+	if checkError(3) != nil {
+		t.Error("expected the failure to be cleared once claimed")
+	}
+}
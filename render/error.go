@@ -0,0 +1,83 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Error represents a failed render operation.
+type Error struct {
+	// Op is the backend call or render operation that failed.
+	Op string
+	// Object is the name of the object the operation was issued against.
+	Object uint32
+	// Checked is the name of the object whose operation was being checked
+	// when the failure was found. It differs from Object if an earlier call
+	// failed.
+	Checked uint32
+	// Code is the GL error code, or zero if the failure was not reported by
+	// glGetError.
+	Code uint32
+	// Message describes the failure.
+	Message string
+	// Technique is the technique being drawn when the failure occurred.
+	Technique *Technique
+	// Command is the command being executed when the failure occurred.
+	Command *Command
+}
+
+// Error returns the string representation of the error.
+func (e *Error) Error() string {
+	str := fmt.Sprintf("%s failed", e.Op)
+	if e.Object != 0 {
+		str += fmt.Sprintf(" on object %d", e.Object)
+	}
+	if e.Checked != 0 && e.Checked != e.Object {
+		str += fmt.Sprintf(", found while checking object %d", e.Checked)
+	}
+	if e.Code != 0 {
+		str += fmt.Sprintf(": %s", errorCodeString(e.Code))
+	}
+	if e.Message != "" {
+		str += fmt.Sprintf(": %s", e.Message)
+	}
+	if e.Technique != nil && e.Technique.shader != nil {
+		str += fmt.Sprintf(", in technique with shader %d", e.Technique.shader.id)
+	}
+	if e.Command != nil && e.Command.renderable != nil {
+		str += fmt.Sprintf(", in command with renderable %d", e.Command.renderable.id)
+	}
+	return str
+}
+
+// withDraw attaches the technique and command being drawn to the error.
+func withDraw(err error, technique *Technique, command *Command) error {
+	e, ok := err.(*Error)
+	if !ok {
+		return err
+	}
+	e.Technique = technique
+	e.Command = command
+	return e
+}
+
+func errorCodeString(code uint32) string {
+	switch code {
+	case gl.INVALID_ENUM:
+		return "GL_INVALID_ENUM"
+	case gl.INVALID_VALUE:
+		return "GL_INVALID_VALUE"
+	case gl.INVALID_OPERATION:
+		return "GL_INVALID_OPERATION"
+	case gl.INVALID_FRAMEBUFFER_OPERATION:
+		return "GL_INVALID_FRAMEBUFFER_OPERATION"
+	case gl.OUT_OF_MEMORY:
+		return "GL_OUT_OF_MEMORY"
+	case gl.STACK_UNDERFLOW:
+		return "GL_STACK_UNDERFLOW"
+	case gl.STACK_OVERFLOW:
+		return "GL_STACK_OVERFLOW"
+	}
+	return fmt.Sprintf("0x%x", code)
+}
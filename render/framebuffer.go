@@ -14,47 +14,45 @@ type FrameBuffer struct {
 
 // NewFrameBuffer instantiates and returns a new framebuffer instance.
 func NewFrameBuffer() *FrameBuffer {
-	var id uint32
-	gl.GenFramebuffers(1, &id)
 	return &FrameBuffer{
-		id:       id,
+		id:       backend.CreateFramebuffer(),
 		textures: make(map[uint32]*Texture),
 	}
 }
 
 // Bind binds the framebuffer object.
 func (f *FrameBuffer) Bind() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, f.id)
 }
 
 // Unbind unbinds the framebuffer object.
 func (f *FrameBuffer) Unbind() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, 0)
 }
 
 // BindForDraw binds the framebuffer object for drawing.
 func (f *FrameBuffer) BindForDraw() {
-	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, f.id)
+	backend.BindFramebuffer(gl.DRAW_FRAMEBUFFER, f.id)
 }
 
 // UnbindForDraw unbinds the framebuffer object for drawing.
 func (f *FrameBuffer) UnbindForDraw() {
-	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
+	backend.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
 }
 
 // BindForRead binds the framebuffer object for reading.
 func (f *FrameBuffer) BindForRead() {
-	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.id)
+	backend.BindFramebuffer(gl.READ_FRAMEBUFFER, f.id)
 }
 
 // UnbindForRead unbinds the framebuffer object for reading.
 func (f *FrameBuffer) UnbindForRead() {
-	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	backend.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
 }
 
 // SetDrawBuffers sets the draw buffers for the framebuffer object.
 func (f *FrameBuffer) SetDrawBuffers(buffers []uint32) {
-	gl.DrawBuffers(int32(len(buffers)), &buffers[0])
+	backend.DrawBuffers(buffers)
 }
 
 // AttachTexture attaches the provided texture to the provided attachment id.
@@ -65,13 +63,16 @@ func (f *FrameBuffer) AttachTexture(attachment uint32, texture *Texture) error {
 			attachment)
 	}
 	f.Bind()
-	gl.FramebufferTexture2D(
+	backend.FramebufferTexture2D(
 		gl.FRAMEBUFFER,
 		attachment,
 		gl.TEXTURE_2D,
 		texture.ID(),
 		0)
-	err := f.checkAttachmentError()
+	err := checkError(f.id)
+	if err == nil {
+		err = f.checkAttachmentError()
+	}
 	f.Unbind()
 	if err == nil {
 		f.textures[attachment] = texture
@@ -85,22 +86,35 @@ func (f *FrameBuffer) Texture(attachment uint32) (*Texture, bool) {
 	return tex, ok
 }
 
+// Size returns the size of the attached textures, or zero if none are
+// attached.
+func (f *FrameBuffer) Size() (uint32, uint32) {
+	for _, texture := range f.textures {
+		return texture.Width(), texture.Height()
+	}
+	return 0, 0
+}
+
 // Resize will resize all attached textures.
-func (f *FrameBuffer) Resize(width uint32, height uint32) {
+func (f *FrameBuffer) Resize(width uint32, height uint32) error {
 	for _, texture := range f.textures {
-		texture.Resize(width, height)
+		err := texture.Resize(width, height)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // Destroy deallocates the framebuffer object.
 func (f *FrameBuffer) Destroy() {
-	gl.DeleteFramebuffers(1, &f.id)
+	backend.DeleteFramebuffer(f.id)
 	f.id = 0
 }
 
 func (f *FrameBuffer) checkAttachmentError() error {
 	// check for errors
-	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	status := backend.CheckFramebufferStatus(gl.FRAMEBUFFER)
 	if status == gl.FRAMEBUFFER_COMPLETE {
 		return nil
 	}
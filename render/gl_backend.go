@@ -0,0 +1,754 @@
+package render
+
+import (
+	"errors"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// GLBackend represents the default OpenGL 4.1 core backend. It requires a
+// current OpenGL context.
+type GLBackend struct{}
+
+// GetError returns and clears the oldest error flag.
+func (b *GLBackend) GetError() uint32 {
+	return gl.GetError()
+}
+
+// GetString returns a string describing the current connection.
+func (b *GLBackend) GetString(name uint32) string {
+	return gl.GoStr(gl.GetString(name))
+}
+
+// Enable enables a server-side capability.
+func (b *GLBackend) Enable(state uint32) {
+	gl.Enable(state)
+}
+
+// Disable disables a server-side capability.
+func (b *GLBackend) Disable(state uint32) {
+	gl.Disable(state)
+}
+
+// BlendFunc sets the pixel arithmetic.
+func (b *GLBackend) BlendFunc(sfactor uint32, dfactor uint32) {
+	gl.BlendFunc(sfactor, dfactor)
+}
+
+// CullFace sets which faces are culled.
+func (b *GLBackend) CullFace(mode uint32) {
+	gl.CullFace(mode)
+}
+
+// DepthMask enables or disables writing into the depth buffer.
+func (b *GLBackend) DepthMask(flag bool) {
+	gl.DepthMask(flag)
+}
+
+// DepthFunc sets the depth comparison function.
+func (b *GLBackend) DepthFunc(xfunc uint32) {
+	gl.DepthFunc(xfunc)
+}
+
+// BlendFuncSeparate sets the pixel arithmetic for the RGB and alpha
+// components separately.
+func (b *GLBackend) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32, dstAlpha uint32) {
+	gl.BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha)
+}
+
+// BlendEquationSeparate sets the blend equations for the RGB and alpha
+// components.
+func (b *GLBackend) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
+	gl.BlendEquationSeparate(modeRGB, modeAlpha)
+}
+
+// BlendColor sets the constant blend color.
+func (b *GLBackend) BlendColor(red float32, green float32, blue float32, alpha float32) {
+	gl.BlendColor(red, green, blue, alpha)
+}
+
+// ColorMask enables or disables writing of the color components.
+func (b *GLBackend) ColorMask(red bool, green bool, blue bool, alpha bool) {
+	gl.ColorMask(red, green, blue, alpha)
+}
+
+// StencilFunc sets the stencil test function and reference value.
+func (b *GLBackend) StencilFunc(xfunc uint32, ref int32, mask uint32) {
+	gl.StencilFunc(xfunc, ref, mask)
+}
+
+// StencilOp sets the stencil test actions.
+func (b *GLBackend) StencilOp(sfail uint32, dpfail uint32, dppass uint32) {
+	gl.StencilOp(sfail, dpfail, dppass)
+}
+
+// StencilMask sets which bits of the stencil buffer are written.
+func (b *GLBackend) StencilMask(mask uint32) {
+	gl.StencilMask(mask)
+}
+
+// Scissor sets the scissor rectangle.
+func (b *GLBackend) Scissor(x int32, y int32, width int32, height int32) {
+	gl.Scissor(x, y, width, height)
+}
+
+// PolygonOffset sets the scale and units used to offset depth values.
+func (b *GLBackend) PolygonOffset(factor float32, units float32) {
+	gl.PolygonOffset(factor, units)
+}
+
+// LineWidth sets the width of rasterized lines.
+func (b *GLBackend) LineWidth(width float32) {
+	gl.LineWidth(width)
+}
+
+// PolygonMode sets how polygons are rasterized.
+func (b *GLBackend) PolygonMode(face uint32, mode uint32) {
+	gl.PolygonMode(face, mode)
+}
+
+// Viewport sets the viewport.
+func (b *GLBackend) Viewport(x int32, y int32, width int32, height int32) {
+	gl.Viewport(x, y, width, height)
+}
+
+// ClearColor sets the clear values for the color buffers.
+func (b *GLBackend) ClearColor(red float32, green float32, blue float32, alpha float32) {
+	gl.ClearColor(red, green, blue, alpha)
+}
+
+// ClearDepth sets the clear value for the depth buffer.
+func (b *GLBackend) ClearDepth(depth float32) {
+	gl.ClearDepthf(depth)
+}
+
+// ClearStencil sets the clear value for the stencil buffer.
+func (b *GLBackend) ClearStencil(s int32) {
+	gl.ClearStencil(s)
+}
+
+// Clear clears the provided buffers to their preset values.
+func (b *GLBackend) Clear(mask uint32) {
+	gl.Clear(mask)
+}
+
+// CreateShader creates and compiles a shader object.
+func (b *GLBackend) CreateShader(typ uint32, source string) (uint32, error) {
+	// create shader object
+	shader := gl.CreateShader(typ)
+	// get c string
+	cstr, free := gl.Strs(source + "\x00")
+	// set source code of shader object
+	gl.ShaderSource(shader, 1, cstr, nil)
+	// free c string
+	free()
+	// compile shader
+	gl.CompileShader(shader)
+	// check error
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		// get info log length
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+		// get error message
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		log = strings.TrimRight(log, "\x00")
+		// delete current object
+		gl.DeleteShader(shader)
+		return 0, errors.New(log)
+	}
+	return shader, nil
+}
+
+// DeleteShader deletes a shader object.
+func (b *GLBackend) DeleteShader(shader uint32) {
+	gl.DeleteShader(shader)
+}
+
+// CreateProgram creates a program object.
+func (b *GLBackend) CreateProgram() uint32 {
+	return gl.CreateProgram()
+}
+
+// AttachShader attaches a shader object to a program object.
+func (b *GLBackend) AttachShader(program uint32, shader uint32) {
+	gl.AttachShader(program, shader)
+}
+
+// DetachShader detaches a shader object from a program object.
+func (b *GLBackend) DetachShader(program uint32, shader uint32) {
+	gl.DetachShader(program, shader)
+}
+
+// TransformFeedbackVaryings specifies the varyings captured by transform
+// feedback. This takes effect when the program is next linked.
+func (b *GLBackend) TransformFeedbackVaryings(program uint32, varyings []string, bufferMode uint32) {
+	strs := make([]string, len(varyings))
+	for i, varying := range varyings {
+		strs[i] = varying + "\x00"
+	}
+	cstrs, free := gl.Strs(strs...)
+	gl.TransformFeedbackVaryings(program, int32(len(varyings)), cstrs, bufferMode)
+	free()
+}
+
+// LinkProgram links a program object.
+func (b *GLBackend) LinkProgram(program uint32) error {
+	// allow the linked binary to be retrieved
+	gl.ProgramParameteri(program, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	// link shader program
+	gl.LinkProgram(program)
+	return linkStatus(program)
+}
+
+// GetProgramBinary returns the binary format and binary of a linked program
+// object.
+func (b *GLBackend) GetProgramBinary(program uint32) (uint32, []byte, error) {
+	var length int32
+	gl.GetProgramiv(program, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length == 0 {
+		return 0, nil, errors.New("program binaries are not supported by the driver")
+	}
+	var format uint32
+	binary := make([]byte, length)
+	gl.GetProgramBinary(program, length, &length, &format, gl.Ptr(binary))
+	return format, binary[:length], nil
+}
+
+// ProgramBinary loads a program object with a binary. The driver may reject
+// binaries, for example after an update, in which case an error is returned.
+func (b *GLBackend) ProgramBinary(program uint32, format uint32, binary []byte) error {
+	if len(binary) == 0 {
+		return errors.New("program binary is empty")
+	}
+	gl.ProgramBinary(program, format, gl.Ptr(binary), int32(len(binary)))
+	return linkStatus(program)
+}
+
+func linkStatus(program uint32) error {
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		log = strings.TrimRight(log, "\x00")
+		if log == "" {
+			// drivers often reject binaries without an info log
+			log = "program is not linked"
+		}
+		return errors.New(log)
+	}
+	return nil
+}
+
+// UseProgram installs a program object as part of the current rendering
+// state.
+func (b *GLBackend) UseProgram(program uint32) {
+	gl.UseProgram(program)
+}
+
+// DeleteProgram deletes a program object.
+func (b *GLBackend) DeleteProgram(program uint32) {
+	gl.DeleteProgram(program)
+}
+
+// ActiveAttributes returns descriptors for all active vertex attributes of a
+// program, excluding built-in inputs.
+func (b *GLBackend) ActiveAttributes(program uint32) []*AttributeDescriptor {
+	// get the number of attributes and the longest name
+	var numActiveAttributes, maxNameLength int32
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTES, &numActiveAttributes)
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxNameLength)
+	descriptors := make([]*AttributeDescriptor, 0, numActiveAttributes)
+	for i := int32(0); i < numActiveAttributes; i++ {
+		var length, size int32
+		var typ uint32
+		name := make([]uint8, maxNameLength+1)
+		gl.GetActiveAttrib(program, uint32(i), maxNameLength+1, &length, &size, &typ, &name[0])
+		str := string(name[:length])
+		// built-in inputs such as gl_VertexID have no location
+		location := gl.GetAttribLocation(program, gl.Str(str+"\x00"))
+		if location < 0 {
+			continue
+		}
+		descriptors = append(descriptors, &AttributeDescriptor{
+			Name:     str,
+			Type:     typ,
+			Count:    size,
+			Location: location,
+		})
+	}
+	return descriptors
+}
+
+// ActiveUniforms returns descriptors for all active uniforms of a program
+// that are not part of a uniform block.
+func (b *GLBackend) ActiveUniforms(program uint32) []*UniformDescriptor {
+	// query all necessary uniform information
+	uniformIndices := queryUniformIndices(program)
+	uniformNames := queryUniformNames(program, uniformIndices)
+	uniformTypes := queryUniformTypes(program, uniformIndices)
+	uniformCounts := queryUniformCounts(program, uniformIndices)
+	parentBlockIndices := queryParentBlockIndices(program, uniformIndices)
+	uniformLocations := queryUniformLocations(program, uniformNames)
+
+	descriptors := make([]*UniformDescriptor, 0, len(uniformIndices))
+	for _, index := range uniformIndices {
+		// check if part of a block or not
+		if parentBlockIndices[index] != -1 {
+			continue
+		}
+		descriptors = append(descriptors, &UniformDescriptor{
+			Name:     uniformNames[index],
+			Type:     uniformTypes[index],
+			Count:    uniformCounts[index],
+			Location: uniformLocations[index],
+		})
+	}
+	return descriptors
+}
+
+// ActiveUniformBlocks returns descriptors for all active uniform blocks of a
+// program.
+func (b *GLBackend) ActiveUniformBlocks(program uint32) []*UniformBlockDescriptor {
+	// query all necessary uniform information
+	uniformIndices := queryUniformIndices(program)
+	uniformNames := queryUniformNames(program, uniformIndices)
+	parentBlockIndices := queryParentBlockIndices(program, uniformIndices)
+	uniformOffsets := queryUniformOffsets(program, uniformIndices)
+	uniformTypes := queryUniformTypes(program, uniformIndices)
+	uniformCounts := queryUniformCounts(program, uniformIndices)
+
+	// query all necessary uniform block information
+	blockIndices := queryUniformBlockIndices(program)
+	blockNames := queryUniformBlockNames(program, blockIndices)
+	blockSizes := queryUniformBlockSizes(program, blockIndices)
+	bufferAlignment := queryUniformBufferAlignment()
+
+	descriptors := make([]*UniformBlockDescriptor, 0, len(blockIndices))
+	for _, index := range blockIndices {
+		// get all uniform offsets that are part of this block
+		offsets := make(map[string]int32)
+		members := make(map[string]*UniformDescriptor)
+		for i, parentIndex := range parentBlockIndices {
+			if parentIndex == int32(index) {
+				// uniform is part of this block
+				offsets[uniformNames[i]] = uniformOffsets[i]
+				members[uniformNames[i]] = &UniformDescriptor{
+					Name:     uniformNames[i],
+					Type:     uniformTypes[i],
+					Count:    uniformCounts[i],
+					Location: -1,
+				}
+			}
+		}
+		descriptors = append(descriptors, &UniformBlockDescriptor{
+			Name:      blockNames[index],
+			Index:     blockIndices[index],
+			Size:      blockSizes[index],
+			Offsets:   offsets,
+			Uniforms:  members,
+			Alignment: bufferAlignment,
+		})
+	}
+	return descriptors
+}
+
+// UniformBlockBinding assigns a binding point to an active uniform block.
+func (b *GLBackend) UniformBlockBinding(program uint32, index uint32, binding uint32) {
+	gl.UniformBlockBinding(program, index, binding)
+}
+
+// Uniform1i buffers a int32 by value.
+func (b *GLBackend) Uniform1i(location int32, value int32) {
+	gl.Uniform1i(location, value)
+}
+
+// Uniform1ui buffers an uint32 by value.
+func (b *GLBackend) Uniform1ui(location int32, value uint32) {
+	gl.Uniform1ui(location, value)
+}
+
+// Uniform1f buffers a float32 by value.
+func (b *GLBackend) Uniform1f(location int32, value float32) {
+	gl.Uniform1f(location, value)
+}
+
+// Uniform1iv buffers one or more int32 by address.
+func (b *GLBackend) Uniform1iv(location int32, count int32, value *int32) {
+	gl.Uniform1iv(location, count, value)
+}
+
+// Uniform1uiv buffers one or more uint32 by address.
+func (b *GLBackend) Uniform1uiv(location int32, count int32, value *uint32) {
+	gl.Uniform1uiv(location, count, value)
+}
+
+// Uniform1fv buffers one or more float32 by address.
+func (b *GLBackend) Uniform1fv(location int32, count int32, value *float32) {
+	gl.Uniform1fv(location, count, value)
+}
+
+// Uniform2fv buffers one or more 2-component float32 by address.
+func (b *GLBackend) Uniform2fv(location int32, count int32, value *float32) {
+	gl.Uniform2fv(location, count, value)
+}
+
+// Uniform3fv buffers one or more 3-component float32 by address.
+func (b *GLBackend) Uniform3fv(location int32, count int32, value *float32) {
+	gl.Uniform3fv(location, count, value)
+}
+
+// Uniform4fv buffers one or more 4-component float32 by address.
+func (b *GLBackend) Uniform4fv(location int32, count int32, value *float32) {
+	gl.Uniform4fv(location, count, value)
+}
+
+// UniformMatrix3fv buffers one or more 9-component float32 by address.
+func (b *GLBackend) UniformMatrix3fv(location int32, count int32, value *float32) {
+	gl.UniformMatrix3fv(location, count, false, value)
+}
+
+// UniformMatrix4fv buffers one or more 16-component float32 by address.
+func (b *GLBackend) UniformMatrix4fv(location int32, count int32, value *float32) {
+	gl.UniformMatrix4fv(location, count, false, value)
+}
+
+// CreateBuffer creates a buffer object.
+func (b *GLBackend) CreateBuffer() uint32 {
+	var id uint32
+	gl.GenBuffers(1, &id)
+	return id
+}
+
+// BindBuffer binds a buffer object to the provided target.
+func (b *GLBackend) BindBuffer(target uint32, buffer uint32) {
+	gl.BindBuffer(target, buffer)
+}
+
+// BufferData creates and initializes the data store of the bound buffer.
+func (b *GLBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	gl.BufferData(target, size, data, usage)
+}
+
+// BufferSubData updates a portion of the data store of the bound buffer.
+func (b *GLBackend) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	gl.BufferSubData(target, offset, size, data)
+}
+
+// BindBufferBase binds a buffer object to an indexed binding point of the
+// provided target.
+func (b *GLBackend) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	gl.BindBufferBase(target, index, buffer)
+}
+
+// DeleteBuffer deletes a buffer object.
+func (b *GLBackend) DeleteBuffer(buffer uint32) {
+	gl.DeleteBuffers(1, &buffer)
+}
+
+// CreateVertexArray creates a vertex array object.
+func (b *GLBackend) CreateVertexArray() uint32 {
+	var id uint32
+	gl.GenVertexArrays(1, &id)
+	return id
+}
+
+// BindVertexArray binds a vertex array object.
+func (b *GLBackend) BindVertexArray(array uint32) {
+	gl.BindVertexArray(array)
+}
+
+// EnableVertexAttribArray enables a vertex attribute array.
+func (b *GLBackend) EnableVertexAttribArray(index uint32) {
+	gl.EnableVertexAttribArray(index)
+}
+
+// VertexAttribPointer defines an array of vertex attribute data.
+func (b *GLBackend) VertexAttribPointer(index uint32, size int32, typ uint32, normalized bool, stride int32, offset int) {
+	gl.VertexAttribPointer(index, size, typ, normalized, stride, gl.PtrOffset(offset))
+}
+
+// VertexAttribDivisor sets the instancing divisor of a vertex attribute.
+func (b *GLBackend) VertexAttribDivisor(index uint32, divisor uint32) {
+	gl.VertexAttribDivisor(index, divisor)
+}
+
+// DeleteVertexArray deletes a vertex array object.
+func (b *GLBackend) DeleteVertexArray(array uint32) {
+	gl.DeleteVertexArrays(1, &array)
+}
+
+// CreateTexture creates a texture object.
+func (b *GLBackend) CreateTexture() uint32 {
+	var id uint32
+	gl.GenTextures(1, &id)
+	return id
+}
+
+// ActiveTexture selects the active texture unit.
+func (b *GLBackend) ActiveTexture(unit uint32) {
+	gl.ActiveTexture(unit)
+}
+
+// BindTexture binds a texture object to the provided target.
+func (b *GLBackend) BindTexture(target uint32, texture uint32) {
+	gl.BindTexture(target, texture)
+}
+
+// TexParameteri sets a texture parameter of the bound texture.
+func (b *GLBackend) TexParameteri(target uint32, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+
+// TexImage2D specifies a two-dimensional image for the bound texture.
+func (b *GLBackend) TexImage2D(target uint32, level int32, internalFormat int32, width int32, height int32, format uint32, typ uint32, data unsafe.Pointer) {
+	gl.TexImage2D(target, level, internalFormat, width, height, 0, format, typ, data)
+}
+
+// GenerateMipmap generates mipmaps for the bound texture.
+func (b *GLBackend) GenerateMipmap(target uint32) {
+	gl.GenerateMipmap(target)
+}
+
+// DeleteTexture deletes a texture object.
+func (b *GLBackend) DeleteTexture(texture uint32) {
+	gl.DeleteTextures(1, &texture)
+}
+
+// CreateFramebuffer creates a framebuffer object.
+func (b *GLBackend) CreateFramebuffer() uint32 {
+	var id uint32
+	gl.GenFramebuffers(1, &id)
+	return id
+}
+
+// BindFramebuffer binds a framebuffer object to the provided target.
+func (b *GLBackend) BindFramebuffer(target uint32, framebuffer uint32) {
+	gl.BindFramebuffer(target, framebuffer)
+}
+
+// FramebufferTexture2D attaches a texture image to the bound framebuffer.
+func (b *GLBackend) FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	gl.FramebufferTexture2D(target, attachment, textarget, texture, level)
+}
+
+// CheckFramebufferStatus returns the completeness status of the bound
+// framebuffer.
+func (b *GLBackend) CheckFramebufferStatus(target uint32) uint32 {
+	return gl.CheckFramebufferStatus(target)
+}
+
+// DrawBuffers sets the color buffers to be drawn into.
+func (b *GLBackend) DrawBuffers(buffers []uint32) {
+	gl.DrawBuffers(int32(len(buffers)), &buffers[0])
+}
+
+// DeleteFramebuffer deletes a framebuffer object.
+func (b *GLBackend) DeleteFramebuffer(framebuffer uint32) {
+	gl.DeleteFramebuffers(1, &framebuffer)
+}
+
+// PatchParameteri sets a parameter of patch primitives.
+func (b *GLBackend) PatchParameteri(pname uint32, value int32) {
+	gl.PatchParameteri(pname, value)
+}
+
+// BeginTransformFeedback starts capturing the varyings of the primitives
+// drawn into the bound transform feedback buffers.
+func (b *GLBackend) BeginTransformFeedback(primitiveMode uint32) {
+	gl.BeginTransformFeedback(primitiveMode)
+}
+
+// EndTransformFeedback stops capturing varyings.
+func (b *GLBackend) EndTransformFeedback() {
+	gl.EndTransformFeedback()
+}
+
+// DrawArrays renders primitives from array data.
+func (b *GLBackend) DrawArrays(mode uint32, first int32, count int32) {
+	gl.DrawArrays(mode, first, count)
+}
+
+// DrawArraysInstanced renders multiple instances of primitives from array
+// data.
+func (b *GLBackend) DrawArraysInstanced(mode uint32, first int32, count int32, primcount int32) {
+	gl.DrawArraysInstanced(mode, first, count, primcount)
+}
+
+// DrawElements renders primitives from indexed array data.
+func (b *GLBackend) DrawElements(mode uint32, count int32, typ uint32, byteOffset int) {
+	gl.DrawElements(mode, count, typ, gl.PtrOffset(byteOffset))
+}
+
+// DrawElementsInstanced renders multiple instances of primitives from indexed
+// array data.
+func (b *GLBackend) DrawElementsInstanced(mode uint32, count int32, typ uint32, byteOffset int, primcount int32) {
+	gl.DrawElementsInstanced(mode, count, typ, gl.PtrOffset(byteOffset), primcount)
+}
+
+// BeginTechnique is a no-op, markers are not issued to GL.
+func (b *GLBackend) BeginTechnique(marker *TechniqueMarker) {}
+
+// EndTechnique is a no-op, markers are not issued to GL.
+func (b *GLBackend) EndTechnique() {}
+
+// BeginCommand is a no-op, markers are not issued to GL.
+func (b *GLBackend) BeginCommand(marker *CommandMarker) {}
+
+// EndCommand is a no-op, markers are not issued to GL.
+func (b *GLBackend) EndCommand() {}
+
+func toString(buff []uint8) string {
+	b := make([]byte, len(buff))
+	for i, v := range buff {
+		b[i] = byte(v)
+	}
+	return string(b[:len(b)-1]) // trim null terminator
+}
+
+func toUint32(arr []int32) []uint32 {
+	res := make([]uint32, len(arr))
+	for i, val := range arr {
+		res[i] = uint32(val)
+	}
+	return res
+}
+
+func queryUniformIndices(program uint32) []uint32 {
+	// get the number of uniforms
+	var numActiveUniforms int32
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORMS, &numActiveUniforms)
+	// get uniform indices from 0 to gl.ACTIVE_UNIFORMS
+	indices := make([]uint32, numActiveUniforms)
+	for i := int32(0); i < numActiveUniforms; i++ {
+		indices[i] = uint32(i)
+	}
+	return indices
+}
+
+func queryUniformNames(program uint32, indices []uint32) []string {
+	// check if no uniforms
+	if len(indices) == 0 {
+		return make([]string, 0)
+	}
+	// get uniform name lengths
+	nameLengths := make([]int32, len(indices))
+	gl.GetActiveUniformsiv(program, int32(len(indices)), &indices[0], gl.UNIFORM_NAME_LENGTH, &nameLengths[0])
+	// for each uniform index
+	names := make([]string, len(indices))
+	for _, index := range indices {
+		// get uniform name
+		nameLength := nameLengths[index]
+		// create name slice
+		name := make([]uint8, nameLength)
+		// get name bytes
+		gl.GetActiveUniformName(program, index, nameLength, nil, &name[0])
+		// cast from uint8 to string
+		names[index] = toString(name)
+	}
+	return names
+}
+
+func queryUniformTypes(program uint32, indices []uint32) []uint32 {
+	// check if no uniforms
+	if len(indices) == 0 {
+		return make([]uint32, 0)
+	}
+	// get uniform types
+	types := make([]int32, len(indices))
+	gl.GetActiveUniformsiv(program, int32(len(indices)), &indices[0], gl.UNIFORM_TYPE, &types[0])
+	// cast to uint32
+	return toUint32(types)
+}
+
+func queryUniformCounts(program uint32, indices []uint32) []int32 {
+	// check if no uniforms
+	if len(indices) == 0 {
+		return make([]int32, 0)
+	}
+	// get uniform types
+	sizes := make([]int32, len(indices))
+	gl.GetActiveUniformsiv(program, int32(len(indices)), &indices[0], gl.UNIFORM_SIZE, &sizes[0])
+	return sizes
+}
+
+func queryParentBlockIndices(program uint32, indices []uint32) []int32 {
+	// check if no uniforms
+	if len(indices) == 0 {
+		return make([]int32, 0)
+	}
+	// get uniform block indices (-1 is not part of a block)
+	blockIndices := make([]int32, len(indices))
+	gl.GetActiveUniformsiv(program, int32(len(indices)), &indices[0], gl.UNIFORM_BLOCK_INDEX, &blockIndices[0])
+	return blockIndices
+}
+
+func queryUniformOffsets(program uint32, indices []uint32) []int32 {
+	// check if no uniforms
+	if len(indices) == 0 {
+		return make([]int32, 0)
+	}
+	// get uniform offsets
+	offsets := make([]int32, len(indices))
+	gl.GetActiveUniformsiv(program, int32(len(indices)), &indices[0], gl.UNIFORM_OFFSET, &offsets[0])
+	return offsets
+}
+
+func queryUniformLocations(program uint32, names []string) []int32 {
+	locations := make([]int32, len(names))
+	for i, name := range names {
+		locations[i] = gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+	}
+	return locations
+}
+
+func queryUniformBlockIndices(program uint32) []uint32 {
+	// get the number of active uniform blocks
+	var numActiveBlocks int32
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_BLOCKS, &numActiveBlocks)
+	// get uniform indices from 0 to gl.ACTIVE_UNIFORMS
+	indices := make([]uint32, numActiveBlocks)
+	for i := int32(0); i < numActiveBlocks; i++ {
+		indices[i] = uint32(i)
+	}
+	return indices
+}
+
+func queryUniformBlockNames(program uint32, indices []uint32) []string {
+	names := make([]string, len(indices))
+	for _, index := range indices {
+		// get the length of the name
+		var nameLength int32
+		gl.GetActiveUniformBlockiv(program, index, gl.UNIFORM_BLOCK_NAME_LENGTH, &nameLength)
+		// get the block name
+		name := make([]uint8, nameLength)
+		// get name bytes
+		gl.GetActiveUniformBlockName(program, index, nameLength, nil, &name[0])
+		// cast from uint8 to string
+		names[index] = toString(name)
+	}
+	return names
+}
+
+func queryUniformBlockSizes(program uint32, indices []uint32) []int32 {
+	sizes := make([]int32, len(indices))
+	for _, index := range indices {
+		var blockSize int32
+		gl.GetActiveUniformBlockiv(program, index, gl.UNIFORM_BLOCK_DATA_SIZE, &blockSize)
+		sizes[index] = blockSize
+	}
+	return sizes
+}
+
+func queryUniformBufferAlignment() int32 {
+	var uniformBufferAlignment int32
+	gl.GetIntegerv(gl.UNIFORM_BUFFER_OFFSET_ALIGNMENT, &uniformBufferAlignment)
+	return uniformBufferAlignment
+}
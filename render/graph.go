@@ -0,0 +1,560 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const (
+	// BackBuffer is the name of the default framebuffer in a graph. Passes
+	// writing to it are never culled, and no pass may read it.
+	BackBuffer = "backbuffer"
+)
+
+// PassFunc returns the commands of a pass, provided the textures the pass
+// reads by name.
+type PassFunc func(inputs map[string]*Texture) []*Command
+
+// Pass represents a pass of a graph, drawing the commands of its function
+// with a technique into its outputs.
+type Pass struct {
+	graph     *Graph
+	index     int
+	name      string
+	technique *Technique
+	fn        PassFunc
+	inputs    []string
+	outputs   []string
+}
+
+// Name returns the name of the pass.
+func (p *Pass) Name() string {
+	return p.name
+}
+
+// Read declares textures read by the pass.
+func (p *Pass) Read(names ...string) *Pass {
+	p.inputs = append(p.inputs, names...)
+	p.graph.dirty = true
+	return p
+}
+
+// Write declares textures written by the pass. They are attached to the
+// framebuffer of the pass in order, starting at gl.COLOR_ATTACHMENT0.
+func (p *Pass) Write(names ...string) *Pass {
+	p.outputs = append(p.outputs, names...)
+	p.graph.dirty = true
+	return p
+}
+
+// TransientTexture describes an RGBA texture allocated by a graph.
+type TransientTexture struct {
+	// Scale is the size of the texture relative to the graph, one if zero.
+	Scale float32
+	// Params are the parameters of the texture, linear filtering if nil.
+	Params *TextureParams
+	// Float allocates half float components rather than normalized bytes,
+	// keeping colors outside of [0, 1].
+	Float bool
+}
+
+func (t *TransientTexture) scale() float32 {
+	if t.Scale == 0 {
+		return 1
+	}
+	return t.Scale
+}
+
+func (t *TransientTexture) params() TextureParams {
+	if t.Params == nil {
+		return TextureParams{
+			MinFilter: gl.LINEAR,
+			MagFilter: gl.LINEAR,
+		}
+	}
+	return *t.Params
+}
+
+func (t *TransientTexture) compatible(other *TransientTexture) bool {
+	return t.scale() == other.scale() &&
+		t.params() == other.params() &&
+		t.Float == other.Float
+}
+
+type graphTexture struct {
+	texture    *Texture
+	descriptor *TransientTexture
+	used       bool
+}
+
+// Graph represents the passes of a frame and the textures they exchange.
+// Passes are ordered by the textures they read and write, and passes whose
+// outputs are neither read, exported nor the back buffer are culled.
+// Transient textures are allocated on the first execution, shared between
+// passes whose lifetimes do not overlap, and resized with the graph.
+//
+// Transient textures hold stale contents from earlier frames and passes, so
+// the first pass writing one should clear it. Techniques of passes should
+// not set a viewport, so it follows the size of their target.
+type Graph struct {
+	passes       []*Pass
+	descriptors  map[string]*TransientTexture
+	exports      map[string]bool
+	viewport     *Viewport
+	dirty        bool
+	order        []*Pass
+	pool         []*graphTexture
+	textures     map[string]*Texture
+	frameBuffers map[*Pass]*FrameBuffer
+	width        int
+	height       int
+}
+
+// NewGraph instantiates and returns a new graph.
+func NewGraph() *Graph {
+	return &Graph{
+		descriptors:  make(map[string]*TransientTexture),
+		exports:      make(map[string]bool),
+		textures:     make(map[string]*Texture),
+		frameBuffers: make(map[*Pass]*FrameBuffer),
+		dirty:        true,
+	}
+}
+
+// AddPass adds a pass drawing the commands of the provided function with
+// the provided technique. The technique target is set by the graph.
+func (g *Graph) AddPass(name string, technique *Technique, fn PassFunc) *Pass {
+	pass := &Pass{
+		graph:     g,
+		index:     len(g.passes),
+		name:      name,
+		technique: technique,
+		fn:        fn,
+	}
+	g.passes = append(g.passes, pass)
+	g.dirty = true
+	return pass
+}
+
+// DeclareTexture describes a transient texture. Textures that are not
+// declared are the size of the graph with linear filtering.
+func (g *Graph) DeclareTexture(name string, descriptor *TransientTexture) {
+	g.descriptors[name] = descriptor
+	g.dirty = true
+}
+
+// Export keeps the named texture alive after the graph executes, so it can
+// be retrieved with Texture. The passes writing it are never culled.
+func (g *Graph) Export(name string) {
+	g.exports[name] = true
+	g.dirty = true
+}
+
+// Viewport sets the size of the graph. Without a viewport the graph is the
+// size of the default framebuffer.
+func (g *Graph) Viewport(viewport *Viewport) {
+	g.viewport = viewport
+}
+
+// Texture returns the texture allocated for the provided name by the last
+// execution.
+func (g *Graph) Texture(name string) (*Texture, bool) {
+	texture, ok := g.textures[name]
+	return texture, ok
+}
+
+// Passes returns the names of the passes that are executed, in order.
+func (g *Graph) Passes() ([]string, error) {
+	if g.dirty {
+		order, err := g.sort()
+		if err != nil {
+			return nil, err
+		}
+		return passNames(order), nil
+	}
+	return passNames(g.order), nil
+}
+
+// Execute draws the graph with the default context.
+func (g *Graph) Execute() error {
+	return defaultContext.Execute(g)
+}
+
+// Destroy deallocates the textures and framebuffers of the graph.
+func (g *Graph) Destroy() {
+	for _, frameBuffer := range g.frameBuffers {
+		frameBuffer.Destroy()
+	}
+	for _, texture := range g.pool {
+		texture.texture.Destroy()
+	}
+	g.frameBuffers = make(map[*Pass]*FrameBuffer)
+	g.textures = make(map[string]*Texture)
+	g.pool = nil
+	g.dirty = true
+}
+
+func (g *Graph) execute(c *Context) error {
+	width, height := g.size(c)
+	if width == 0 || height == 0 {
+		return fmt.Errorf("graph size is unknown, set a viewport")
+	}
+	if g.dirty {
+		err := g.compile(c, width, height)
+		if err != nil {
+			return err
+		}
+	} else if width != g.width || height != g.height {
+		err := g.resize(width, height)
+		if err != nil {
+			return err
+		}
+	}
+	for _, pass := range g.order {
+		inputs := make(map[string]*Texture, len(pass.inputs))
+		for _, name := range pass.inputs {
+			inputs[name] = g.textures[name]
+		}
+		pass.technique.FrameBuffer(g.frameBuffers[pass])
+		err := c.Draw(pass.technique, pass.fn(inputs))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Graph) size(c *Context) (int, int) {
+	if g.viewport != nil {
+		return int(g.viewport.Width), int(g.viewport.Height)
+	}
+	viewport := c.targetViewport(nil)
+	if viewport == nil {
+		return 0, 0
+	}
+	return int(viewport.Width), int(viewport.Height)
+}
+
+func (g *Graph) writers() map[string][]*Pass {
+	writers := make(map[string][]*Pass)
+	for _, pass := range g.passes {
+		for _, name := range pass.outputs {
+			writers[name] = append(writers[name], pass)
+		}
+	}
+	return writers
+}
+
+// sort returns the live passes in execution order.
+func (g *Graph) sort() ([]*Pass, error) {
+	writers := g.writers()
+	// validate declarations
+	for _, pass := range g.passes {
+		for _, name := range pass.inputs {
+			if name == BackBuffer {
+				return nil, fmt.Errorf("pass `%s` reads the back buffer", pass.name)
+			}
+			if len(writers[name]) == 0 {
+				return nil, fmt.Errorf("pass `%s` reads texture `%s` that no pass writes", pass.name, name)
+			}
+			if containsString(pass.outputs, name) {
+				return nil, fmt.Errorf("pass `%s` reads texture `%s` it writes", pass.name, name)
+			}
+		}
+		if containsString(pass.outputs, BackBuffer) && len(pass.outputs) > 1 {
+			return nil, fmt.Errorf("pass `%s` writes the back buffer and textures", pass.name)
+		}
+	}
+	// cull passes that do not contribute to the back buffer or exports
+	live := make(map[*Pass]bool)
+	var resources []string
+	resources = append(resources, BackBuffer)
+	for name := range g.exports {
+		resources = append(resources, name)
+	}
+	visited := make(map[string]bool)
+	for len(resources) > 0 {
+		name := resources[len(resources)-1]
+		resources = resources[:len(resources)-1]
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+		// every writer contributes, later writers draw over earlier ones
+		for _, pass := range writers[name] {
+			live[pass] = true
+			resources = append(resources, pass.inputs...)
+		}
+	}
+	// order live passes after the passes they depend on, otherwise in the
+	// order they were added
+	dependencies := make(map[*Pass]map[*Pass]bool)
+	for _, pass := range g.passes {
+		if !live[pass] {
+			continue
+		}
+		dependencies[pass] = make(map[*Pass]bool)
+		for _, name := range pass.inputs {
+			for _, writer := range writers[name] {
+				dependencies[pass][writer] = true
+			}
+		}
+		for _, name := range pass.outputs {
+			for _, writer := range writers[name] {
+				if writer.index < pass.index {
+					dependencies[pass][writer] = true
+				}
+			}
+		}
+	}
+	var order []*Pass
+	done := make(map[*Pass]bool)
+	for len(order) < len(dependencies) {
+		var next *Pass
+		for _, pass := range g.passes {
+			if !live[pass] || done[pass] {
+				continue
+			}
+			ready := true
+			for dependency := range dependencies[pass] {
+				if !done[dependency] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = pass
+				break
+			}
+		}
+		if next == nil {
+			var cycle []string
+			for _, pass := range g.passes {
+				if live[pass] && !done[pass] {
+					cycle = append(cycle, pass.name)
+				}
+			}
+			return nil, fmt.Errorf("passes `%s` cannot be ordered, the textures they read and write form a cycle",
+				strings.Join(cycle, "`, `"))
+		}
+		done[next] = true
+		order = append(order, next)
+	}
+	return order, nil
+}
+
+// compile orders the passes and allocates their textures and framebuffers.
+func (g *Graph) compile(c *Context, width int, height int) error {
+	order, err := g.sort()
+	if err != nil {
+		return err
+	}
+	// lifetimes of the textures in execution order
+	first := make(map[string]int)
+	last := make(map[string]int)
+	for i, pass := range order {
+		for _, name := range pass.outputs {
+			if name == BackBuffer {
+				continue
+			}
+			if _, ok := first[name]; !ok {
+				first[name] = i
+			}
+			last[name] = i
+		}
+		for _, name := range pass.inputs {
+			last[name] = i
+		}
+	}
+	// allocate textures, reusing those whose last pass has executed
+	for _, texture := range g.pool {
+		texture.used = false
+	}
+	g.textures = make(map[string]*Texture)
+	var allocated []*graphTexture
+	assigned := make(map[string]*graphTexture)
+	for i, pass := range order {
+		for _, name := range pass.outputs {
+			if name == BackBuffer || first[name] != i || assigned[name] != nil {
+				continue
+			}
+			texture, err := g.allocate(g.descriptor(name), width, height)
+			if err != nil {
+				return err
+			}
+			if !containsTexture(allocated, texture) {
+				allocated = append(allocated, texture)
+			}
+			assigned[name] = texture
+			g.textures[name] = texture.texture
+		}
+		for name, texture := range assigned {
+			if last[name] == i && !g.exports[name] {
+				texture.used = false
+			}
+		}
+	}
+	// deallocate textures no longer needed
+	for _, texture := range g.pool {
+		if !containsTexture(allocated, texture) {
+			texture.texture.Destroy()
+		}
+	}
+	g.pool = allocated
+	// create framebuffers
+	for _, frameBuffer := range g.frameBuffers {
+		if c.frameBuffer == frameBuffer {
+			// deleting the bound framebuffer binds the default one
+			c.frameBuffer = nil
+			c.frameBufferID = 0
+		}
+		frameBuffer.Destroy()
+	}
+	g.frameBuffers = make(map[*Pass]*FrameBuffer)
+	for _, pass := range order {
+		if len(pass.outputs) == 0 || pass.outputs[0] == BackBuffer {
+			continue
+		}
+		frameBuffer, err := g.createFrameBuffer(pass, assigned)
+		if err != nil {
+			return err
+		}
+		g.frameBuffers[pass] = frameBuffer
+	}
+	if len(g.frameBuffers) > 0 {
+		// attaching textures leaves the default framebuffer bound
+		c.frameBuffer = nil
+		c.frameBufferID = 0
+	}
+	g.order = order
+	g.width = width
+	g.height = height
+	g.dirty = false
+	return nil
+}
+
+func (g *Graph) descriptor(name string) *TransientTexture {
+	descriptor, ok := g.descriptors[name]
+	if !ok {
+		return &TransientTexture{}
+	}
+	return descriptor
+}
+
+// allocate returns an unused texture of the pool compatible with the
+// descriptor, resized to the graph if it was allocated at another size, or a
+// new one.
+func (g *Graph) allocate(descriptor *TransientTexture, width int, height int) (*graphTexture, error) {
+	scaledWidth := scaledSize(width, descriptor.scale())
+	scaledHeight := scaledSize(height, descriptor.scale())
+	for _, texture := range g.pool {
+		if texture.used || !texture.descriptor.compatible(descriptor) {
+			continue
+		}
+		if texture.texture.Width() != scaledWidth || texture.texture.Height() != scaledHeight {
+			err := texture.texture.Resize(scaledWidth, scaledHeight)
+			if err != nil {
+				return nil, err
+			}
+		}
+		texture.used = true
+		return texture, nil
+	}
+	params := descriptor.params()
+	var texture *Texture
+	var err error
+	if descriptor.Float {
+		texture, err = NewFloatTexture(scaledWidth, scaledHeight, &params)
+	} else {
+		texture, err = NewRGBATexture(nil, scaledWidth, scaledHeight, &params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	allocation := &graphTexture{
+		texture:    texture,
+		descriptor: descriptor,
+		used:       true,
+	}
+	g.pool = append(g.pool, allocation)
+	return allocation, nil
+}
+
+func (g *Graph) createFrameBuffer(pass *Pass, assigned map[string]*graphTexture) (*FrameBuffer, error) {
+	frameBuffer := NewFrameBuffer()
+	buffers := make([]uint32, len(pass.outputs))
+	for i, name := range pass.outputs {
+		texture := assigned[name]
+		first := assigned[pass.outputs[0]]
+		if texture.descriptor.scale() != first.descriptor.scale() {
+			frameBuffer.Destroy()
+			return nil, fmt.Errorf("pass `%s` writes textures `%s` and `%s` of different sizes",
+				pass.name, pass.outputs[0], name)
+		}
+		buffers[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+		err := frameBuffer.AttachTexture(buffers[i], texture.texture)
+		if err != nil {
+			frameBuffer.Destroy()
+			return nil, err
+		}
+	}
+	if len(buffers) > 1 {
+		frameBuffer.Bind()
+		frameBuffer.SetDrawBuffers(buffers)
+		frameBuffer.Unbind()
+	}
+	return frameBuffer, nil
+}
+
+// resize resizes the textures of the graph.
+func (g *Graph) resize(width int, height int) error {
+	for _, texture := range g.pool {
+		scale := texture.descriptor.scale()
+		err := texture.texture.Resize(
+			scaledSize(width, scale),
+			scaledSize(height, scale))
+		if err != nil {
+			return err
+		}
+	}
+	g.width = width
+	g.height = height
+	return nil
+}
+
+func scaledSize(size int, scale float32) uint32 {
+	scaled := uint32(math.Ceil(float64(float32(size) * scale)))
+	if scaled == 0 {
+		return 1
+	}
+	return scaled
+}
+
+func passNames(passes []*Pass) []string {
+	names := make([]string, len(passes))
+	for i, pass := range passes {
+		names[i] = pass.name
+	}
+	return names
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTexture(textures []*graphTexture, texture *graphTexture) bool {
+	for _, t := range textures {
+		if t == texture {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package render_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// graphPass represents a pass added to a graph by a test.
+type graphPass struct {
+	name    string
+	inputs  []string
+	outputs []string
+}
+
+func newGraph(passes []graphPass, exports []string, technique *render.Technique, executed *[]string) *render.Graph {
+	graph := render.NewGraph()
+	for _, p := range passes {
+		name := p.name
+		graph.AddPass(name, technique, func(inputs map[string]*render.Texture) []*render.Command {
+			if executed != nil {
+				*executed = append(*executed, name)
+			}
+			return nil
+		}).Read(p.inputs...).Write(p.outputs...)
+	}
+	for _, name := range exports {
+		graph.Export(name)
+	}
+	return graph
+}
+
+func newGraphTechnique(t *testing.T) *render.Technique {
+	shader, err := render.NewVertFragShader(
+		"../resources/shaders/flat.vert",
+		"../resources/shaders/flat.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	technique := render.NewTechnique()
+	technique.Shader(shader)
+	return technique
+}
+
+func TestGraphPasses(t *testing.T) {
+	tests := []struct {
+		name     string
+		passes   []graphPass
+		exports  []string
+		expected []string
+		err      string
+	}{
+		{
+			name: "dependencies first",
+			passes: []graphPass{
+				{"composite", []string{"scene", "blur"}, []string{render.BackBuffer}},
+				{"blur", []string{"scene"}, []string{"blur"}},
+				{"scene", nil, []string{"scene"}},
+			},
+			expected: []string{"scene", "blur", "composite"},
+		},
+		{
+			name: "independent passes in the order added",
+			passes: []graphPass{
+				{"b", nil, []string{render.BackBuffer}},
+				{"a", nil, []string{render.BackBuffer}},
+				{"c", nil, []string{"c"}},
+				{"d", []string{"c"}, []string{render.BackBuffer}},
+			},
+			expected: []string{"b", "a", "c", "d"},
+		},
+		{
+			name: "later writers draw over earlier ones",
+			passes: []graphPass{
+				{"overlay", nil, []string{"scene"}},
+				{"composite", []string{"scene"}, []string{render.BackBuffer}},
+				{"scene", nil, []string{"scene"}},
+			},
+			expected: []string{"overlay", "scene", "composite"},
+		},
+		{
+			name: "unused outputs culled",
+			passes: []graphPass{
+				{"scene", nil, []string{"scene"}},
+				{"debug", []string{"scene"}, []string{"debug"}},
+				{"unused", nil, []string{"unused"}},
+				{"composite", []string{"scene"}, []string{render.BackBuffer}},
+			},
+			expected: []string{"scene", "composite"},
+		},
+		{
+			name: "exports kept",
+			passes: []graphPass{
+				{"scene", nil, []string{"scene"}},
+				{"debug", []string{"scene"}, []string{"debug"}},
+				{"composite", []string{"scene"}, []string{render.BackBuffer}},
+			},
+			exports:  []string{"debug"},
+			expected: []string{"scene", "debug", "composite"},
+		},
+		{
+			name: "cycle",
+			passes: []graphPass{
+				{"a", []string{"y"}, []string{"x"}},
+				{"b", []string{"x"}, []string{"y"}},
+				{"c", []string{"x"}, []string{render.BackBuffer}},
+			},
+			err: "passes `a`, `b`, `c` cannot be ordered",
+		},
+		{
+			name: "reads the back buffer",
+			passes: []graphPass{
+				{"a", []string{render.BackBuffer}, []string{"a"}},
+			},
+			err: "pass `a` reads the back buffer",
+		},
+		{
+			name: "reads an unwritten texture",
+			passes: []graphPass{
+				{"a", []string{"missing"}, []string{render.BackBuffer}},
+			},
+			err: "pass `a` reads texture `missing` that no pass writes",
+		},
+		{
+			name: "reads its own output",
+			passes: []graphPass{
+				{"a", []string{"a"}, []string{"a"}},
+			},
+			err: "pass `a` reads texture `a` it writes",
+		},
+	}
+	for _, test := range tests {
+		graph := newGraph(test.passes, test.exports, nil, nil)
+		passes, err := graph.Passes()
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected error containing %q, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if strings.Join(passes, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: expected passes %q, got %q", test.name, test.expected, passes)
+		}
+	}
+}
+
+func TestGraphExecute(t *testing.T) {
+	newRecordingBackend(t)
+	technique := newGraphTechnique(t)
+	var executed []string
+	graph := newGraph([]graphPass{
+		{"composite", []string{"scene", "blurred"}, []string{render.BackBuffer}},
+		{"bright", []string{"scene"}, []string{"bright"}},
+		{"blur", []string{"bright"}, []string{"blur"}},
+		{"blurred", []string{"blur"}, []string{"blurred"}},
+		{"scene", nil, []string{"scene"}},
+		{"unused", []string{"scene"}, []string{"unused"}},
+	}, nil, technique, &executed)
+	defer graph.Destroy()
+	graph.Viewport(&render.Viewport{
+		Width:  testWidth,
+		Height: testHeight,
+	})
+	err := graph.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, "execution order", executed, []string{"scene", "bright", "blur", "blurred", "composite"})
+
+	// textures whose lifetimes do not overlap share a texture
+	ids := make(map[string]uint32)
+	for _, name := range []string{"scene", "bright", "blur", "blurred"} {
+		texture, ok := graph.Texture(name)
+		if !ok {
+			t.Fatalf("expected texture `%s` to be allocated", name)
+		}
+		ids[name] = texture.ID()
+	}
+	if _, ok := graph.Texture("unused"); ok {
+		t.Errorf("expected the output of a culled pass not to be allocated")
+	}
+	if ids["blurred"] != ids["bright"] {
+		t.Errorf("expected `blurred` to alias `bright`, got textures %d and %d", ids["blurred"], ids["bright"])
+	}
+	for _, pair := range [][2]string{{"scene", "bright"}, {"scene", "blur"}, {"bright", "blur"}, {"scene", "blurred"}} {
+		if ids[pair[0]] == ids[pair[1]] {
+			t.Errorf("expected `%s` and `%s` not to alias, both are texture %d", pair[0], pair[1], ids[pair[0]])
+		}
+	}
+}
+
+func TestGraphResizesReusedTextures(t *testing.T) {
+	newRecordingBackend(t)
+	technique := newGraphTechnique(t)
+	graph := newGraph([]graphPass{
+		{"scene", nil, []string{"scene"}},
+		{"half", []string{"scene"}, []string{"half"}},
+		{"composite", []string{"scene", "half"}, []string{render.BackBuffer}},
+	}, nil, technique, nil)
+	defer graph.Destroy()
+	graph.DeclareTexture("half", &render.TransientTexture{
+		Scale: 0.5,
+	})
+	graph.Viewport(&render.Viewport{
+		Width:  testWidth,
+		Height: testHeight,
+	})
+	err := graph.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// recompile the graph at another size, reusing the pooled textures
+	graph.DeclareTexture("scene", &render.TransientTexture{})
+	graph.Viewport(&render.Viewport{
+		Width:  testWidth / 2,
+		Height: testHeight / 2,
+	})
+	err = graph.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := map[string]uint32{
+		"scene": testWidth / 2,
+		"half":  testWidth / 4,
+	}
+	for name, size := range sizes {
+		texture, _ := graph.Texture(name)
+		if texture.Width() != size || texture.Height() != size {
+			t.Errorf("expected `%s` to be %dx%d, got %dx%d", name, size, size, texture.Width(), texture.Height())
+		}
+	}
+}
+
+func TestGraphFloatTextures(t *testing.T) {
+	b := newRecordingBackend(t)
+	technique := newGraphTechnique(t)
+	graph := newGraph([]graphPass{
+		{"scene", nil, []string{"scene"}},
+		{"bright", []string{"scene"}, []string{"bright"}},
+		{"mask", []string{"bright"}, []string{"mask"}},
+		{"composite", []string{"mask"}, []string{render.BackBuffer}},
+	}, nil, technique, nil)
+	defer graph.Destroy()
+	for _, name := range []string{"scene", "bright"} {
+		graph.DeclareTexture(name, &render.TransientTexture{
+			Float: true,
+		})
+	}
+	graph.Viewport(&render.Viewport{
+		Width:  testWidth,
+		Height: testHeight,
+	})
+	b.reset()
+	err := graph.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the mask is allocated after the scene is released, but is not a float
+	// texture so it does not alias it
+	expectCalls(t, "allocations", filterCalls(b.calls, "TexImage2D"), []string{
+		fmt.Sprintf("TexImage2D %d %d %d %d", gl.RGBA16F, testWidth, testHeight, gl.HALF_FLOAT),
+		fmt.Sprintf("TexImage2D %d %d %d %d", gl.RGBA16F, testWidth, testHeight, gl.HALF_FLOAT),
+		fmt.Sprintf("TexImage2D %d %d %d %d", gl.RGBA, testWidth, testHeight, gl.UNSIGNED_BYTE),
+	})
+}
@@ -10,56 +10,62 @@ type IndexBuffer struct {
 }
 
 // BufferUint8 allocates uint8 buffer data.
-func (i *IndexBuffer) BufferUint8(data []uint8) {
+func (i *IndexBuffer) BufferUint8(data []uint8) error {
 	if i.id == 0 {
-		gl.GenBuffers(1, &i.id)
+		i.id = backend.CreateBuffer()
 	}
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, i.id)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(data), gl.Ptr(data), gl.STATIC_DRAW)
+	backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, i.id)
+	backend.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(data), gl.Ptr(data), gl.STATIC_DRAW)
+	stats.BytesUploaded += len(data)
+	return checkError(i.id)
 }
 
 // BufferUint16 allocates uint16 buffer data.
-func (i *IndexBuffer) BufferUint16(data []uint16) {
+func (i *IndexBuffer) BufferUint16(data []uint16) error {
 	if i.id == 0 {
-		gl.GenBuffers(1, &i.id)
+		i.id = backend.CreateBuffer()
 	}
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, i.id)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(data)*2, gl.Ptr(data), gl.STATIC_DRAW)
+	backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, i.id)
+	backend.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(data)*2, gl.Ptr(data), gl.STATIC_DRAW)
+	stats.BytesUploaded += len(data) * 2
+	return checkError(i.id)
 }
 
 // BufferUint32 allocates uint32 buffer data.
-func (i *IndexBuffer) BufferUint32(data []uint32) {
+func (i *IndexBuffer) BufferUint32(data []uint32) error {
 	if i.id == 0 {
-		gl.GenBuffers(1, &i.id)
+		i.id = backend.CreateBuffer()
 	}
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, i.id)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(data)*4, gl.Ptr(data), gl.STATIC_DRAW)
+	backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, i.id)
+	backend.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(data)*4, gl.Ptr(data), gl.STATIC_DRAW)
+	stats.BytesUploaded += len(data) * 4
+	return checkError(i.id)
 }
 
 // Bind binds the indexbuffer.
 func (i *IndexBuffer) Bind() {
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, i.id)
+	backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, i.id)
 }
 
 // Unbind unbinds the indexbuffer.
 func (i *IndexBuffer) Unbind() {
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+	backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
 }
 
 // Draw renders the indexbuffer.
 func (i *IndexBuffer) Draw(mode uint32, count int32, typ uint32, byteOffset int) {
-	gl.DrawElements(mode, count, typ, gl.PtrOffset(byteOffset))
+	backend.DrawElements(mode, count, typ, byteOffset)
 }
 
 // DrawInstanced renders multiple instances of the indexbuffer.
 func (i *IndexBuffer) DrawInstanced(mode uint32, count int32, typ uint32, byteOffset int, primcount int32) {
-	gl.DrawElementsInstanced(mode, count, typ, gl.PtrOffset(byteOffset), primcount)
+	backend.DrawElementsInstanced(mode, count, typ, byteOffset, primcount)
 }
 
 // Destroy deallocates the indexbuffer.
 func (i *IndexBuffer) Destroy() {
 	if i.id != 0 {
-		gl.DeleteBuffers(1, &i.id)
+		backend.DeleteBuffer(i.id)
 		i.id = 0
 	}
 }
@@ -0,0 +1,272 @@
+package render
+
+import (
+	"sort"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// TechniqueMarker describes the state a technique is drawn with. It is
+// issued through Backend.BeginTechnique before any call of the draw, object
+// names are those of the backend the marker is issued to.
+type TechniqueMarker struct {
+	Program        uint32
+	Framebuffer    uint32
+	Viewport       [4]int32
+	Enables        []uint32
+	BlendFunc      [4]uint32
+	BlendEquation  [2]uint32
+	BlendColor     [4]float32
+	ColorMask      [4]bool
+	CullFace       uint32
+	DepthMask      bool
+	DepthFunc      uint32
+	StencilFunc    [3]uint32
+	StencilOp      [3]uint32
+	StencilMask    uint32
+	Scissor        [4]int32
+	PolygonOffset  [2]float32
+	LineWidth      float32
+	PolygonMode    uint32
+	ClearMask      uint32
+	ClearColor     [4]float32
+	ClearDepth     float32
+	ClearStencil   int32
+	UniformBuffers []MarkerBuffer
+}
+
+// CommandMarker describes the uniforms and bindings of a command. It is
+// issued through Backend.BeginCommand before any call of the command.
+type CommandMarker struct {
+	// Uniforms lists the uniforms set by the command, sorted by name.
+	Uniforms []MarkerUniform
+	// Textures lists the textures bound by the command, including those
+	// bound for sampler uniforms, sorted by unit.
+	Textures []MarkerTexture
+	// UniformBuffers lists the uniform buffers bound by the command.
+	UniformBuffers []MarkerBuffer
+	// VertexArray is the vertex array of the renderable drawn.
+	VertexArray uint32
+}
+
+// MarkerUniform represents a uniform value as its raw 32-bit components.
+type MarkerUniform struct {
+	Name string
+	// Type is the GL type of each element, or zero if the value is not a
+	// supported uniform value.
+	Type  uint32
+	Count int32
+	Words []uint32
+}
+
+// MarkerTexture represents a texture bound to a texture unit.
+type MarkerTexture struct {
+	Unit    uint32
+	Texture uint32
+}
+
+// MarkerBuffer represents a uniform buffer bound to a binding point.
+type MarkerBuffer struct {
+	Binding uint32
+	Buffer  uint32
+}
+
+func markerBuffers(buffers []*UniformBuffer) []MarkerBuffer {
+	var marked []MarkerBuffer
+	for _, buffer := range buffers {
+		marked = append(marked, MarkerBuffer{
+			Binding: buffer.binding,
+			Buffer:  buffer.id,
+		})
+	}
+	return marked
+}
+
+// marker returns the state the technique is drawn with in the provided
+// context. States the technique does not set keep their current value.
+func (t *Technique) marker(c *Context) *TechniqueMarker {
+	m := &TechniqueMarker{
+		Enables:        append([]uint32(nil), t.enables...),
+		ClearMask:      t.clearMask,
+		UniformBuffers: markerBuffers(t.buffers),
+	}
+	if t.shader != nil {
+		m.Program = t.shader.id
+	}
+	if t.framebuffer != nil {
+		m.Framebuffer = t.framebuffer.id
+	}
+	viewport := t.viewport
+	if viewport == nil {
+		viewport = c.targetViewport(t.framebuffer)
+	}
+	if viewport != nil {
+		m.Viewport = [4]int32{viewport.X, viewport.Y, viewport.Width, viewport.Height}
+	}
+	if b := t.blendFunc; b != nil || c.blendFunc != nil {
+		if b == nil {
+			b = c.blendFunc
+		}
+		m.BlendFunc = [4]uint32{b.srcRGB, b.dstRGB, b.srcAlpha, b.dstAlpha}
+	}
+	if b := t.blendEquation; b != nil || c.blendEquation != nil {
+		if b == nil {
+			b = c.blendEquation
+		}
+		m.BlendEquation = [2]uint32{b.modeRGB, b.modeAlpha}
+	}
+	if b := t.blendColor; b != nil || c.blendColor != nil {
+		if b == nil {
+			b = c.blendColor
+		}
+		m.BlendColor = [4]float32{b.r, b.g, b.b, b.a}
+	}
+	if mask := t.colorMask; mask != nil || c.colorMask != nil {
+		if mask == nil {
+			mask = c.colorMask
+		}
+		m.ColorMask = [4]bool{mask.r, mask.g, mask.b, mask.a}
+	}
+	if cull := t.cullFace; cull != nil || c.cullFace != nil {
+		if cull == nil {
+			cull = c.cullFace
+		}
+		m.CullFace = cull.mode
+	}
+	if mask := t.depthMask; mask != nil || c.depthMask != nil {
+		if mask == nil {
+			mask = c.depthMask
+		}
+		m.DepthMask = mask.flag
+	}
+	if depth := t.depthFunc; depth != nil || c.depthFunc != nil {
+		if depth == nil {
+			depth = c.depthFunc
+		}
+		m.DepthFunc = depth.xfunc
+	}
+	if stencil := t.stencilFunc; stencil != nil || c.stencilFunc != nil {
+		if stencil == nil {
+			stencil = c.stencilFunc
+		}
+		m.StencilFunc = [3]uint32{stencil.xfunc, uint32(stencil.ref), stencil.mask}
+	}
+	if stencil := t.stencilOp; stencil != nil || c.stencilOp != nil {
+		if stencil == nil {
+			stencil = c.stencilOp
+		}
+		m.StencilOp = [3]uint32{stencil.sfail, stencil.dpfail, stencil.dppass}
+	}
+	if mask := t.stencilMask; mask != nil || c.stencilMask != nil {
+		if mask == nil {
+			mask = c.stencilMask
+		}
+		m.StencilMask = mask.mask
+	}
+	if s := t.scissor; s != nil || c.scissor != nil {
+		if s == nil {
+			s = c.scissor
+		}
+		m.Scissor = [4]int32{s.x, s.y, s.width, s.height}
+	}
+	if offset := t.polygonOffset; offset != nil || c.polygonOffset != nil {
+		if offset == nil {
+			offset = c.polygonOffset
+		}
+		m.PolygonOffset = [2]float32{offset.factor, offset.units}
+	}
+	if width := t.lineWidth; width != nil || c.lineWidth != nil {
+		if width == nil {
+			width = c.lineWidth
+		}
+		m.LineWidth = width.width
+	}
+	if mode := t.polygonMode; mode != nil || c.polygonMode != nil {
+		if mode == nil {
+			mode = c.polygonMode
+		}
+		m.PolygonMode = mode.mode
+	}
+	if t.clearColor != nil {
+		m.ClearColor = [4]float32{t.clearColor.r, t.clearColor.g, t.clearColor.b, t.clearColor.a}
+	}
+	if t.clearDepth != nil {
+		m.ClearDepth = t.clearDepth.depth
+	}
+	if t.clearStencil != nil {
+		m.ClearStencil = t.clearStencil.s
+	}
+	return m
+}
+
+// marker returns the uniforms and bindings of the command when executed
+// with the provided shader. Texture uniforms are reported as the units they
+// are bound to.
+func (c *Command) marker(shader *Shader) *CommandMarker {
+	m := &CommandMarker{
+		UniformBuffers: markerBuffers(c.buffers),
+	}
+	for location, texture := range c.textures {
+		m.Textures = append(m.Textures, MarkerTexture{
+			Unit:    location,
+			Texture: texture.id,
+		})
+	}
+	unit := c.firstFreeUnit()
+	names := make([]string, 0, len(c.uniforms))
+	for name := range c.uniforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := c.uniforms[name]
+		texture, ok := value.(*Texture)
+		if ok {
+			m.Textures = append(m.Textures, MarkerTexture{
+				Unit:    gl.TEXTURE0 + unit,
+				Texture: texture.id,
+			})
+			value = int32(unit)
+			unit++
+		}
+		m.Uniforms = append(m.Uniforms, markerUniform(shader, name, value))
+	}
+	sort.Sort(texturesByUnit(m.Textures))
+	if c.renderable != nil {
+		m.VertexArray = c.renderable.id
+	}
+	return m
+}
+
+// markerUniform flattens a uniform value, raw pointers are sized by the
+// active uniform of the shader they are buffered to.
+func markerUniform(shader *Shader, name string, arg interface{}) MarkerUniform {
+	uniform := MarkerUniform{
+		Name: name,
+	}
+	value, err := newUniformValue(arg)
+	if err != nil {
+		return uniform
+	}
+	uniform.Type = value.typ
+	uniform.Count = value.count
+	if uniform.Type == 0 {
+		descriptor, ok := shader.descriptors[name]
+		if !ok {
+			descriptor, ok = shader.descriptors[name+"[0]"]
+		}
+		if !ok {
+			return uniform
+		}
+		uniform.Type = descriptor.Type
+		uniform.Count = descriptor.Count
+	}
+	uniform.Words = value.components(uniform.Type, uniform.Count)
+	return uniform
+}
+
+type texturesByUnit []MarkerTexture
+
+func (t texturesByUnit) Len() int           { return len(t) }
+func (t texturesByUnit) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t texturesByUnit) Less(i, j int) bool { return t[i].Unit < t[j].Unit }
@@ -0,0 +1,190 @@
+//go:build linux
+// +build linux
+
+package offscreen
+
+/*
+#cgo LDFLAGS: -lEGL
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+#ifndef EGL_PLATFORM_SURFACELESS_MESA
+#define EGL_PLATFORM_SURFACELESS_MESA 0x31DD
+#endif
+
+static EGLDisplay getDisplay() {
+	// prefer the mesa surfaceless platform, it requires no display server
+	PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+		(PFNEGLGETPLATFORMDISPLAYEXTPROC) eglGetProcAddress("eglGetPlatformDisplayEXT");
+	if (getPlatformDisplay != NULL) {
+		EGLDisplay display = getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+		if (display != EGL_NO_DISPLAY) {
+			return display;
+		}
+	}
+	return eglGetDisplay(EGL_DEFAULT_DISPLAY);
+}
+
+static EGLConfig chooseConfig(EGLDisplay display) {
+	const EGLint attribs[] = {
+		EGL_SURFACE_TYPE, EGL_PBUFFER_BIT,
+		EGL_RENDERABLE_TYPE, EGL_OPENGL_BIT,
+		EGL_RED_SIZE, 8,
+		EGL_GREEN_SIZE, 8,
+		EGL_BLUE_SIZE, 8,
+		EGL_ALPHA_SIZE, 8,
+		EGL_DEPTH_SIZE, 24,
+		EGL_STENCIL_SIZE, 8,
+		EGL_NONE
+	};
+	EGLConfig config = NULL;
+	EGLint numConfigs = 0;
+	if (!eglChooseConfig(display, attribs, &config, 1, &numConfigs) || numConfigs == 0) {
+		return NULL;
+	}
+	return config;
+}
+
+static EGLSurface createSurface(EGLDisplay display, EGLConfig config, EGLint width, EGLint height) {
+	const EGLint attribs[] = {
+		EGL_WIDTH, width,
+		EGL_HEIGHT, height,
+		EGL_NONE
+	};
+	return eglCreatePbufferSurface(display, config, attribs);
+}
+
+static EGLContext createContext(EGLDisplay display, EGLConfig config, int debug) {
+	EGLint attribs[] = {
+		EGL_CONTEXT_MAJOR_VERSION, 4,
+		EGL_CONTEXT_MINOR_VERSION, 1,
+		EGL_CONTEXT_OPENGL_PROFILE_MASK, EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+		EGL_NONE, EGL_NONE,
+		EGL_NONE
+	};
+	if (debug) {
+		// EGL_CONTEXT_OPENGL_DEBUG
+		attribs[6] = 0x31B0;
+		attribs[7] = EGL_TRUE;
+	}
+	if (!eglBindAPI(EGL_OPENGL_API)) {
+		return EGL_NO_CONTEXT;
+	}
+	return eglCreateContext(display, config, EGL_NO_CONTEXT, attribs);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// Context represents a headless OpenGL 4.1 core context backed by an EGL
+// pbuffer surface. It requires no window system and runs on Mesa's llvmpipe
+// when no GPU is present.
+type Context struct {
+	display C.EGLDisplay
+	surface C.EGLSurface
+	context C.EGLContext
+	width   int
+	height  int
+}
+
+// NewContext creates a new headless context with a default framebuffer of
+// the provided size.
+func NewContext(width int, height int) (*Context, error) {
+	display := C.getDisplay()
+	if display == 0 {
+		return nil, fmt.Errorf("failed to get EGL display")
+	}
+	if C.eglInitialize(display, nil, nil) == C.EGL_FALSE {
+		return nil, fmt.Errorf("failed to initialize EGL display: %s", eglError())
+	}
+	config := C.chooseConfig(display)
+	if config == 0 {
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("failed to choose EGL config: %s", eglError())
+	}
+	surface := C.createSurface(display, config, C.EGLint(width), C.EGLint(height))
+	if surface == nil {
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("failed to create EGL pbuffer surface: %s", eglError())
+	}
+	debug := 0
+	if render.Debug {
+		debug = 1
+	}
+	context := C.createContext(display, config, C.int(debug))
+	if context == nil {
+		C.eglDestroySurface(display, surface)
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("failed to create EGL context: %s", eglError())
+	}
+	return &Context{
+		display: display,
+		surface: surface,
+		context: context,
+		width:   width,
+		height:  height,
+	}, nil
+}
+
+// MakeCurrent makes the context current on the calling thread.
+func (c *Context) MakeCurrent() error {
+	if C.eglMakeCurrent(c.display, c.surface, c.surface, c.context) == C.EGL_FALSE {
+		return fmt.Errorf("failed to make EGL context current: %s", eglError())
+	}
+	return nil
+}
+
+// FramebufferSize returns the size of the default framebuffer in pixels.
+func (c *Context) FramebufferSize() (int, int) {
+	return c.width, c.height
+}
+
+// SwapBuffers flushes all pending render commands. The pbuffer surface is
+// single buffered, so nothing is presented.
+func (c *Context) SwapBuffers() {
+	gl.Finish()
+}
+
+// Image reads back the default framebuffer into an image.RGBA.
+func (c *Context) Image() *image.RGBA {
+	pix := make([]uint8, c.width*c.height*4)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(
+		0,
+		0,
+		int32(c.width),
+		int32(c.height),
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(pix))
+	// flip rows, the framebuffer origin is the bottom-left
+	img := image.NewRGBA(image.Rect(0, 0, c.width, c.height))
+	stride := c.width * 4
+	for y := 0; y < c.height; y++ {
+		src := pix[(c.height-1-y)*stride : (c.height-y)*stride]
+		copy(img.Pix[y*img.Stride:y*img.Stride+stride], src)
+	}
+	return img
+}
+
+// Destroy releases the context and its pbuffer surface.
+func (c *Context) Destroy() {
+	C.eglMakeCurrent(c.display, nil, nil, nil)
+	C.eglDestroyContext(c.display, c.context)
+	C.eglDestroySurface(c.display, c.surface)
+	C.eglTerminate(c.display)
+}
+
+func eglError() string {
+	code := C.eglGetError()
+	return fmt.Sprintf("0x%x", uint32(code))
+}
@@ -0,0 +1,248 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// DefaultPreprocessor is the preprocessor used by shaders created without
+	// one of their own.
+	DefaultPreprocessor = NewPreprocessor()
+
+	includeRegex *regexp.Regexp
+	versionRegex *regexp.Regexp
+	lineRegex    *regexp.Regexp
+)
+
+func init() {
+	includeRegex = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"\s*$`)
+	versionRegex = regexp.MustCompile(`^\s*#\s*version\s`)
+	lineRegex = regexp.MustCompile(`^\s*#\s*line\s+(\d+)(?:\s+\d+)?\s*$`)
+}
+
+// Preprocessor represents a GLSL preprocessor that resolves `#include "file"`
+// directives and injects defines after the `#version` directive. `#line`
+// directives renumber the lines of the line map rather than the lines the
+// compiler reports. Files are read through the asset file system.
+type Preprocessor struct {
+	// SearchPath is the list of directories searched for included files after
+	// the directory of the including file.
+	SearchPath []string
+	// Defines are the preprocessor definitions injected into every source.
+	Defines map[string]string
+}
+
+// NewPreprocessor instantiates and returns a new preprocessor.
+func NewPreprocessor(searchPath ...string) *Preprocessor {
+	return &Preprocessor{
+		SearchPath: searchPath,
+		Defines:    make(map[string]string),
+	}
+}
+
+// WithDefines returns a copy of the preprocessor with the provided defines
+// added to its own.
+func (p *Preprocessor) WithDefines(defines map[string]string) *Preprocessor {
+	copied := NewPreprocessor(p.SearchPath...)
+	for name, value := range p.Defines {
+		copied.Defines[name] = value
+	}
+	for name, value := range defines {
+		copied.Defines[name] = value
+	}
+	return copied
+}
+
+// SourceLine represents the origin of a line of preprocessed source.
+type SourceLine struct {
+	File string
+	Line int
+}
+
+// Source represents preprocessed GLSL source.
+type Source struct {
+	// Text is the preprocessed GLSL.
+	Text string
+	// Files are the files read to produce the source, starting with the root
+	// file if the source was read from one.
+	Files []string
+	// Lines maps each line of the text, starting from zero, to its origin.
+	Lines []SourceLine
+}
+
+// Origin returns the file and line a one-based line of the preprocessed text
+// originated from.
+func (s *Source) Origin(line int) (SourceLine, bool) {
+	if line < 1 || line > len(s.Lines) {
+		return SourceLine{}, false
+	}
+	return s.Lines[line-1], true
+}
+
+// ProcessFile reads and preprocesses the GLSL source file at the provided
+// path.
+func (p *Preprocessor) ProcessFile(path string) (*Source, error) {
+	raw, err := ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.process(string(raw), path)
+}
+
+// Process preprocesses the provided GLSL source. Includes are resolved
+// against the search path only.
+func (p *Preprocessor) Process(source string) (*Source, error) {
+	return p.process(source, "")
+}
+
+func (p *Preprocessor) process(source string, path string) (*Source, error) {
+	res := &Source{}
+	var stack []string
+	if path != "" {
+		res.Files = append(res.Files, path)
+		stack = append(stack, path)
+	}
+	// inject defines after the version directive, or first if there is none
+	lines := splitLines(source)
+	version := -1
+	for i, line := range lines {
+		if versionRegex.MatchString(line) {
+			version = i
+			break
+		}
+	}
+	var buf bytes.Buffer
+	if version == -1 {
+		p.inject(&buf, res)
+	}
+	num := 1
+	for i, line := range lines {
+		next, err := p.processLine(&buf, res, line, path, num, stack)
+		if err != nil {
+			return nil, err
+		}
+		num = next
+		if i == version {
+			p.inject(&buf, res)
+		}
+	}
+	res.Text = buf.String()
+	return res, nil
+}
+
+func (p *Preprocessor) inject(buf *bytes.Buffer, res *Source) {
+	names := make([]string, 0, len(p.Defines))
+	for name := range p.Defines {
+		names = append(names, name)
+	}
+	// sort for a deterministic output
+	sort.Strings(names)
+	for _, name := range names {
+		value := p.Defines[name]
+		if value == "" {
+			fmt.Fprintf(buf, "#define %s\n", name)
+		} else {
+			fmt.Fprintf(buf, "#define %s %s\n", name, value)
+		}
+		res.Lines = append(res.Lines, SourceLine{
+			File: "<defines>",
+			Line: 0,
+		})
+	}
+}
+
+// processLine writes the processed line and returns the number of the line
+// following it in the file.
+func (p *Preprocessor) processLine(buf *bytes.Buffer, res *Source, line string, path string, num int, stack []string) (int, error) {
+	match := lineRegex.FindStringSubmatch(line)
+	if match != nil {
+		// the directive is dropped so the compiler keeps reporting lines of
+		// the preprocessed text, which the line map renumbers instead
+		buf.WriteString("\n")
+		res.Lines = append(res.Lines, SourceLine{
+			File: sourceName(path),
+			Line: num,
+		})
+		next, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, fmt.Errorf("%s:%d: invalid line number `%s`", sourceName(path), num, match[1])
+		}
+		return next, nil
+	}
+	match = includeRegex.FindStringSubmatch(line)
+	if match == nil {
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		res.Lines = append(res.Lines, SourceLine{
+			File: sourceName(path),
+			Line: num,
+		})
+		return num + 1, nil
+	}
+	include, err := p.resolve(match[1], path)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%d: %v", sourceName(path), num, err)
+	}
+	// guard against cycles
+	for _, parent := range stack {
+		if parent == include {
+			cycle := append(append([]string{}, stack...), include)
+			return 0, fmt.Errorf("%s:%d: include cycle `%s`",
+				sourceName(path),
+				num,
+				strings.Join(cycle, "` -> `"))
+		}
+	}
+	raw, err := ReadAsset(include)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%d: %v", sourceName(path), num, err)
+	}
+	res.Files = append(res.Files, include)
+	included := 1
+	for _, line := range splitLines(string(raw)) {
+		included, err = p.processLine(buf, res, line, include, included, append(stack, include))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return num + 1, nil
+}
+
+func (p *Preprocessor) resolve(name string, from string) (string, error) {
+	var dirs []string
+	if from != "" {
+		dirs = append(dirs, path.Dir(from))
+	}
+	dirs = append(dirs, p.SearchPath...)
+	for _, dir := range dirs {
+		include := path.Join(dir, name)
+		file, err := assets.Open(include)
+		if err == nil {
+			file.Close()
+			return include, nil
+		}
+	}
+	return "", fmt.Errorf("could not find include `%s`", name)
+}
+
+func splitLines(source string) []string {
+	source = strings.Replace(source, "\r\n", "\n", -1)
+	source = strings.TrimSuffix(source, "\n")
+	if source == "" {
+		return nil
+	}
+	return strings.Split(source, "\n")
+}
+
+func sourceName(path string) string {
+	if path == "" {
+		return "<source>"
+	}
+	return path
+}
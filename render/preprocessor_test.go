@@ -0,0 +1,154 @@
+package render_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// mapFS returns an in-memory file system of the provided file contents.
+func mapFS(files map[string]string) render.MapFS {
+	fsys := make(render.MapFS)
+	for name, data := range files {
+		fsys[name] = &render.MapFile{
+			Data: []byte(data),
+		}
+	}
+	return fsys
+}
+
+func origins(source *render.Source) []string {
+	lines := make([]string, len(source.Lines))
+	for i, line := range source.Lines {
+		lines[i] = fmt.Sprintf("%s:%d", line.File, line.Line)
+	}
+	return lines
+}
+
+func TestPreprocessor(t *testing.T) {
+	defer render.SetAssets(render.Dir(""))
+	tests := []struct {
+		name       string
+		files      map[string]string
+		searchPath []string
+		defines    map[string]string
+		text       string
+		lines      []string
+		err        string
+	}{
+		{
+			name: "nested includes",
+			files: map[string]string{
+				"main.glsl":      "#version 410\n#include \"a.glsl\"\nvoid main() {}\n",
+				"a.glsl":         "float a;\n#include \"lib/b.glsl\"\nfloat c;\n",
+				"lib/b.glsl":     "float b;\n#include \"inner.glsl\"\n",
+				"lib/inner.glsl": "float inner;\n",
+			},
+			text: "#version 410\nfloat a;\nfloat b;\nfloat inner;\nfloat c;\nvoid main() {}\n",
+			lines: []string{
+				"main.glsl:1",
+				"a.glsl:1",
+				"lib/b.glsl:1",
+				"lib/inner.glsl:1",
+				"a.glsl:3",
+				"main.glsl:3",
+			},
+		},
+		{
+			name: "search path",
+			files: map[string]string{
+				"main.glsl":           "#include \"camera.glsl\"\nvoid main() {}\n",
+				"include/camera.glsl": "uniform mat4 uView;\n",
+			},
+			searchPath: []string{"include"},
+			text:       "uniform mat4 uView;\nvoid main() {}\n",
+			lines: []string{
+				"include/camera.glsl:1",
+				"main.glsl:2",
+			},
+		},
+		{
+			name: "defines after version",
+			files: map[string]string{
+				"main.glsl": "#version 410\n#include \"a.glsl\"\n",
+				"a.glsl":    "#ifdef FADE\n#endif\n",
+			},
+			defines: map[string]string{
+				"FADE":  "",
+				"COUNT": "4",
+			},
+			text: "#version 410\n#define COUNT 4\n#define FADE\n#ifdef FADE\n#endif\n",
+			lines: []string{
+				"main.glsl:1",
+				"<defines>:0",
+				"<defines>:0",
+				"a.glsl:1",
+				"a.glsl:2",
+			},
+		},
+		{
+			name: "line directives",
+			files: map[string]string{
+				"main.glsl": "#version 410\n#line 20\nfloat a;\n#include \"a.glsl\"\nfloat b;\n",
+				"a.glsl":    "float c;\n# line 7 2\nfloat d;\n",
+			},
+			text: "#version 410\n\nfloat a;\nfloat c;\n\nfloat d;\nfloat b;\n",
+			lines: []string{
+				"main.glsl:1",
+				"main.glsl:2",
+				"main.glsl:20",
+				"a.glsl:1",
+				"a.glsl:2",
+				"a.glsl:7",
+				"main.glsl:22",
+			},
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"main.glsl": "#include \"a.glsl\"\n",
+				"a.glsl":    "#include \"b.glsl\"\n",
+				"b.glsl":    "float b;\n#include \"a.glsl\"\n",
+			},
+			err: "b.glsl:2: include cycle `main.glsl` -> `a.glsl` -> `b.glsl` -> `a.glsl`",
+		},
+		{
+			name: "self include",
+			files: map[string]string{
+				"main.glsl": "#include \"main.glsl\"\n",
+			},
+			err: "main.glsl:1: include cycle `main.glsl` -> `main.glsl`",
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"main.glsl": "float a;\n#include \"missing.glsl\"\n",
+			},
+			err: "main.glsl:2: could not find include `missing.glsl`",
+		},
+	}
+	for _, test := range tests {
+		render.SetAssets(mapFS(test.files))
+		preprocessor := render.NewPreprocessor(test.searchPath...).WithDefines(test.defines)
+		source, err := preprocessor.ProcessFile("main.glsl")
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if source.Text != test.text {
+			t.Errorf("%s: expected text %q, got %q", test.name, test.text, source.Text)
+		}
+		lines := origins(source)
+		if strings.Join(lines, ",") != strings.Join(test.lines, ",") {
+			t.Errorf("%s: expected lines %q, got %q", test.name, test.lines, lines)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package render
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/unchartedsoftware/plog"
+)
+
+const (
+	programCacheMagic = "CPRG"
+)
+
+var (
+	programCache *ProgramCache
+)
+
+// ProgramCache represents an on-disk cache of linked program binaries. Entries
+// are keyed by a hash of the preprocessed sources, the defines, and the driver
+// vendor, renderer and version, so editing a shader or updating the driver
+// never loads a stale binary.
+type ProgramCache struct {
+	dir string
+}
+
+// NewProgramCache instantiates and returns a new program cache storing
+// binaries in the provided directory, which is created if it does not exist.
+func NewProgramCache(dir string) (*ProgramCache, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &ProgramCache{
+		dir: dir,
+	}, nil
+}
+
+// SetProgramCache sets the program cache used when building shaders. A nil
+// cache disables caching.
+func SetProgramCache(cache *ProgramCache) {
+	programCache = cache
+}
+
+// Clear removes every entry from the cache.
+func (c *ProgramCache) Clear() error {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.bin"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ProgramCache) key(sources []shaderSource, processed []*Source, defines map[string]string, varyings []string) string {
+	h := sha256.New()
+	for _, name := range []uint32{gl.VENDOR, gl.RENDERER, gl.VERSION} {
+		fmt.Fprintf(h, "%s\x00", backend.GetString(name))
+	}
+	fmt.Fprintf(h, "%s\x00", VariantKey(defines))
+	fmt.Fprintf(h, "%s\x00", strings.Join(varyings, ","))
+	for i, source := range sources {
+		fmt.Fprintf(h, "%d\x00%s\x00", source.typ, processed[i].Text)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *ProgramCache) path(key string) string {
+	return filepath.Join(c.dir, key+".bin")
+}
+
+// load returns the binary format and binary stored for the key. Entries that
+// cannot be read are removed so they are rebuilt.
+func (c *ProgramCache) load(key string) (uint32, []byte, bool) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return 0, nil, false
+	}
+	header := len(programCacheMagic) + 4
+	if len(data) <= header || string(data[:len(programCacheMagic)]) != programCacheMagic {
+		log.Warnf("program cache entry `%s` is invalid, rebuilding", key)
+		c.remove(key)
+		return 0, nil, false
+	}
+	format := binary.LittleEndian.Uint32(data[len(programCacheMagic):header])
+	return format, data[header:], true
+}
+
+// store writes the binary of the linked program to the cache.
+func (c *ProgramCache) store(key string, program uint32) error {
+	format, bin, err := backend.GetProgramBinary(program)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(programCacheMagic)
+	binary.Write(&buf, binary.LittleEndian, format)
+	buf.Write(bin)
+	// write to a temporary file first so a partial entry is never read
+	tmp := c.path(key) + ".tmp"
+	err = ioutil.WriteFile(tmp, buf.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path(key))
+}
+
+func (c *ProgramCache) remove(key string) {
+	err := os.Remove(c.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed to remove program cache entry `%s`: %v", key, err)
+	}
+}
+
+// loadBinary links the program from the cached binary for the key. A binary
+// rejected by the driver is removed from the cache so it is rebuilt.
+func (s *Shader) loadBinary(key string) bool {
+	format, bin, ok := programCache.load(key)
+	if !ok {
+		return false
+	}
+	s.id = backend.CreateProgram()
+	err := backend.ProgramBinary(s.id, format, bin)
+	if err != nil {
+		// discard any error raised by the rejected binary
+		checkError(s.id)
+		log.Warnf("program binary `%s` was rejected, compiling from source: %v", key, err)
+		s.Destroy()
+		programCache.remove(key)
+		return false
+	}
+	s.queryUniforms()
+	return true
+}
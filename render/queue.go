@@ -0,0 +1,141 @@
+package render
+
+import (
+	"sort"
+)
+
+type queueEntry struct {
+	technique *Technique
+	command   *Command
+	layer     int
+	depth     float32
+	index     int
+}
+
+// stateKey orders entries to minimize state changes, by framebuffer, then
+// shader, then technique, in the order they were first added.
+type stateKey struct {
+	frameBuffer int
+	shader      uint32
+	technique   int
+}
+
+func (k stateKey) less(other stateKey) bool {
+	if k.frameBuffer != other.frameBuffer {
+		return k.frameBuffer < other.frameBuffer
+	}
+	if k.shader != other.shader {
+		return k.shader < other.shader
+	}
+	return k.technique < other.technique
+}
+
+// Queue represents the draws of a frame, collected and then sorted before
+// they are issued. Entries are drawn in ascending layer order. Within an
+// opaque layer they are sorted by state to minimize switches and then front
+// to back, and within a transparent layer back to front, with larger depths
+// further back. Consecutive entries of a technique are drawn together.
+type Queue struct {
+	entries     []*queueEntry
+	transparent map[int]bool
+	keys        map[*Technique]stateKey
+}
+
+// NewQueue instantiates and returns a new queue.
+func NewQueue() *Queue {
+	return &Queue{
+		transparent: make(map[int]bool),
+	}
+}
+
+// Transparent sets whether the provided layer is transparent, keeping its
+// entries in back to front order.
+func (q *Queue) Transparent(layer int, transparent bool) {
+	q.transparent[layer] = transparent
+}
+
+// Add adds a command drawn with the provided technique to the queue.
+func (q *Queue) Add(technique *Technique, command *Command, layer int, depth float32) {
+	q.entries = append(q.entries, &queueEntry{
+		technique: technique,
+		command:   command,
+		layer:     layer,
+		depth:     depth,
+		index:     len(q.entries),
+	})
+}
+
+// Len returns the number of entries in the queue.
+func (q *Queue) Len() int {
+	return len(q.entries)
+}
+
+// Flush draws and removes every entry of the queue with the default
+// context.
+func (q *Queue) Flush() error {
+	return defaultContext.Flush(q)
+}
+
+// Reset removes every entry of the queue without drawing it.
+func (q *Queue) Reset() {
+	q.entries = q.entries[:0]
+	q.keys = nil
+}
+
+// sort sorts the entries into draw order. The variants of the techniques
+// must be selected.
+func (q *Queue) sort() {
+	// rank framebuffers and techniques in the order they were added
+	q.keys = make(map[*Technique]stateKey)
+	frameBuffers := make(map[*FrameBuffer]int)
+	for _, entry := range q.entries {
+		technique := entry.technique
+		if _, ok := q.keys[technique]; ok {
+			continue
+		}
+		rank, ok := frameBuffers[technique.framebuffer]
+		if !ok {
+			rank = len(frameBuffers)
+			frameBuffers[technique.framebuffer] = rank
+		}
+		key := stateKey{
+			frameBuffer: rank,
+			technique:   len(q.keys),
+		}
+		if technique.shader != nil {
+			key.shader = technique.shader.id
+		}
+		q.keys[technique] = key
+	}
+	sort.Stable(drawOrder{q})
+}
+
+// drawOrder sorts the entries of a queue into draw order.
+type drawOrder struct {
+	queue *Queue
+}
+
+func (d drawOrder) Len() int {
+	return len(d.queue.entries)
+}
+
+func (d drawOrder) Less(i, j int) bool {
+	q := d.queue
+	a, b := q.entries[i], q.entries[j]
+	if a.layer != b.layer {
+		return a.layer < b.layer
+	}
+	if q.transparent[a.layer] {
+		// back to front, ties keep the order they were added
+		return a.depth > b.depth
+	}
+	keyA, keyB := q.keys[a.technique], q.keys[b.technique]
+	if keyA != keyB {
+		return keyA.less(keyB)
+	}
+	return a.depth < b.depth
+}
+
+func (d drawOrder) Swap(i, j int) {
+	d.queue.entries[i], d.queue.entries[j] = d.queue.entries[j], d.queue.entries[i]
+}
@@ -0,0 +1,110 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func newSortTechnique(program uint32, frameBuffer *FrameBuffer) *Technique {
+	technique := NewTechnique()
+	technique.Shader(&Shader{
+		id: program,
+	})
+	technique.FrameBuffer(frameBuffer)
+	return technique
+}
+
+func TestQueueSort(t *testing.T) {
+	offscreen := &FrameBuffer{}
+	// techniques are ranked by framebuffer, then by program
+	first := newSortTechnique(2, nil)
+	second := newSortTechnique(1, nil)
+	target := newSortTechnique(1, offscreen)
+
+	type entry struct {
+		name      string
+		technique *Technique
+		layer     int
+		depth     float32
+	}
+	tests := []struct {
+		name        string
+		transparent []int
+		entries     []entry
+		expected    []string
+	}{
+		{
+			name: "layers ascending",
+			entries: []entry{
+				{"a", first, 2, 0},
+				{"b", first, 0, 0},
+				{"c", first, 1, 0},
+			},
+			expected: []string{"b", "c", "a"},
+		},
+		{
+			name: "state then front to back",
+			entries: []entry{
+				{"a", first, 0, 5},
+				{"b", second, 0, 3},
+				{"c", first, 0, 1},
+				{"d", second, 0, 9},
+			},
+			expected: []string{"b", "d", "c", "a"},
+		},
+		{
+			name: "framebuffer before program",
+			entries: []entry{
+				{"a", target, 0, 0},
+				{"b", first, 0, 0},
+				{"c", second, 0, 0},
+				{"d", target, 0, 0},
+			},
+			expected: []string{"a", "d", "c", "b"},
+		},
+		{
+			name:        "transparent back to front",
+			transparent: []int{0},
+			entries: []entry{
+				{"a", first, 0, 1},
+				{"b", second, 0, 5},
+				{"c", first, 0, 3},
+				{"d", second, 0, 3},
+			},
+			// ties keep the order they were added, regardless of state
+			expected: []string{"b", "c", "d", "a"},
+		},
+		{
+			name:        "opaque then transparent layer",
+			transparent: []int{1},
+			entries: []entry{
+				{"a", second, 1, 1},
+				{"b", first, 0, 2},
+				{"c", first, 1, 4},
+				{"d", second, 0, 8},
+				{"e", first, 0, 1},
+			},
+			expected: []string{"d", "e", "b", "c", "a"},
+		},
+	}
+	for _, test := range tests {
+		queue := NewQueue()
+		for _, layer := range test.transparent {
+			queue.Transparent(layer, true)
+		}
+		names := make(map[*Command]string)
+		for _, e := range test.entries {
+			command := &Command{}
+			names[command] = e.name
+			queue.Add(e.technique, command, e.layer, e.depth)
+		}
+		queue.sort()
+		order := make([]string, queue.Len())
+		for i, entry := range queue.entries {
+			order[i] = names[entry.command]
+		}
+		if strings.Join(order, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: expected order %q, got %q", test.name, test.expected, order)
+		}
+	}
+}
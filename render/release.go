@@ -0,0 +1,23 @@
+//go:build !debug
+// +build !debug
+
+package render
+
+// Debug is true when the render package is built with the `debug` tag, in
+// which case every backend call is checked for errors.
+const Debug = false
+
+func wrapBackend(b Backend) Backend {
+	return b
+}
+
+func unwrapBackend(b Backend) Backend {
+	return b
+}
+
+func checkError(object uint32) error {
+	return nil
+}
+
+func enableDebugOutput() {
+}
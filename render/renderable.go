@@ -4,13 +4,15 @@ import (
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
-// AttributePointer represents a vertex attribute pointer.
+// AttributePointer represents a vertex attribute pointer. If Buffer is nil
+// the attribute is read from the vertexbuffer of the renderable.
 type AttributePointer struct {
 	Index      uint32
 	Size       int32
 	Type       uint32
 	ByteStride int32
 	ByteOffset int
+	Buffer     *VertexBuffer
 }
 
 // Renderable represents a renderable object.
@@ -20,13 +22,16 @@ type Renderable struct {
 	indexbuffer  *IndexBuffer
 	pointers     map[uint32]*AttributePointer
 	instanced    map[uint32]bool
+	// validated holds the generation of each shader last validated against
+	validated map[*Shader]uint64
 	// draw params
-	mode       uint32
-	count      int32
-	first      int32
-	typ        uint32
-	byteOffset int
-	primcount  int32
+	mode          uint32
+	count         int32
+	first         int32
+	typ           uint32
+	byteOffset    int
+	primcount     int32
+	patchVertices int32
 }
 
 // SetVertexBuffer sets the vertexbuffer of the renderable.
@@ -81,6 +86,12 @@ func (r *Renderable) SetDrawElementsInstanced(mode uint32, count int32, typ uint
 	r.primcount = primcount
 }
 
+// SetPatchVertices sets the number of vertices per patch when drawing with
+// gl.PATCHES for tessellation.
+func (r *Renderable) SetPatchVertices(count int32) {
+	r.patchVertices = count
+}
+
 // SetInstancedAttributes flags provided attributes for instancing.
 func (r *Renderable) SetInstancedAttributes(instancedIndices []uint32) {
 	if r.instanced == nil {
@@ -92,27 +103,32 @@ func (r *Renderable) SetInstancedAttributes(instancedIndices []uint32) {
 }
 
 // Upload allocates the renderable to the GPU.
-func (r *Renderable) Upload() {
+func (r *Renderable) Upload() error {
+	// pointers may have changed since the last validation
+	r.validated = nil
 	// create underlying vao
-	gl.GenVertexArrays(1, &r.id)
+	r.id = backend.CreateVertexArray()
 	// bind
-	gl.BindVertexArray(r.id)
-	// bind vbo
-	r.vertexbuffer.Bind()
+	backend.BindVertexArray(r.id)
 	// set attribute pointers
 	for index, pointer := range r.pointers {
-		gl.EnableVertexAttribArray(index)
-		gl.VertexAttribPointer(
+		if pointer.Buffer != nil {
+			pointer.Buffer.Bind()
+		} else if r.vertexbuffer != nil {
+			r.vertexbuffer.Bind()
+		}
+		backend.EnableVertexAttribArray(index)
+		backend.VertexAttribPointer(
 			index,
 			pointer.Size,
 			pointer.Type,
 			false,
 			pointer.ByteStride,
-			gl.PtrOffset(pointer.ByteOffset))
+			pointer.ByteOffset)
 		// check if the attribute is instanced
 		_, instanced := r.instanced[index]
 		if instanced {
-			gl.VertexAttribDivisor(index, 1)
+			backend.VertexAttribDivisor(index, 1)
 		}
 	}
 	// bind EABO
@@ -120,21 +136,26 @@ func (r *Renderable) Upload() {
 		r.indexbuffer.Bind()
 	}
 	// unbind
-	gl.BindVertexArray(0)
+	backend.BindVertexArray(0)
+	return checkError(r.id)
 }
 
 // Bind binds the renderable.
 func (r *Renderable) Bind() {
-	gl.BindVertexArray(r.id)
+	backend.BindVertexArray(r.id)
 }
 
 // Unbind ubinds the renderable.
 func (r *Renderable) Unbind() {
-	gl.BindVertexArray(0)
+	backend.BindVertexArray(0)
 }
 
 // Draw renders the renderable.
 func (r *Renderable) Draw() {
+	countDraw(r.count, r.primcount)
+	if r.mode == gl.PATCHES && r.patchVertices > 0 {
+		backend.PatchParameteri(gl.PATCH_VERTICES, r.patchVertices)
+	}
 	if r.indexbuffer != nil {
 		if r.primcount > 0 {
 			r.indexbuffer.DrawInstanced(r.mode, r.count, r.typ, r.byteOffset, r.primcount)
@@ -153,7 +174,7 @@ func (r *Renderable) Draw() {
 // Destroy deallocates the renderable.
 func (r *Renderable) Destroy() {
 	if r.id != 0 {
-		gl.DeleteVertexArrays(1, &r.id)
+		backend.DeleteVertexArray(r.id)
 		r.id = 0
 	}
 }
@@ -2,9 +2,8 @@ package render
 
 import (
 	"fmt"
-	"io/ioutil"
 	"regexp"
-	"strings"
+	"sort"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/unchartedsoftware/plog"
@@ -26,80 +25,93 @@ func isGLSL(str string) bool {
 type Shader struct {
 	id               uint32
 	shaders          []uint32
+	sources          []shaderSource
+	preprocessor     *Preprocessor
+	varyings         []string
+	attributes       []*AttributeDescriptor
 	descriptors      map[string]*UniformDescriptor
 	blockDescriptors map[string]*UniformBlockDescriptor
+	// uploaded holds the components last uploaded to each uniform location
+	// since the uniform epoch
+	uploaded map[int32][]uint32
+	epoch    uint64
+	// generation is bumped whenever the program is relinked or replaced,
+	// program names may be reused so they cannot key cached results
+	generation uint64
+}
+
+// shaderSource represents the source of a single shader stage, either a file
+// path or GLSL, and the files it includes.
+type shaderSource struct {
+	typ    uint32
+	source string
+	files  []string
 }
 
 // Use activates the shader.
 func (s *Shader) Use() {
-	gl.UseProgram(s.id)
+	backend.UseProgram(s.id)
 }
 
-// CreateShader creates an individual shader object.
+// CreateShader preprocesses and creates an individual shader object. The
+// source is remembered so that the program can be reloaded.
 func (s *Shader) CreateShader(source string, typ uint32) (uint32, error) {
-	if !isGLSL(source) {
-		// load shader file into memory
-		raw, err := ioutil.ReadFile(source)
-		if err != nil {
-			return 0, err
-		}
-		source = string(raw)
-	}
-	// create shader object
-	shader := gl.CreateShader(typ)
-	// get c string
-	cstr, free := gl.Strs(source + "\x00")
-	// set source code of shader object
-	gl.ShaderSource(shader, 1, cstr, nil)
-	// free c string
-	free()
-	// compile shader
-	gl.CompileShader(shader)
-	// check error
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		// get info log length
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-		// get error message
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		// delete current objects and abort constructor
-		gl.DeleteShader(shader)
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+	processed, err := s.preprocess(source, typ)
+	if err != nil {
+		return 0, err
+	}
+	return s.compile(source, typ, processed)
+}
+
+// preprocess resolves includes and injects defines into the source, and
+// remembers it so that the program can be reloaded.
+func (s *Shader) preprocess(source string, typ uint32) (*Source, error) {
+	var processed *Source
+	var err error
+	if isGLSL(source) {
+		processed, err = s.getPreprocessor().Process(source)
+	} else {
+		processed, err = s.getPreprocessor().ProcessFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to preprocess %v: %v", source, err)
+	}
+	s.sources = append(s.sources, shaderSource{
+		typ:    typ,
+		source: source,
+		files:  processed.Files,
+	})
+	return processed, nil
+}
+
+func (s *Shader) compile(source string, typ uint32, processed *Source) (uint32, error) {
+	// create and compile shader object
+	shader, err := backend.CreateShader(typ, processed.Text)
+	if err != nil {
+		return 0, newCompileError(typ, source, processed, err.Error())
 	}
 	// return shader object
 	return shader, nil
 }
 
-// AttachShader attaches a shader object to the program.
+// AttachShader attaches a shader object to the program. Every attached
+// object is deleted once the program is linked.
 func (s *Shader) AttachShader(shader uint32) {
 	if s.id == 0 {
-		s.id = gl.CreateProgram()
+		s.id = backend.CreateProgram()
 	}
-	if s.shaders == nil {
-		s.shaders = make([]uint32, 0)
-		s.shaders = append(s.shaders, shader)
-	}
-	gl.AttachShader(s.id, shader)
+	s.shaders = append(s.shaders, shader)
+	backend.AttachShader(s.id, shader)
 }
 
 // LinkProgram links the shader program.
 func (s *Shader) LinkProgram() error {
 	// link shader program
-	gl.LinkProgram(s.id)
-	// error check
-	var status int32
-	gl.GetProgramiv(s.id, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(s.id, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(s.id, logLength, nil, gl.Str(log))
+	err := backend.LinkProgram(s.id)
+	if err != nil {
 		// delete shader objects
 		s.deleteShaders()
-		return fmt.Errorf("failed to link program: %v", log)
+		return newLinkError(err.Error())
 	}
 	// delete shader objects
 	s.deleteShaders()
@@ -108,412 +120,391 @@ func (s *Shader) LinkProgram() error {
 	return nil
 }
 
+// Reload recompiles and relinks the program from its original sources and
+// swaps it in place, so every technique using the shader draws with the new
+// program. On failure the current program is kept and the error returned.
+func (s *Shader) Reload() error {
+	next := &Shader{
+		preprocessor: s.preprocessor,
+		varyings:     s.varyings,
+	}
+	err := next.build(s.sources)
+	if err != nil {
+		return err
+	}
+	// swap in the new program
+	backend.DeleteProgram(s.id)
+	s.id = next.id
+	s.sources = next.sources
+	s.attributes = next.attributes
+	s.descriptors = next.descriptors
+	s.blockDescriptors = next.blockDescriptors
+	s.uploaded = nil
+	s.generation++
+	return nil
+}
+
+// build preprocesses, compiles and links the program from the provided
+// sources. If a program cache is set the linked program is loaded from it
+// when possible, and stored in it otherwise.
+func (s *Shader) build(sources []shaderSource) error {
+	processed := make([]*Source, len(sources))
+	for i, source := range sources {
+		p, err := s.preprocess(source.source, source.typ)
+		if err != nil {
+			return err
+		}
+		processed[i] = p
+	}
+	// load the linked program from the cache
+	var key string
+	if programCache != nil {
+		key = programCache.key(sources, processed, s.getPreprocessor().Defines, s.varyings)
+		if s.loadBinary(key) {
+			return nil
+		}
+	}
+	for i, source := range sources {
+		shader, err := s.compile(source.source, source.typ, processed[i])
+		if err != nil {
+			s.deleteShaders()
+			s.Destroy()
+			return err
+		}
+		s.AttachShader(shader)
+	}
+	if len(s.varyings) > 0 {
+		backend.TransformFeedbackVaryings(s.id, s.varyings, gl.INTERLEAVED_ATTRIBS)
+	}
+	err := s.LinkProgram()
+	if err != nil {
+		s.Destroy()
+		return err
+	}
+	if programCache != nil {
+		err := programCache.store(key, s.id)
+		if err != nil {
+			log.Warnf("failed to cache program binary: %v", err)
+		}
+	}
+	return nil
+}
+
+// Attributes returns the descriptors of the active vertex attributes of the
+// program, ordered by location.
+func (s *Shader) Attributes() []*AttributeDescriptor {
+	return s.attributes
+}
+
+// UniformBlock returns the descriptor of the active uniform block with the
+// provided name, or nil if the program has no such block.
+func (s *Shader) UniformBlock(name string) *UniformBlockDescriptor {
+	return s.blockDescriptors[name]
+}
+
 // SetUniform1i buffers a int32 by value.
-func (s *Shader) SetUniform1i(location int32, arg interface{}) {
+func (s *Shader) SetUniform1i(location int32, arg interface{}) error {
 	value, ok := arg.(int32)
 	if !ok {
-		log.Warnf("%v is not of type int32", arg)
-		return
+		return s.typeError(arg, "int32")
 	}
-	gl.Uniform1i(location, value)
+	s.uploaded = nil
+	backend.Uniform1i(location, value)
+	return checkError(s.id)
 }
 
 // SetUniform1ui buffers an uint32 by value.
-func (s *Shader) SetUniform1ui(location int32, arg interface{}) {
+func (s *Shader) SetUniform1ui(location int32, arg interface{}) error {
 	value, ok := arg.(uint32)
 	if !ok {
-		log.Warnf("%v is not of type uint32", arg)
-		return
+		return s.typeError(arg, "uint32")
 	}
-	gl.Uniform1ui(location, value)
+	s.uploaded = nil
+	backend.Uniform1ui(location, value)
+	return checkError(s.id)
 }
 
 // SetUniform1f buffers a float32 by value.
-func (s *Shader) SetUniform1f(location int32, arg interface{}) {
+func (s *Shader) SetUniform1f(location int32, arg interface{}) error {
 	value, ok := arg.(float32)
 	if !ok {
-		log.Warnf("%v is not of type float32", arg)
-		return
+		return s.typeError(arg, "float32")
 	}
-	gl.Uniform1f(location, value)
+	s.uploaded = nil
+	backend.Uniform1f(location, value)
+	return checkError(s.id)
 }
 
 // SetUniform1iv buffers one or more int32 by address.
-func (s *Shader) SetUniform1iv(location int32, count int32, arg interface{}) {
+func (s *Shader) SetUniform1iv(location int32, count int32, arg interface{}) error {
 	value, ok := arg.(*int32)
 	if !ok {
-		log.Warnf("%v is not of type *int32", arg)
-		return
+		return s.typeError(arg, "*int32")
 	}
-	gl.Uniform1iv(location, count, value)
+	s.uploaded = nil
+	backend.Uniform1iv(location, count, value)
+	return checkError(s.id)
 }
 
 // SetUniform1uiv buffers  one or more uint32 by address.
-func (s *Shader) SetUniform1uiv(location int32, count int32, arg interface{}) {
+func (s *Shader) SetUniform1uiv(location int32, count int32, arg interface{}) error {
 	value, ok := arg.(*uint32)
 	if !ok {
-		log.Warnf("%v is not of type *uint32", arg)
-		return
+		return s.typeError(arg, "*uint32")
 	}
-	gl.Uniform1uiv(location, count, value)
+	s.uploaded = nil
+	backend.Uniform1uiv(location, count, value)
+	return checkError(s.id)
 }
 
 // SetUniform1fv buffers one or more float32 by address.
-func (s *Shader) SetUniform1fv(location int32, count int32, arg interface{}) {
+func (s *Shader) SetUniform1fv(location int32, count int32, arg interface{}) error {
 	value, ok := arg.(*float32)
 	if !ok {
-		log.Warnf("%v is not of type *float32", arg)
-		return
+		return s.typeError(arg, "*float32")
 	}
-	gl.Uniform1fv(location, count, value)
+	s.uploaded = nil
+	backend.Uniform1fv(location, count, value)
+	return checkError(s.id)
 }
 
 // SetUniform2fv buffers one or more 2-component float32 by address.
-func (s *Shader) SetUniform2fv(location int32, count int32, arg interface{}) {
+func (s *Shader) SetUniform2fv(location int32, count int32, arg interface{}) error {
 	value, ok := arg.(*float32)
 	if !ok {
-		log.Warnf("%v is not of type *float32", arg)
-		return
+		return s.typeError(arg, "*float32")
 	}
-	gl.Uniform2fv(location, count, value)
+	s.uploaded = nil
+	backend.Uniform2fv(location, count, value)
+	return checkError(s.id)
 }
 
 // SetUniform3fv buffers one or more 3-component float32 by address.
-func (s *Shader) SetUniform3fv(location int32, count int32, arg interface{}) {
+func (s *Shader) SetUniform3fv(location int32, count int32, arg interface{}) error {
 	value, ok := arg.(*float32)
 	if !ok {
-		log.Warnf("%v is not of type *float32", arg)
-		return
+		return s.typeError(arg, "*float32")
 	}
-	gl.Uniform3fv(location, count, value)
+	s.uploaded = nil
+	backend.Uniform3fv(location, count, value)
+	return checkError(s.id)
 }
 
 // SetUniform4fv buffers one or more 4-component float32 by address.
-func (s *Shader) SetUniform4fv(location int32, count int32, arg interface{}) {
+func (s *Shader) SetUniform4fv(location int32, count int32, arg interface{}) error {
 	value, ok := arg.(*float32)
 	if !ok {
-		log.Warnf("%v is not of type *float32", arg)
-		return
+		return s.typeError(arg, "*float32")
 	}
-	gl.Uniform4fv(location, count, value)
+	s.uploaded = nil
+	backend.Uniform4fv(location, count, value)
+	return checkError(s.id)
 }
 
 // SetUniformMatrix3fv buffers one or more 9-component float32 by address.
-func (s *Shader) SetUniformMatrix3fv(location int32, count int32, arg interface{}) {
+func (s *Shader) SetUniformMatrix3fv(location int32, count int32, arg interface{}) error {
 	value, ok := arg.(*float32)
 	if !ok {
-		log.Warnf("%v is not of type *float32", arg)
-		return
+		return s.typeError(arg, "*float32")
 	}
-	gl.UniformMatrix3fv(location, count, false, value)
+	s.uploaded = nil
+	backend.UniformMatrix3fv(location, count, value)
+	return checkError(s.id)
 }
 
 // SetUniformMatrix4fv buffers one or more 16-component float32 by address.
-func (s *Shader) SetUniformMatrix4fv(location int32, count int32, arg interface{}) {
+func (s *Shader) SetUniformMatrix4fv(location int32, count int32, arg interface{}) error {
 	value, ok := arg.(*float32)
 	if !ok {
-		log.Warnf("%v is not of type *float32", arg)
-		return
+		return s.typeError(arg, "*float32")
 	}
-	gl.UniformMatrix4fv(location, count, false, value)
+	s.uploaded = nil
+	backend.UniformMatrix4fv(location, count, value)
+	return checkError(s.id)
 }
 
-// SetUniform buffers one or more uniforms.
-func (s *Shader) SetUniform(name string, arg interface{}) {
-	// check descriptors
+// SetUniform buffers one or more uniforms. The value is checked against the
+// type and array size of the active uniform with the provided name, see
+// Command.Uniform for the supported values.
+func (s *Shader) SetUniform(name string, arg interface{}) error {
+	// check descriptors, arrays are reflected by their first element
 	descriptor, ok := s.descriptors[name]
 	if !ok {
-		log.Warnf("uniform `%s` was not recognized", name)
-		return
+		descriptor, ok = s.descriptors[name+"[0]"]
+	}
+	if !ok {
+		return &Error{
+			Op:      "SetUniform",
+			Object:  s.id,
+			Message: fmt.Sprintf("uniform `%s` is not an active uniform of the program, it may have been optimized out", name),
+		}
+	}
+	err := s.setUniform(descriptor, arg)
+	if err != nil {
+		e, ok := err.(*Error)
+		if ok {
+			e.Message = fmt.Sprintf("uniform `%s`: %s", name, e.Message)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Shader) setUniform(descriptor *UniformDescriptor, arg interface{}) error {
+	value, err := newUniformValue(arg)
+	if err != nil {
+		return s.uniformError("%v", err)
+	}
+	// check type and array size
+	if !value.matches(descriptor.Type) {
+		return s.uniformError("%T cannot be buffered to a uniform of type %s", arg, glslTypeName(descriptor.Type))
+	}
+	count := value.count
+	if count == 0 {
+		count = descriptor.Count
+	}
+	if count > descriptor.Count {
+		return s.uniformError("%d elements exceed the %s[%d] array", count, glslTypeName(descriptor.Type), descriptor.Count)
 	}
+	// skip values identical to the last upload to the location, unless
+	// foreign code may have changed it since
+	if s.epoch != uniformEpoch {
+		s.uploaded = nil
+		s.epoch = uniformEpoch
+	}
+	location := descriptor.Location
+	components := value.components(descriptor.Type, count)
+	if wordsEqual(s.uploaded[location], components) {
+		stats.UniformCacheHits++
+		return nil
+	}
+	stats.UniformCacheMisses++
 	// buffer uniform data
 	switch descriptor.Type {
-	case gl.SAMPLER_2D:
-		s.SetUniform1i(descriptor.Location, arg)
-	case gl.SAMPLER_CUBE:
-		s.SetUniform1i(descriptor.Location, arg)
-	case gl.INT:
-		if descriptor.Count > 1 {
-			s.SetUniform1iv(descriptor.Location, descriptor.Count, arg)
-		} else {
-			s.SetUniform1i(descriptor.Location, arg)
-		}
+	case gl.INT, gl.BOOL, gl.SAMPLER_2D, gl.SAMPLER_CUBE:
+		backend.Uniform1iv(location, count, value.ints)
 	case gl.UNSIGNED_INT:
-		if descriptor.Count > 1 {
-			s.SetUniform1uiv(descriptor.Location, descriptor.Count, arg)
-		} else {
-			s.SetUniform1ui(descriptor.Location, arg)
-		}
+		backend.Uniform1uiv(location, count, value.uints)
 	case gl.FLOAT:
-		if descriptor.Count > 1 {
-			s.SetUniform1fv(descriptor.Location, descriptor.Count, arg)
-		} else {
-			s.SetUniform1f(descriptor.Location, arg)
-		}
+		backend.Uniform1fv(location, count, value.floats)
 	case gl.FLOAT_VEC2:
-		s.SetUniform2fv(descriptor.Location, descriptor.Count, arg)
+		backend.Uniform2fv(location, count, value.floats)
 	case gl.FLOAT_VEC3:
-		s.SetUniform3fv(descriptor.Location, descriptor.Count, arg)
+		backend.Uniform3fv(location, count, value.floats)
 	case gl.FLOAT_VEC4:
-		s.SetUniform4fv(descriptor.Location, descriptor.Count, arg)
+		backend.Uniform4fv(location, count, value.floats)
 	case gl.FLOAT_MAT3:
-		s.SetUniformMatrix3fv(descriptor.Location, descriptor.Count, arg)
+		backend.UniformMatrix3fv(location, count, value.floats)
 	case gl.FLOAT_MAT4:
-		s.SetUniformMatrix4fv(descriptor.Location, descriptor.Count, arg)
+		backend.UniformMatrix4fv(location, count, value.floats)
+	default:
+		return s.uniformError("uniforms of type %s are not supported", glslTypeName(descriptor.Type))
+	}
+	err = checkError(s.id)
+	if err != nil {
+		delete(s.uploaded, location)
+		return err
+	}
+	if s.uploaded == nil {
+		s.uploaded = make(map[int32][]uint32)
 	}
+	s.uploaded[location] = components
+	return nil
+}
+
+func (s *Shader) getPreprocessor() *Preprocessor {
+	if s.preprocessor != nil {
+		return s.preprocessor
+	}
+	return DefaultPreprocessor
 }
 
 // Destroy deallocates the shader program.
 func (s *Shader) Destroy() {
 	if s.id != 0 {
-		gl.DeleteProgram(s.id)
+		backend.DeleteProgram(s.id)
 		s.id = 0
 	}
+	s.uploaded = nil
+}
+
+func (s *Shader) typeError(arg interface{}, typ string) error {
+	return &Error{
+		Op:      "SetUniform",
+		Object:  s.id,
+		Message: fmt.Sprintf("%v is not of type %s", arg, typ),
+	}
+}
+
+func (s *Shader) uniformError(format string, args ...interface{}) error {
+	return &Error{
+		Op:      "SetUniform",
+		Object:  s.id,
+		Message: fmt.Sprintf(format, args...),
+	}
 }
 
 func (s *Shader) deleteShaders() {
-	if s.shaders != nil {
-		for _, shader := range s.shaders {
-			gl.DeleteShader(shader)
+	// attached objects are only deleted once detached
+	for _, shader := range s.shaders {
+		if s.id != 0 {
+			backend.DetachShader(s.id, shader)
 		}
-		s.shaders = nil
+		backend.DeleteShader(shader)
 	}
+	s.shaders = nil
 }
 
 func (s *Shader) queryUniforms() {
-	// query all necessary uniform information
-	uniformIndices := s.queryUniformIndices()
-	uniformNames := s.queryUniformNames(uniformIndices)
-	uniformTypes := s.queryUniformTypes(uniformIndices)
-	uniformCounts := s.queryUniformCounts(uniformIndices)
-	parentBlockIndices := s.queryParentBlockIndices(uniformIndices)
-	uniformOffsets := s.queryUniformOffsets(uniformIndices)
-	uniformLocations := s.queryUniformLocations(uniformNames)
+	// values uploaded to and renderables validated against a previous
+	// program are stale
+	s.uploaded = nil
+	s.generation++
+
+	// query attributes, ordered by location
+	s.attributes = backend.ActiveAttributes(s.id)
+	sort.Sort(attributesByLocation(s.attributes))
 
 	// create descriptor maps
 	s.descriptors = make(map[string]*UniformDescriptor)
 	s.blockDescriptors = make(map[string]*UniformBlockDescriptor)
 
-	// for each uniform index
-	for _, index := range uniformIndices {
-
-		// check if part of a block or not
-		if parentBlockIndices[index] == -1 {
-			// not in a block, add as uniform descriptor
-
-			name := uniformNames[index]
-			typ := uniformTypes[index]
-			count := uniformCounts[index]
-			location := uniformLocations[index]
-
-			//// DEBUG
-			log.Infof("Uniform name: `%s`, type: `%d`, count: `%d`, location: %d",
-				name,
-				typ,
-				count,
-				location)
-			////
-
-			s.descriptors[name] = &UniformDescriptor{
-				Name:     name[:len(name)-1],
-				Type:     typ,
-				Count:    count,
-				Location: location,
-			}
-		}
-	}
+	// for each uniform not part of a block
+	for _, descriptor := range backend.ActiveUniforms(s.id) {
 
-	// query all necessary uniform block information
-	blockIndices := s.queryUniformBlockIndices()
-	blockNames := s.queryUniformBlockNames(blockIndices)
-	blockSizes := s.queryUniformBlockSizes(blockIndices)
-	bufferAlignment := s.queryUniformBufferAlignment()
+		//// DEBUG
+		log.Infof("Uniform name: `%s`, type: `%d`, count: `%d`, location: %d",
+			descriptor.Name,
+			descriptor.Type,
+			descriptor.Count,
+			descriptor.Location)
+		////
 
-	// for each block
-	for _, index := range blockIndices {
-
-		// get all uniform offsets that are part of this block
-		offsets := make(map[string]int32)
-		for i, parentIndex := range parentBlockIndices {
-			if parentIndex == int32(index) {
-				// uniform is part of this block
-				offsets[uniformNames[i]] = uniformOffsets[i]
-			}
-		}
+		s.descriptors[descriptor.Name] = descriptor
+	}
 
-		blockName := blockNames[index]
-		blockIndex := blockIndices[index]
-		blockSize := blockSizes[index]
+	// for each block
+	for _, descriptor := range backend.ActiveUniformBlocks(s.id) {
 
 		//// DEBUG
 		log.Infof("Uniform block name: `%s`, index: `%d`, size: %d",
-			blockName,
-			blockIndex,
-			blockSize)
-		for name, offset := range offsets {
+			descriptor.Name,
+			descriptor.Index,
+			descriptor.Size)
+		for name, offset := range descriptor.Offsets {
 			log.Infof("Name: %s, offset: %d", name, offset)
 		}
 		////
 
 		// add block descriptor
-		s.blockDescriptors[blockName] = &UniformBlockDescriptor{
-			Name:      blockName,
-			Index:     blockIndex,
-			Size:      blockSize,
-			Offsets:   offsets,
-			Alignment: bufferAlignment,
-		}
-
-		// set binding point for block index and shader
-		// TODO: make this configurable
-		gl.UniformBlockBinding(s.id, blockIndex, blockIndex)
-	}
-}
-
-func toString(buff []uint8) string {
-	b := make([]byte, len(buff))
-	for i, v := range buff {
-		b[i] = byte(v)
-	}
-	return string(b[:len(b)-1]) // trim null terminator
-}
+		s.blockDescriptors[descriptor.Name] = descriptor
 
-func toUint32(arr []int32) []uint32 {
-	res := make([]uint32, len(arr))
-	for i, val := range arr {
-		res[i] = uint32(val)
-	}
-	return res
-}
-
-func (s *Shader) queryUniformIndices() []uint32 {
-	// get the number of uniforms
-	var numActiveUniforms int32
-	gl.GetProgramiv(s.id, gl.ACTIVE_UNIFORMS, &numActiveUniforms)
-	// get uniform indices from 0 to gl.ACTIVE_UNIFORMS
-	indices := make([]uint32, numActiveUniforms)
-	for i := int32(0); i < numActiveUniforms; i++ {
-		indices[i] = uint32(i)
-	}
-	return indices
-}
-
-func (s *Shader) queryUniformNames(indices []uint32) []string {
-	// check if no uniforms
-	if len(indices) == 0 {
-		return make([]string, 0)
-	}
-	// get uniform name lengths
-	nameLengths := make([]int32, len(indices))
-	gl.GetActiveUniformsiv(s.id, int32(len(indices)), &indices[0], gl.UNIFORM_NAME_LENGTH, &nameLengths[0])
-	// for each uniform index
-	names := make([]string, len(indices))
-	for _, index := range indices {
-		// get uniform name
-		nameLength := nameLengths[index]
-		// create name slice
-		name := make([]uint8, nameLength)
-		// get name bytes
-		gl.GetActiveUniformName(s.id, index, nameLength, nil, &name[0])
-		// cast from uint8 to string
-		names[index] = toString(name)
-	}
-	return names
-}
-
-func (s *Shader) queryUniformTypes(indices []uint32) []uint32 {
-	// check if no uniforms
-	if len(indices) == 0 {
-		return make([]uint32, 0)
-	}
-	// get uniform types
-	types := make([]int32, len(indices))
-	gl.GetActiveUniformsiv(s.id, int32(len(indices)), &indices[0], gl.UNIFORM_TYPE, &types[0])
-	// cast to uint32
-	return toUint32(types)
-}
-
-func (s *Shader) queryUniformCounts(indices []uint32) []int32 {
-	// check if no uniforms
-	if len(indices) == 0 {
-		return make([]int32, 0)
-	}
-	// get uniform types
-	sizes := make([]int32, len(indices))
-	gl.GetActiveUniformsiv(s.id, int32(len(indices)), &indices[0], gl.UNIFORM_SIZE, &sizes[0])
-	return sizes
-}
-
-func (s *Shader) queryParentBlockIndices(indices []uint32) []int32 {
-	// check if no uniforms
-	if len(indices) == 0 {
-		return make([]int32, 0)
-	}
-	// get uniform block indices (-1 is not part of a block)
-	blockIndices := make([]int32, len(indices))
-	gl.GetActiveUniformsiv(s.id, int32(len(indices)), &indices[0], gl.UNIFORM_BLOCK_INDEX, &blockIndices[0])
-	return blockIndices
-}
-
-func (s *Shader) queryUniformOffsets(indices []uint32) []int32 {
-	// check if no uniforms
-	if len(indices) == 0 {
-		return make([]int32, 0)
-	}
-	// get uniform offsets
-	offsets := make([]int32, len(indices))
-	gl.GetActiveUniformsiv(s.id, int32(len(indices)), &indices[0], gl.UNIFORM_OFFSET, &offsets[0])
-	return offsets
-}
-
-func (s *Shader) queryUniformLocations(names []string) []int32 {
-	locations := make([]int32, len(names))
-	for i, name := range names {
-		locations[i] = gl.GetUniformLocation(s.id, gl.Str(name+"\x00"))
-	}
-	return locations
-}
-
-func (s *Shader) queryUniformBlockIndices() []uint32 {
-	// get the number of active uniform blocks
-	var numActiveBlocks int32
-	gl.GetProgramiv(s.id, gl.ACTIVE_UNIFORM_BLOCKS, &numActiveBlocks)
-	// get uniform indices from 0 to gl.ACTIVE_UNIFORMS
-	indices := make([]uint32, numActiveBlocks)
-	for i := int32(0); i < numActiveBlocks; i++ {
-		indices[i] = uint32(i)
-	}
-	return indices
-}
-
-func (s *Shader) queryUniformBlockNames(indices []uint32) []string {
-	names := make([]string, len(indices))
-	for _, index := range indices {
-		// get the length of the name
-		var nameLength int32
-		gl.GetActiveUniformBlockiv(s.id, index, gl.UNIFORM_BLOCK_NAME_LENGTH, &nameLength)
-		// get the block name
-		name := make([]uint8, nameLength)
-		// get name bytes
-		gl.GetActiveUniformBlockName(s.id, index, nameLength, nil, &name[0])
-		// cast from uint8 to string
-		names[index] = toString(name)
-	}
-	return names
-}
-
-func (s *Shader) queryUniformBlockSizes(indices []uint32) []int32 {
-	sizes := make([]int32, len(indices))
-	for _, index := range indices {
-		var blockSize int32
-		gl.GetActiveUniformBlockiv(s.id, index, gl.UNIFORM_BLOCK_DATA_SIZE, &blockSize)
-		sizes[index] = blockSize
+		// set binding point for block index and shader, defaulting to the
+		// block index
+		binding, ok := blockBindings[descriptor.Name]
+		if !ok {
+			binding = descriptor.Index
+		}
+		backend.UniformBlockBinding(s.id, descriptor.Index, binding)
 	}
-	return sizes
-}
-
-func (s *Shader) queryUniformBufferAlignment() int32 {
-	var uniformBufferAlignment int32
-	gl.GetIntegerv(gl.UNIFORM_BUFFER_OFFSET_ALIGNMENT, &uniformBufferAlignment)
-	return uniformBufferAlignment
 }
@@ -0,0 +1,231 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	mesaLogRegex    *regexp.Regexp
+	nvidiaLogRegex  *regexp.Regexp
+	amdLogRegex     *regexp.Regexp
+	plainLogRegex   *regexp.Regexp
+	summaryLogRegex *regexp.Regexp
+)
+
+func init() {
+	// mesa `0:12(3): error: message`
+	mesaLogRegex = regexp.MustCompile(`^\d+:(\d+)\((\d+)\)\s*:\s*(error|warning)\s*:\s*(.*)$`)
+	// nvidia `0(12) : error C1008: message`
+	nvidiaLogRegex = regexp.MustCompile(`^\d+\((\d+)\)\s*:\s*(error|warning)\s+(.*)$`)
+	// amd `ERROR: 0:12: message`
+	amdLogRegex = regexp.MustCompile(`^(ERROR|WARNING):\s*\d+:(\d+):\s*(.*)$`)
+	// messages without a location, such as most link errors
+	plainLogRegex = regexp.MustCompile(`^(?i)(error|warning)\s*:\s*(.*)$`)
+	// amd `ERROR: 2 compilation errors.  No code generated.`
+	summaryLogRegex = regexp.MustCompile(`^(?i)(error|warning):\s*\d+ compilation (errors|warnings)`)
+}
+
+// Severity represents the severity of a diagnostic.
+type Severity int
+
+const (
+	// SeverityError is the severity of a diagnostic that fails the build.
+	SeverityError Severity = iota
+	// SeverityWarning is the severity of a diagnostic that does not.
+	SeverityWarning
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic represents a single message of a compile or link log.
+type Diagnostic struct {
+	// Stage is the shader type of the stage, or zero for link diagnostics.
+	Stage uint32
+	// File is the file the message refers to, or `<source>` for GLSL
+	// strings. It is empty if the log gives no location.
+	File string
+	// Line is the one-based line in the file, or zero if unknown.
+	Line int
+	// Column is the one-based column in the line, or zero if unknown.
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+// String returns the diagnostic in the `file:line:column: severity: message`
+// form understood by editors.
+func (d Diagnostic) String() string {
+	var buf bytes.Buffer
+	if d.File != "" {
+		buf.WriteString(d.File)
+		if d.Line > 0 {
+			fmt.Fprintf(&buf, ":%d", d.Line)
+			if d.Column > 0 {
+				fmt.Fprintf(&buf, ":%d", d.Column)
+			}
+		}
+		buf.WriteString(": ")
+	}
+	fmt.Fprintf(&buf, "%s: %s", d.Severity, d.Message)
+	return buf.String()
+}
+
+// ShaderError represents a failure to compile a shader stage or to link a
+// program, with the driver log parsed into diagnostics.
+type ShaderError struct {
+	// Op is either `compile` or `link`.
+	Op string
+	// Stage is the shader type of the stage that failed to compile, or zero
+	// for link failures.
+	Stage uint32
+	// Source is the file the stage was read from, or `<source>` for GLSL
+	// strings.
+	Source string
+	// Log is the driver log as returned by the backend.
+	Log         string
+	Diagnostics []Diagnostic
+}
+
+// Error returns the error message with one diagnostic per line.
+func (e *ShaderError) Error() string {
+	var header string
+	if e.Op == "link" {
+		header = "failed to link program"
+	} else {
+		header = fmt.Sprintf("failed to compile %s shader %s", stageName(e.Stage), e.Source)
+	}
+	if len(e.Diagnostics) == 0 {
+		return fmt.Sprintf("%s: %s", header, strings.TrimSpace(e.Log))
+	}
+	lines := make([]string, len(e.Diagnostics))
+	for i, diagnostic := range e.Diagnostics {
+		lines[i] = diagnostic.String()
+	}
+	return fmt.Sprintf("%s: %s", header, strings.Join(lines, "\n"))
+}
+
+// Errors returns the diagnostics with error severity.
+func (e *ShaderError) Errors() []Diagnostic {
+	var errs []Diagnostic
+	for _, diagnostic := range e.Diagnostics {
+		if diagnostic.Severity == SeverityError {
+			errs = append(errs, diagnostic)
+		}
+	}
+	return errs
+}
+
+// newCompileError returns the error for a stage that failed to compile.
+// Line numbers of the log are mapped through the preprocessed source to the
+// file and line they originated from.
+func newCompileError(typ uint32, source string, processed *Source, log string) *ShaderError {
+	name := source
+	if isGLSL(source) {
+		name = sourceName("")
+	}
+	return &ShaderError{
+		Op:          "compile",
+		Stage:       typ,
+		Source:      name,
+		Log:         log,
+		Diagnostics: parseLog(log, typ, name, processed),
+	}
+}
+
+// newLinkError returns the error for a program that failed to link.
+func newLinkError(log string) *ShaderError {
+	return &ShaderError{
+		Op:          "link",
+		Log:         log,
+		Diagnostics: parseLog(log, 0, "", nil),
+	}
+}
+
+// parseLog parses the diagnostics of a Mesa, NVIDIA or AMD log. Lines that
+// continue a message are appended to it, and headers preceding the first
+// message are skipped.
+func parseLog(log string, stage uint32, file string, processed *Source) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range splitLines(log) {
+		line = strings.TrimSpace(line)
+		if line == "" || summaryLogRegex.MatchString(line) {
+			continue
+		}
+		diagnostic, ok := parseLogLine(line)
+		if !ok {
+			if len(diagnostics) > 0 {
+				last := &diagnostics[len(diagnostics)-1]
+				last.Message += "\n" + line
+			}
+			continue
+		}
+		diagnostic.Stage = stage
+		if diagnostic.Line > 0 {
+			diagnostic.File = file
+		}
+		// map the line of the preprocessed text to its origin
+		if diagnostic.Line > 0 && processed != nil {
+			origin, ok := processed.Origin(diagnostic.Line)
+			if ok {
+				diagnostic.File = origin.File
+				diagnostic.Line = origin.Line
+			}
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+func parseLogLine(line string) (Diagnostic, bool) {
+	if match := mesaLogRegex.FindStringSubmatch(line); match != nil {
+		return Diagnostic{
+			Line:     atoi(match[1]),
+			Column:   atoi(match[2]),
+			Severity: parseSeverity(match[3]),
+			Message:  match[4],
+		}, true
+	}
+	if match := nvidiaLogRegex.FindStringSubmatch(line); match != nil {
+		return Diagnostic{
+			Line:     atoi(match[1]),
+			Severity: parseSeverity(match[2]),
+			Message:  match[3],
+		}, true
+	}
+	if match := amdLogRegex.FindStringSubmatch(line); match != nil {
+		return Diagnostic{
+			Line:     atoi(match[2]),
+			Severity: parseSeverity(match[1]),
+			Message:  match[3],
+		}, true
+	}
+	if match := plainLogRegex.FindStringSubmatch(line); match != nil {
+		return Diagnostic{
+			Severity: parseSeverity(match[1]),
+			Message:  match[2],
+		}, true
+	}
+	return Diagnostic{}, false
+}
+
+func parseSeverity(str string) Severity {
+	if strings.EqualFold(str, "warning") {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+func atoi(str string) int {
+	n, _ := strconv.Atoi(str)
+	return n
+}
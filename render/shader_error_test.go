@@ -0,0 +1,112 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestParseLog(t *testing.T) {
+	tests := []struct {
+		vendor   string
+		log      string
+		expected []Diagnostic
+	}{
+		{
+			vendor: "mesa",
+			log: "0:12(15): error: `foo' undeclared\n" +
+				"0:12(15): error: operands to arithmetic operators must be numeric\n" +
+				"0:3(10): warning: extension `GL_ARB_foo' unsupported in fragment shader\n",
+			expected: []Diagnostic{
+				{File: "flat.frag", Line: 12, Column: 15, Severity: SeverityError, Message: "`foo' undeclared"},
+				{File: "flat.frag", Line: 12, Column: 15, Severity: SeverityError, Message: "operands to arithmetic operators must be numeric"},
+				{File: "flat.frag", Line: 3, Column: 10, Severity: SeverityWarning, Message: "extension `GL_ARB_foo' unsupported in fragment shader"},
+			},
+		},
+		{
+			vendor: "nvidia",
+			log: "0(12) : error C1008: undefined variable \"foo\"\n" +
+				"0(14) : warning C7050: \"color\" might be used before being initialized\n",
+			expected: []Diagnostic{
+				{File: "flat.frag", Line: 12, Severity: SeverityError, Message: "C1008: undefined variable \"foo\""},
+				{File: "flat.frag", Line: 14, Severity: SeverityWarning, Message: "C7050: \"color\" might be used before being initialized"},
+			},
+		},
+		{
+			vendor: "amd",
+			log: "ERROR: 0:12: 'foo' : undeclared identifier \n" +
+				"ERROR: 0:12: '' : compilation terminated \n" +
+				"ERROR: 2 compilation errors.  No code generated.\n\n",
+			expected: []Diagnostic{
+				{File: "flat.frag", Line: 12, Severity: SeverityError, Message: "'foo' : undeclared identifier"},
+				{File: "flat.frag", Line: 12, Severity: SeverityError, Message: "'' : compilation terminated"},
+			},
+		},
+		{
+			vendor: "continuation",
+			log: "Fragment info\n" +
+				"-------------\n" +
+				"0:7(2): error: no matching function for call to `texture(sampler2D, float)'; candidates are:\n" +
+				"    vec4 texture(sampler2D, vec2)\n" +
+				"    vec4 texture(sampler2D, vec2, float)\n",
+			expected: []Diagnostic{
+				{
+					File:     "flat.frag",
+					Line:     7,
+					Column:   2,
+					Severity: SeverityError,
+					Message: "no matching function for call to `texture(sampler2D, float)'; candidates are:\n" +
+						"vec4 texture(sampler2D, vec2)\n" +
+						"vec4 texture(sampler2D, vec2, float)",
+				},
+			},
+		},
+		{
+			vendor: "link",
+			log:    "error: fragment shader varying vColor not written by vertex shader\n",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Message: "fragment shader varying vColor not written by vertex shader"},
+			},
+		},
+	}
+	for _, test := range tests {
+		diagnostics := parseLog(test.log, gl.FRAGMENT_SHADER, "flat.frag", nil)
+		if len(diagnostics) != len(test.expected) {
+			t.Errorf("%s: expected %d diagnostics, got %d: %v", test.vendor, len(test.expected), len(diagnostics), diagnostics)
+			continue
+		}
+		for i, diagnostic := range diagnostics {
+			expected := test.expected[i]
+			expected.Stage = gl.FRAGMENT_SHADER
+			if diagnostic != expected {
+				t.Errorf("%s: expected diagnostic %#v, got %#v", test.vendor, expected, diagnostic)
+			}
+		}
+	}
+}
+
+func TestParseLogMapsLines(t *testing.T) {
+	processed := &Source{
+		Lines: []SourceLine{
+			{File: "flat.frag", Line: 1},
+			{File: "<defines>", Line: 0},
+			{File: "common.glsl", Line: 1},
+			{File: "common.glsl", Line: 2},
+			{File: "flat.frag", Line: 3},
+		},
+	}
+	diagnostics := parseLog("0:4(5): error: syntax error\n0(9) : error C0000: syntax error", gl.FRAGMENT_SHADER, "flat.frag", processed)
+	expected := []Diagnostic{
+		{Stage: gl.FRAGMENT_SHADER, File: "common.glsl", Line: 2, Column: 5, Severity: SeverityError, Message: "syntax error"},
+		// lines outside of the source keep the stage file
+		{Stage: gl.FRAGMENT_SHADER, File: "flat.frag", Line: 9, Severity: SeverityError, Message: "C0000: syntax error"},
+	}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %d: %v", len(expected), len(diagnostics), diagnostics)
+	}
+	for i := range expected {
+		if diagnostics[i] != expected[i] {
+			t.Errorf("expected diagnostic %#v, got %#v", expected[i], diagnostics[i])
+		}
+	}
+}
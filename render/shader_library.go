@@ -0,0 +1,68 @@
+package render
+
+import (
+	"sort"
+	"strings"
+)
+
+// ShaderLibrary represents a base vertex and fragment shader compiled into a
+// separate program for each set of feature defines. Each permutation is
+// compiled once, when first requested, and cached by its key.
+type ShaderLibrary struct {
+	vert     string
+	frag     string
+	variants map[string]*Shader
+	watchers []*ShaderWatcher
+}
+
+// NewShaderLibrary instantiates and returns a new shader library for the
+// provided vertex and fragment shader files or GLSL.
+func NewShaderLibrary(vert, frag string) *ShaderLibrary {
+	return &ShaderLibrary{
+		vert:     vert,
+		frag:     frag,
+		variants: make(map[string]*Shader),
+	}
+}
+
+// VariantKey returns the cache key of the permutation for the provided
+// defines. The key does not depend on the map order.
+func VariantKey(defines map[string]string) string {
+	pairs := make([]string, 0, len(defines))
+	for name, value := range defines {
+		if value == "" {
+			pairs = append(pairs, name)
+		} else {
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Variant returns the permutation of the shader for the provided defines,
+// compiling it if it has not been requested before.
+func (l *ShaderLibrary) Variant(defines map[string]string) (*Shader, error) {
+	key := VariantKey(defines)
+	shader, ok := l.variants[key]
+	if ok {
+		return shader, nil
+	}
+	shader, err := NewVertFragShaderWithDefines(l.vert, l.frag, defines)
+	if err != nil {
+		return nil, err
+	}
+	l.variants[key] = shader
+	for _, watcher := range l.watchers {
+		watcher.Watch(shader)
+	}
+	return shader, nil
+}
+
+// Destroy deallocates every compiled permutation.
+func (l *ShaderLibrary) Destroy() {
+	for key, shader := range l.variants {
+		shader.Destroy()
+		delete(l.variants, key)
+	}
+}
@@ -0,0 +1,97 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+func TestUniformCache(t *testing.T) {
+	b := newRecordingBackend(t)
+	shader, err := render.NewVertFragShader(
+		"../resources/shaders/flat.vert",
+		"../resources/shaders/flat.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quad := newQuad(t, 8)
+	technique := render.NewTechnique()
+	technique.Shader(shader)
+	technique.Viewport(&render.Viewport{
+		Width:  testWidth,
+		Height: testHeight,
+	})
+	red := mgl32.Vec4{1, 0, 0, 1}
+	blue := mgl32.Vec4{0, 0, 1, 1}
+	tests := []struct {
+		name    string
+		before  func() error
+		color   mgl32.Vec4
+		hits    int
+		misses  int
+		uploads []string
+	}{
+		{
+			name:    "first draw",
+			color:   red,
+			misses:  2,
+			uploads: []string{"Uniform4fv 1", "UniformMatrix4fv 1"},
+		},
+		{
+			name:  "repeated values",
+			color: red,
+			hits:  2,
+		},
+		{
+			name:    "changed value",
+			color:   blue,
+			hits:    1,
+			misses:  1,
+			uploads: []string{"Uniform4fv 1"},
+		},
+		{
+			name:    "after reload",
+			before:  shader.Reload,
+			color:   blue,
+			misses:  2,
+			uploads: []string{"Uniform4fv 1", "UniformMatrix4fv 1"},
+		},
+		{
+			name: "after invalidate",
+			before: func() error {
+				render.DefaultContext().Invalidate()
+				return nil
+			},
+			color:   blue,
+			misses:  2,
+			uploads: []string{"Uniform4fv 1", "UniformMatrix4fv 1"},
+		},
+	}
+	for _, test := range tests {
+		if test.before != nil {
+			err := test.before()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		command := &render.Command{}
+		command.Uniform("uModel", mgl32.Ident4())
+		command.Uniform("uColor", test.color)
+		command.Renderable(quad)
+
+		b.reset()
+		render.ResetStats()
+		err := technique.Draw([]*render.Command{command})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := render.FrameStats()
+		if stats.UniformCacheHits != test.hits || stats.UniformCacheMisses != test.misses {
+			t.Errorf("%s: expected %d hits and %d misses, got %d and %d",
+				test.name, test.hits, test.misses, stats.UniformCacheHits, stats.UniformCacheMisses)
+		}
+		expectCalls(t, test.name, filterCalls(b.calls, "Uniform4fv", "UniformMatrix4fv"), test.uploads)
+	}
+}
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/unchartedsoftware/plog"
+)
+
+// ShaderWatcher represents a set of shaders that are reloaded whenever one of
+// their source or included files is modified. Modification times are polled
+// through the asset file system, so Poll must be called regularly from the
+// render thread.
+type ShaderWatcher struct {
+	interval time.Duration
+	last     time.Time
+	shaders  []*Shader
+	modTimes map[*Shader]map[string]time.Time
+}
+
+// NewShaderWatcher instantiates and returns a new shader watcher that checks
+// source files no more than once per interval.
+func NewShaderWatcher(interval time.Duration) *ShaderWatcher {
+	return &ShaderWatcher{
+		interval: interval,
+		modTimes: make(map[*Shader]map[string]time.Time),
+	}
+}
+
+// Watch adds the shader to the watcher. Shaders created from GLSL strings
+// rather than files have nothing to watch and are ignored.
+func (w *ShaderWatcher) Watch(shader *Shader) {
+	paths := shader.paths()
+	if len(paths) == 0 {
+		return
+	}
+	// shaders sharing a file each keep the time they last loaded it
+	modTimes := make(map[string]time.Time)
+	for _, path := range paths {
+		modTimes[path] = assetModTime(path)
+	}
+	w.modTimes[shader] = modTimes
+	w.shaders = append(w.shaders, shader)
+}
+
+// WatchLibrary adds every permutation of the library to the watcher,
+// including permutations compiled later.
+func (w *ShaderWatcher) WatchLibrary(library *ShaderLibrary) {
+	for _, shader := range library.variants {
+		w.Watch(shader)
+	}
+	library.watchers = append(library.watchers, w)
+}
+
+// Poll reloads every watched shader with a source file modified since the
+// last poll. It returns an error for each shader that failed to reload, those
+// shaders keep their last good program. Compile and link failures are
+// returned as *ShaderError values.
+func (w *ShaderWatcher) Poll() []error {
+	now := time.Now()
+	if now.Sub(w.last) < w.interval {
+		return nil
+	}
+	w.last = now
+	var errs []error
+	for _, shader := range w.shaders {
+		paths := shader.paths()
+		modTimes := w.modTimes[shader]
+		modified := false
+		for _, path := range paths {
+			t := assetModTime(path)
+			if t.After(modTimes[path]) {
+				modTimes[path] = t
+				modified = true
+			}
+		}
+		if !modified {
+			continue
+		}
+		err := shader.Reload()
+		if err != nil {
+			// compile and link errors name their source and are kept as is
+			if _, ok := err.(*ShaderError); !ok {
+				err = fmt.Errorf("failed to reload shader `%s`: %v",
+					strings.Join(paths, "`, `"),
+					err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		// the reloaded sources may include new files
+		for _, path := range shader.paths() {
+			if _, ok := modTimes[path]; !ok {
+				modTimes[path] = assetModTime(path)
+			}
+		}
+		log.Infof("reloaded shader `%s`", strings.Join(paths, "`, `"))
+	}
+	return errs
+}
+
+func (s *Shader) paths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, source := range s.sources {
+		for _, path := range source.files {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
@@ -0,0 +1,122 @@
+package render_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/software"
+)
+
+// registerWatchedPorts registers a Go port for the current preprocessed
+// source of each vertex and fragment file.
+func registerWatchedPorts(t *testing.T, b *recordingBackend, vert string, frags ...string) {
+	source, err := render.DefaultPreprocessor.ProcessFile(vert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.RegisterVertexShader(source.Text, &software.VertexShader{
+		Main: func(uniforms *software.Uniforms, attributes []mgl32.Vec4, varyings []float32) mgl32.Vec4 {
+			return attributes[0]
+		},
+	})
+	for _, frag := range frags {
+		source, err := render.DefaultPreprocessor.ProcessFile(frag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.RegisterFragmentShader(source.Text, &software.FragmentShader{
+			Main: func(uniforms *software.Uniforms, varyings []float32) mgl32.Vec4 {
+				return mgl32.Vec4{1, 1, 1, 1}
+			},
+		})
+	}
+}
+
+func TestShaderWatcherReloadsSharedFiles(t *testing.T) {
+	b := newRecordingBackend(t)
+	modified := time.Unix(1000, 0)
+	fsys := render.MapFS{
+		"common.glsl": &render.MapFile{
+			Data:    []byte("const float scale = 1.0;\n"),
+			ModTime: modified,
+		},
+		"shared.vert": &render.MapFile{
+			Data:    []byte("#version 410\n#include \"common.glsl\"\nlayout(location=0) in vec3 aPosition;\nvoid main() {\n\tgl_Position = vec4(aPosition * scale, 1);\n}\n"),
+			ModTime: modified,
+		},
+		"a.frag": &render.MapFile{
+			Data:    []byte("#version 410\nout vec4 oColor;\nvoid main() {\n\toColor = vec4(1);\n}\n"),
+			ModTime: modified,
+		},
+		"b.frag": &render.MapFile{
+			Data:    []byte("#version 410\nout vec4 oColor;\nvoid main() {\n\toColor = vec4(0.5);\n}\n"),
+			ModTime: modified,
+		},
+	}
+	render.SetAssets(fsys)
+	defer render.SetAssets(render.Dir(""))
+	registerWatchedPorts(t, b, "shared.vert", "a.frag", "b.frag")
+	a, err := render.NewVertFragShader("shared.vert", "a.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Destroy()
+	c, err := render.NewVertFragShader("shared.vert", "b.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Destroy()
+	watcher := render.NewShaderWatcher(0)
+	watcher.Watch(a)
+	watcher.Watch(c)
+
+	tests := []struct {
+		name     string
+		file     string
+		expected []string
+	}{
+		{
+			name: "unmodified",
+		},
+		{
+			name: "shared include",
+			file: "common.glsl",
+			expected: []string{
+				"CreateProgram",
+				"CreateProgram",
+			},
+		},
+		{
+			name: "shared source",
+			file: "shared.vert",
+			expected: []string{
+				"CreateProgram",
+				"CreateProgram",
+			},
+		},
+		{
+			name: "own source",
+			file: "b.frag",
+			expected: []string{
+				"CreateProgram",
+			},
+		},
+	}
+	for _, test := range tests {
+		if test.file != "" {
+			modified = modified.Add(time.Second)
+			file := fsys[test.file]
+			file.Data = append(file.Data, []byte("// "+test.name+"\n")...)
+			file.ModTime = modified
+			registerWatchedPorts(t, b, "shared.vert", "a.frag", "b.frag")
+		}
+		b.reset()
+		for _, err := range watcher.Poll() {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		expectCalls(t, test.name, filterCalls(b.calls, "CreateProgram"), test.expected)
+	}
+}
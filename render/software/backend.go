@@ -0,0 +1,1005 @@
+package software
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+const (
+	maxSliceLen = 1 << 28
+)
+
+type shaderObject struct {
+	typ      uint32
+	vertex   *VertexShader
+	fragment *FragmentShader
+}
+
+type attribute struct {
+	enabled    bool
+	size       int32
+	typ        uint32
+	normalized bool
+	stride     int32
+	offset     int
+	buffer     uint32
+	divisor    uint32
+}
+
+type vertexArray struct {
+	attributes    map[uint32]*attribute
+	elementBuffer uint32
+}
+
+func newVertexArray() *vertexArray {
+	return &vertexArray{
+		attributes: make(map[uint32]*attribute),
+	}
+}
+
+func (v *vertexArray) attribute(index uint32) *attribute {
+	attr, ok := v.attributes[index]
+	if !ok {
+		attr = &attribute{}
+		v.attributes[index] = attr
+	}
+	return attr
+}
+
+type texture struct {
+	width   int
+	height  int
+	format  uint32
+	pix     []uint8
+	depth   []float32
+	stencil []uint8
+}
+
+func (t *texture) allocate(width int, height int, format uint32) {
+	t.width = width
+	t.height = height
+	t.format = format
+	t.pix = nil
+	t.depth = nil
+	t.stencil = nil
+	if isDepthFormat(format) {
+		t.depth = make([]float32, width*height)
+		if format == gl.DEPTH_STENCIL {
+			t.stencil = make([]uint8, width*height)
+		}
+	} else {
+		t.pix = make([]uint8, width*height*4)
+	}
+}
+
+type framebuffer struct {
+	attachments map[uint32]uint32
+}
+
+// Backend represents a CPU software rasterizer that implements the
+// render.Backend interface and renders into an image.RGBA. It requires no
+// OpenGL context.
+type Backend struct {
+	next uint32
+	// objects
+	shaders      map[uint32]*shaderObject
+	programs     map[uint32]*program
+	buffers      map[uint32][]byte
+	vertexArrays map[uint32]*vertexArray
+	textures     map[uint32]*texture
+	framebuffers map[uint32]*framebuffer
+	// bindings
+	program         *program
+	arrayBuffer     uint32
+	uniformBuffer   uint32
+	uniformBindings map[uint32]uint32
+	vertexArray     *vertexArray
+	activeUnit      uint32
+	units           map[uint32]uint32
+	drawFramebuffer uint32
+	readFramebuffer uint32
+	feedbackBuffer  uint32
+	// state
+	enables       map[uint32]bool
+	blendFunc     [4]uint32
+	blendEquation [2]uint32
+	blendColor    mgl32.Vec4
+	colorMask     [4]bool
+	cullFace      uint32
+	depthMask     bool
+	depthFunc     uint32
+	stencilFunc   uint32
+	stencilRef    int32
+	stencilMask   uint32
+	stencilOp     [3]uint32
+	stencilWrite  uint32
+	scissor       [4]int32
+	polygonOffset [2]float32
+	lineWidth     float32
+	polygonMode   uint32
+	viewport      [4]int32
+	clearColor    [4]float32
+	clearDepth    float32
+	clearStencil  int32
+	patchVertices int32
+	// transform feedback
+	feedbackActive bool
+	feedbackOffset int
+	// default framebuffer
+	color *texture
+	depth *texture
+	// registered shader ports
+	vertexShaders   map[string]*VertexShader
+	fragmentShaders map[string]*FragmentShader
+}
+
+// NewBackend instantiates and returns a new software backend with a default
+// framebuffer of the provided size.
+func NewBackend(width int, height int) *Backend {
+	b := &Backend{
+		next:            1,
+		shaders:         make(map[uint32]*shaderObject),
+		programs:        make(map[uint32]*program),
+		buffers:         make(map[uint32][]byte),
+		vertexArrays:    make(map[uint32]*vertexArray),
+		textures:        make(map[uint32]*texture),
+		framebuffers:    make(map[uint32]*framebuffer),
+		units:           make(map[uint32]uint32),
+		uniformBindings: make(map[uint32]uint32),
+		activeUnit:      gl.TEXTURE0,
+		enables:         make(map[uint32]bool),
+		blendFunc:       [4]uint32{gl.ONE, gl.ZERO, gl.ONE, gl.ZERO},
+		blendEquation:   [2]uint32{gl.FUNC_ADD, gl.FUNC_ADD},
+		colorMask:       [4]bool{true, true, true, true},
+		cullFace:        gl.BACK,
+		depthMask:       true,
+		depthFunc:       gl.LESS,
+		stencilFunc:     gl.ALWAYS,
+		stencilMask:     0xffffffff,
+		stencilOp:       [3]uint32{gl.KEEP, gl.KEEP, gl.KEEP},
+		stencilWrite:    0xffffffff,
+		scissor:         [4]int32{0, 0, int32(width), int32(height)},
+		lineWidth:       1,
+		clearDepth:      1,
+		polygonMode:     gl.FILL,
+		viewport:        [4]int32{0, 0, int32(width), int32(height)},
+		patchVertices:   3,
+		color:           &texture{},
+		depth:           &texture{},
+		vertexShaders:   make(map[string]*VertexShader),
+		fragmentShaders: make(map[string]*FragmentShader),
+	}
+	// vertex array zero holds the default element buffer binding
+	b.vertexArrays[0] = newVertexArray()
+	b.vertexArray = b.vertexArrays[0]
+	b.Resize(width, height)
+	return b
+}
+
+// Resize resizes the default framebuffer, clearing its contents.
+func (b *Backend) Resize(width int, height int) {
+	b.color.allocate(width, height, gl.RGBA)
+	b.depth.allocate(width, height, gl.DEPTH_STENCIL)
+}
+
+// Image returns a copy of the default framebuffer color buffer.
+func (b *Backend) Image() *image.RGBA {
+	return toImage(b.color)
+}
+
+// TextureImage returns a copy of the provided texture as an image.RGBA.
+func (b *Backend) TextureImage(t *render.Texture) (*image.RGBA, error) {
+	tex, ok := b.textures[t.ID()]
+	if !ok || tex.pix == nil {
+		return nil, fmt.Errorf("texture `%d` does not have a color image", t.ID())
+	}
+	return toImage(tex), nil
+}
+
+func (b *Backend) genName() uint32 {
+	id := b.next
+	b.next++
+	return id
+}
+
+// GetError always returns gl.NO_ERROR, calls are not validated.
+func (b *Backend) GetError() uint32 {
+	return gl.NO_ERROR
+}
+
+// GetString returns a string describing the software rasterizer.
+func (b *Backend) GetString(name uint32) string {
+	switch name {
+	case gl.VENDOR:
+		return "cauldron"
+	case gl.RENDERER:
+		return "software rasterizer"
+	case gl.VERSION:
+		return "4.1 software"
+	case gl.SHADING_LANGUAGE_VERSION:
+		return "4.10"
+	}
+	return ""
+}
+
+// Enable enables a server-side capability.
+func (b *Backend) Enable(state uint32) {
+	b.enables[state] = true
+}
+
+// Disable disables a server-side capability.
+func (b *Backend) Disable(state uint32) {
+	delete(b.enables, state)
+}
+
+// BlendFunc sets the pixel arithmetic.
+func (b *Backend) BlendFunc(sfactor uint32, dfactor uint32) {
+	b.blendFunc = [4]uint32{sfactor, dfactor, sfactor, dfactor}
+}
+
+// CullFace sets which faces are culled.
+func (b *Backend) CullFace(mode uint32) {
+	b.cullFace = mode
+}
+
+// DepthMask enables or disables writing into the depth buffer.
+func (b *Backend) DepthMask(flag bool) {
+	b.depthMask = flag
+}
+
+// DepthFunc sets the depth comparison function.
+func (b *Backend) DepthFunc(xfunc uint32) {
+	b.depthFunc = xfunc
+}
+
+// BlendFuncSeparate sets the pixel arithmetic for the RGB and alpha
+// components separately.
+func (b *Backend) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32, dstAlpha uint32) {
+	b.blendFunc = [4]uint32{srcRGB, dstRGB, srcAlpha, dstAlpha}
+}
+
+// BlendEquationSeparate sets the blend equations for the RGB and alpha
+// components.
+func (b *Backend) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
+	b.blendEquation = [2]uint32{modeRGB, modeAlpha}
+}
+
+// BlendColor sets the constant blend color.
+func (b *Backend) BlendColor(red float32, green float32, blue float32, alpha float32) {
+	b.blendColor = mgl32.Vec4{red, green, blue, alpha}
+}
+
+// ColorMask enables or disables writing of the color components.
+func (b *Backend) ColorMask(red bool, green bool, blue bool, alpha bool) {
+	b.colorMask = [4]bool{red, green, blue, alpha}
+}
+
+// StencilFunc sets the stencil test function and reference value.
+func (b *Backend) StencilFunc(xfunc uint32, ref int32, mask uint32) {
+	b.stencilFunc = xfunc
+	b.stencilRef = ref
+	b.stencilMask = mask
+}
+
+// StencilOp sets the stencil test actions.
+func (b *Backend) StencilOp(sfail uint32, dpfail uint32, dppass uint32) {
+	b.stencilOp = [3]uint32{sfail, dpfail, dppass}
+}
+
+// StencilMask sets which bits of the stencil buffer are written.
+func (b *Backend) StencilMask(mask uint32) {
+	b.stencilWrite = mask
+}
+
+// Scissor sets the scissor rectangle.
+func (b *Backend) Scissor(x int32, y int32, width int32, height int32) {
+	b.scissor = [4]int32{x, y, width, height}
+}
+
+// PolygonOffset sets the scale and units used to offset depth values.
+func (b *Backend) PolygonOffset(factor float32, units float32) {
+	b.polygonOffset = [2]float32{factor, units}
+}
+
+// LineWidth sets the width of rasterized lines.
+func (b *Backend) LineWidth(width float32) {
+	b.lineWidth = width
+}
+
+// PolygonMode sets how polygons are rasterized. Only front and back faces
+// together are supported, as in the core profile.
+func (b *Backend) PolygonMode(face uint32, mode uint32) {
+	b.polygonMode = mode
+}
+
+// Viewport sets the viewport.
+func (b *Backend) Viewport(x int32, y int32, width int32, height int32) {
+	b.viewport = [4]int32{x, y, width, height}
+}
+
+// ClearColor sets the clear values for the color buffers.
+func (b *Backend) ClearColor(red float32, green float32, blue float32, alpha float32) {
+	b.clearColor = [4]float32{red, green, blue, alpha}
+}
+
+// ClearDepth sets the clear value for the depth buffer.
+func (b *Backend) ClearDepth(depth float32) {
+	b.clearDepth = depth
+}
+
+// ClearStencil sets the clear value for the stencil buffer.
+func (b *Backend) ClearStencil(s int32) {
+	b.clearStencil = s
+}
+
+// Clear clears the provided buffers of the bound draw framebuffer. Clears
+// are limited to the scissor rectangle and respect the write masks.
+func (b *Backend) Clear(mask uint32) {
+	color, depth := b.drawTargets()
+	if mask&gl.COLOR_BUFFER_BIT != 0 && color != nil {
+		value := [4]uint8{
+			toUint8(b.clearColor[0]),
+			toUint8(b.clearColor[1]),
+			toUint8(b.clearColor[2]),
+			toUint8(b.clearColor[3]),
+		}
+		b.forEachCleared(color, func(index int) {
+			for i, write := range b.colorMask {
+				if write {
+					color.pix[index*4+i] = value[i]
+				}
+			}
+		})
+	}
+	if mask&gl.DEPTH_BUFFER_BIT != 0 && depth != nil && b.depthMask {
+		b.forEachCleared(depth, func(index int) {
+			depth.depth[index] = clamp(b.clearDepth)
+		})
+	}
+	if mask&gl.STENCIL_BUFFER_BIT != 0 && depth != nil && depth.stencil != nil {
+		write := uint8(b.stencilWrite)
+		value := uint8(b.clearStencil)
+		b.forEachCleared(depth, func(index int) {
+			depth.stencil[index] = depth.stencil[index]&^write | value&write
+		})
+	}
+}
+
+// forEachCleared calls the provided function with the index of every pixel
+// of the target inside the scissor rectangle.
+func (b *Backend) forEachCleared(target *texture, fn func(index int)) {
+	minX, minY, maxX, maxY := 0, 0, target.width, target.height
+	if b.enables[gl.SCISSOR_TEST] {
+		minX = maxInt(minX, int(b.scissor[0]))
+		minY = maxInt(minY, int(b.scissor[1]))
+		maxX = minInt(maxX, int(b.scissor[0]+b.scissor[2]))
+		maxY = minInt(maxY, int(b.scissor[1]+b.scissor[3]))
+	}
+	for y := minY; y < maxY; y++ {
+		for x := minX; x < maxX; x++ {
+			fn(y*target.width + x)
+		}
+	}
+}
+
+// CreateShader creates a shader object from a registered Go port matching
+// the provided source.
+func (b *Backend) CreateShader(typ uint32, source string) (uint32, error) {
+	obj := &shaderObject{
+		typ: typ,
+	}
+	switch typ {
+	case gl.VERTEX_SHADER:
+		obj.vertex = b.vertexShaders[sourceKey(source)]
+		if obj.vertex == nil {
+			return 0, fmt.Errorf("no vertex shader port registered for source")
+		}
+	case gl.FRAGMENT_SHADER:
+		obj.fragment = b.fragmentShaders[sourceKey(source)]
+		if obj.fragment == nil {
+			return 0, fmt.Errorf("no fragment shader port registered for source")
+		}
+	default:
+		return 0, fmt.Errorf("shader type `%d` is not supported", typ)
+	}
+	id := b.genName()
+	b.shaders[id] = obj
+	return id, nil
+}
+
+// DeleteShader deletes a shader object.
+func (b *Backend) DeleteShader(shader uint32) {
+	delete(b.shaders, shader)
+}
+
+// CreateProgram creates a program object.
+func (b *Backend) CreateProgram() uint32 {
+	id := b.genName()
+	b.programs[id] = &program{}
+	return id
+}
+
+// AttachShader attaches a shader object to a program object.
+func (b *Backend) AttachShader(program uint32, shader uint32) {
+	prog, ok := b.programs[program]
+	if !ok {
+		return
+	}
+	obj, ok := b.shaders[shader]
+	if !ok {
+		return
+	}
+	if obj.vertex != nil {
+		prog.vertex = obj.vertex
+	}
+	if obj.fragment != nil {
+		prog.fragment = obj.fragment
+	}
+}
+
+// DetachShader is a no-op, ports are resolved when attached and linked
+// programs keep them.
+func (b *Backend) DetachShader(program uint32, shader uint32) {
+}
+
+// TransformFeedbackVaryings specifies the outputs of the vertex shader port
+// captured by transform feedback. Only interleaved capture is supported.
+func (b *Backend) TransformFeedbackVaryings(program uint32, varyings []string, bufferMode uint32) {
+	prog, ok := b.programs[program]
+	if !ok {
+		return
+	}
+	prog.feedback = append([]string{}, varyings...)
+}
+
+// LinkProgram links a program object.
+func (b *Backend) LinkProgram(program uint32) error {
+	prog, ok := b.programs[program]
+	if !ok {
+		return fmt.Errorf("program `%d` does not exist", program)
+	}
+	return prog.link()
+}
+
+// GetProgramBinary always fails, program ports have no binary form.
+func (b *Backend) GetProgramBinary(program uint32) (uint32, []byte, error) {
+	return 0, nil, errors.New("program binaries are not supported by the software backend")
+}
+
+// ProgramBinary always fails, program ports have no binary form.
+func (b *Backend) ProgramBinary(program uint32, format uint32, binary []byte) error {
+	return errors.New("program binaries are not supported by the software backend")
+}
+
+// UseProgram installs a program object as part of the current rendering
+// state.
+func (b *Backend) UseProgram(program uint32) {
+	b.program = b.programs[program]
+}
+
+// DeleteProgram deletes a program object.
+func (b *Backend) DeleteProgram(program uint32) {
+	if b.program == b.programs[program] {
+		b.program = nil
+	}
+	delete(b.programs, program)
+}
+
+// ActiveAttributes returns descriptors for all attributes declared by the
+// program's vertex shader port.
+func (b *Backend) ActiveAttributes(program uint32) []*render.AttributeDescriptor {
+	prog, ok := b.programs[program]
+	if !ok || prog.vertex == nil {
+		return nil
+	}
+	descriptors := make([]*render.AttributeDescriptor, len(prog.vertex.Attributes))
+	for i, descriptor := range prog.vertex.Attributes {
+		d := descriptor
+		descriptors[i] = &d
+	}
+	return descriptors
+}
+
+// ActiveUniforms returns descriptors for all uniforms declared by the
+// program's shader ports.
+func (b *Backend) ActiveUniforms(program uint32) []*render.UniformDescriptor {
+	prog, ok := b.programs[program]
+	if !ok {
+		return nil
+	}
+	descriptors := make([]*render.UniformDescriptor, len(prog.descriptors))
+	for i, descriptor := range prog.descriptors {
+		d := descriptor
+		descriptors[i] = &d
+	}
+	return descriptors
+}
+
+// ActiveUniformBlocks returns descriptors for all uniform blocks declared by
+// the program's shader ports.
+func (b *Backend) ActiveUniformBlocks(program uint32) []*render.UniformBlockDescriptor {
+	prog, ok := b.programs[program]
+	if !ok {
+		return nil
+	}
+	descriptors := make([]*render.UniformBlockDescriptor, len(prog.blocks))
+	for i, block := range prog.blocks {
+		d := *block.descriptor
+		descriptors[i] = &d
+	}
+	return descriptors
+}
+
+// UniformBlockBinding assigns a binding point to an active uniform block.
+func (b *Backend) UniformBlockBinding(program uint32, index uint32, binding uint32) {
+	prog, ok := b.programs[program]
+	if !ok || int(index) >= len(prog.blocks) {
+		return
+	}
+	prog.blocks[index].binding = binding
+}
+
+// Uniform1i buffers a int32 by value.
+func (b *Backend) Uniform1i(location int32, value int32) {
+	b.setUniform(location, []float32{float32(value)})
+}
+
+// Uniform1ui buffers an uint32 by value.
+func (b *Backend) Uniform1ui(location int32, value uint32) {
+	b.setUniform(location, []float32{float32(value)})
+}
+
+// Uniform1f buffers a float32 by value.
+func (b *Backend) Uniform1f(location int32, value float32) {
+	b.setUniform(location, []float32{value})
+}
+
+// Uniform1iv buffers one or more int32 by address.
+func (b *Backend) Uniform1iv(location int32, count int32, value *int32) {
+	src := (*[maxSliceLen]int32)(unsafe.Pointer(value))[:count:count]
+	values := make([]float32, count)
+	for i, v := range src {
+		values[i] = float32(v)
+	}
+	b.setUniform(location, values)
+}
+
+// Uniform1uiv buffers one or more uint32 by address.
+func (b *Backend) Uniform1uiv(location int32, count int32, value *uint32) {
+	src := (*[maxSliceLen]uint32)(unsafe.Pointer(value))[:count:count]
+	values := make([]float32, count)
+	for i, v := range src {
+		values[i] = float32(v)
+	}
+	b.setUniform(location, values)
+}
+
+// Uniform1fv buffers one or more float32 by address.
+func (b *Backend) Uniform1fv(location int32, count int32, value *float32) {
+	b.setUniform(location, toFloat32s(value, int(count)))
+}
+
+// Uniform2fv buffers one or more 2-component float32 by address.
+func (b *Backend) Uniform2fv(location int32, count int32, value *float32) {
+	b.setUniform(location, toFloat32s(value, int(count)*2))
+}
+
+// Uniform3fv buffers one or more 3-component float32 by address.
+func (b *Backend) Uniform3fv(location int32, count int32, value *float32) {
+	b.setUniform(location, toFloat32s(value, int(count)*3))
+}
+
+// Uniform4fv buffers one or more 4-component float32 by address.
+func (b *Backend) Uniform4fv(location int32, count int32, value *float32) {
+	b.setUniform(location, toFloat32s(value, int(count)*4))
+}
+
+// UniformMatrix3fv buffers one or more 9-component float32 by address.
+func (b *Backend) UniformMatrix3fv(location int32, count int32, value *float32) {
+	b.setUniform(location, toFloat32s(value, int(count)*9))
+}
+
+// UniformMatrix4fv buffers one or more 16-component float32 by address.
+func (b *Backend) UniformMatrix4fv(location int32, count int32, value *float32) {
+	b.setUniform(location, toFloat32s(value, int(count)*16))
+}
+
+func (b *Backend) setUniform(location int32, values []float32) {
+	if b.program == nil {
+		return
+	}
+	b.program.set(location, values)
+}
+
+// CreateBuffer creates a buffer object.
+func (b *Backend) CreateBuffer() uint32 {
+	id := b.genName()
+	b.buffers[id] = nil
+	return id
+}
+
+// BindBuffer binds a buffer object to the provided target.
+func (b *Backend) BindBuffer(target uint32, buffer uint32) {
+	switch target {
+	case gl.ARRAY_BUFFER:
+		b.arrayBuffer = buffer
+	case gl.ELEMENT_ARRAY_BUFFER:
+		b.vertexArray.elementBuffer = buffer
+	case gl.UNIFORM_BUFFER:
+		b.uniformBuffer = buffer
+	case gl.TRANSFORM_FEEDBACK_BUFFER:
+		b.feedbackBuffer = buffer
+	}
+}
+
+// BindBufferBase binds a buffer object to an indexed binding point of the
+// provided target. Uniform buffer binding points and the first transform
+// feedback binding point are supported.
+func (b *Backend) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	switch target {
+	case gl.UNIFORM_BUFFER:
+		b.uniformBindings[index] = buffer
+		b.uniformBuffer = buffer
+	case gl.TRANSFORM_FEEDBACK_BUFFER:
+		if index == 0 {
+			b.feedbackBuffer = buffer
+		}
+	}
+}
+
+// BufferData creates and initializes the data store of the bound buffer.
+func (b *Backend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	id := b.boundBuffer(target)
+	if id == 0 {
+		return
+	}
+	store := make([]byte, size)
+	if data != nil {
+		copy(store, (*[maxSliceLen]byte)(data)[:size:size])
+	}
+	b.buffers[id] = store
+}
+
+// BufferSubData updates a portion of the data store of the bound buffer.
+func (b *Backend) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	id := b.boundBuffer(target)
+	if id == 0 || data == nil {
+		return
+	}
+	store := b.buffers[id]
+	if offset+size > len(store) {
+		return
+	}
+	copy(store[offset:offset+size], (*[maxSliceLen]byte)(data)[:size:size])
+}
+
+func (b *Backend) boundBuffer(target uint32) uint32 {
+	switch target {
+	case gl.ARRAY_BUFFER:
+		return b.arrayBuffer
+	case gl.ELEMENT_ARRAY_BUFFER:
+		return b.vertexArray.elementBuffer
+	case gl.UNIFORM_BUFFER:
+		return b.uniformBuffer
+	case gl.TRANSFORM_FEEDBACK_BUFFER:
+		return b.feedbackBuffer
+	}
+	return 0
+}
+
+// DeleteBuffer deletes a buffer object.
+func (b *Backend) DeleteBuffer(buffer uint32) {
+	delete(b.buffers, buffer)
+}
+
+// CreateVertexArray creates a vertex array object.
+func (b *Backend) CreateVertexArray() uint32 {
+	id := b.genName()
+	b.vertexArrays[id] = newVertexArray()
+	return id
+}
+
+// BindVertexArray binds a vertex array object.
+func (b *Backend) BindVertexArray(array uint32) {
+	vao, ok := b.vertexArrays[array]
+	if !ok {
+		vao = b.vertexArrays[0]
+	}
+	b.vertexArray = vao
+}
+
+// EnableVertexAttribArray enables a vertex attribute array.
+func (b *Backend) EnableVertexAttribArray(index uint32) {
+	b.vertexArray.attribute(index).enabled = true
+}
+
+// VertexAttribPointer defines an array of vertex attribute data sourced from
+// the bound array buffer.
+func (b *Backend) VertexAttribPointer(index uint32, size int32, typ uint32, normalized bool, stride int32, offset int) {
+	attr := b.vertexArray.attribute(index)
+	attr.size = size
+	attr.typ = typ
+	attr.normalized = normalized
+	attr.stride = stride
+	attr.offset = offset
+	attr.buffer = b.arrayBuffer
+}
+
+// VertexAttribDivisor sets the instancing divisor of a vertex attribute.
+func (b *Backend) VertexAttribDivisor(index uint32, divisor uint32) {
+	b.vertexArray.attribute(index).divisor = divisor
+}
+
+// DeleteVertexArray deletes a vertex array object.
+func (b *Backend) DeleteVertexArray(array uint32) {
+	if array == 0 {
+		return
+	}
+	if b.vertexArray == b.vertexArrays[array] {
+		b.vertexArray = b.vertexArrays[0]
+	}
+	delete(b.vertexArrays, array)
+}
+
+// CreateTexture creates a texture object.
+func (b *Backend) CreateTexture() uint32 {
+	id := b.genName()
+	b.textures[id] = &texture{}
+	return id
+}
+
+// ActiveTexture selects the active texture unit.
+func (b *Backend) ActiveTexture(unit uint32) {
+	b.activeUnit = unit
+}
+
+// BindTexture binds a texture object to the active texture unit.
+func (b *Backend) BindTexture(target uint32, texture uint32) {
+	if target != gl.TEXTURE_2D {
+		return
+	}
+	b.units[b.activeUnit] = texture
+}
+
+// TexParameteri is a no-op, textures are always sampled as nearest and
+// clamped to edge.
+func (b *Backend) TexParameteri(target uint32, pname uint32, param int32) {
+}
+
+// TexImage2D specifies a two-dimensional image for the bound texture. Only
+// RGBA unsigned byte data is copied, all other formats are allocated and
+// zero-filled. Color images hold a byte per component, so float formats are
+// clamped to [0, 1].
+func (b *Backend) TexImage2D(target uint32, level int32, internalFormat int32, width int32, height int32, format uint32, typ uint32, data unsafe.Pointer) {
+	if target != gl.TEXTURE_2D || level != 0 {
+		return
+	}
+	tex, ok := b.textures[b.units[b.activeUnit]]
+	if !ok {
+		return
+	}
+	tex.allocate(int(width), int(height), format)
+	if data != nil && format == gl.RGBA && typ == gl.UNSIGNED_BYTE {
+		size := len(tex.pix)
+		copy(tex.pix, (*[maxSliceLen]byte)(data)[:size:size])
+	}
+}
+
+// GenerateMipmap is a no-op, mipmaps are not supported.
+func (b *Backend) GenerateMipmap(target uint32) {
+}
+
+// DeleteTexture deletes a texture object.
+func (b *Backend) DeleteTexture(texture uint32) {
+	delete(b.textures, texture)
+}
+
+// CreateFramebuffer creates a framebuffer object.
+func (b *Backend) CreateFramebuffer() uint32 {
+	id := b.genName()
+	b.framebuffers[id] = &framebuffer{
+		attachments: make(map[uint32]uint32),
+	}
+	return id
+}
+
+// BindFramebuffer binds a framebuffer object to the provided target.
+func (b *Backend) BindFramebuffer(target uint32, framebuffer uint32) {
+	switch target {
+	case gl.FRAMEBUFFER:
+		b.drawFramebuffer = framebuffer
+		b.readFramebuffer = framebuffer
+	case gl.DRAW_FRAMEBUFFER:
+		b.drawFramebuffer = framebuffer
+	case gl.READ_FRAMEBUFFER:
+		b.readFramebuffer = framebuffer
+	}
+}
+
+// FramebufferTexture2D attaches a texture image to the bound framebuffer.
+func (b *Backend) FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	fbo, ok := b.framebuffers[b.boundFramebuffer(target)]
+	if !ok {
+		return
+	}
+	fbo.attachments[attachment] = texture
+}
+
+// CheckFramebufferStatus returns the completeness status of the bound
+// framebuffer.
+func (b *Backend) CheckFramebufferStatus(target uint32) uint32 {
+	id := b.boundFramebuffer(target)
+	if id == 0 {
+		return gl.FRAMEBUFFER_COMPLETE
+	}
+	fbo, ok := b.framebuffers[id]
+	if !ok || len(fbo.attachments) == 0 {
+		return gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT
+	}
+	width, height := -1, -1
+	for _, id := range fbo.attachments {
+		tex, ok := b.textures[id]
+		if !ok {
+			return gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT
+		}
+		if width == -1 {
+			width, height = tex.width, tex.height
+		}
+		if tex.width != width || tex.height != height {
+			return gl.FRAMEBUFFER_UNSUPPORTED
+		}
+	}
+	return gl.FRAMEBUFFER_COMPLETE
+}
+
+func (b *Backend) boundFramebuffer(target uint32) uint32 {
+	if target == gl.READ_FRAMEBUFFER {
+		return b.readFramebuffer
+	}
+	return b.drawFramebuffer
+}
+
+// DrawBuffers is a no-op, only the first color attachment is rendered to.
+func (b *Backend) DrawBuffers(buffers []uint32) {
+}
+
+// DeleteFramebuffer deletes a framebuffer object.
+func (b *Backend) DeleteFramebuffer(framebuffer uint32) {
+	if b.drawFramebuffer == framebuffer {
+		b.drawFramebuffer = 0
+	}
+	if b.readFramebuffer == framebuffer {
+		b.readFramebuffer = 0
+	}
+	delete(b.framebuffers, framebuffer)
+}
+
+// PatchParameteri sets a parameter of patch primitives. Tessellation is not
+// supported, so the parameters are only stored.
+func (b *Backend) PatchParameteri(pname uint32, value int32) {
+	if pname == gl.PATCH_VERTICES {
+		b.patchVertices = value
+	}
+}
+
+// BeginTransformFeedback starts capturing the outputs of the vertices drawn
+// into the buffer bound to the first transform feedback binding point.
+func (b *Backend) BeginTransformFeedback(primitiveMode uint32) {
+	b.feedbackActive = true
+	b.feedbackOffset = 0
+}
+
+// EndTransformFeedback stops capturing outputs.
+func (b *Backend) EndTransformFeedback() {
+	b.feedbackActive = false
+}
+
+// DrawArrays renders primitives from array data.
+func (b *Backend) DrawArrays(mode uint32, first int32, count int32) {
+	b.draw(mode, arrayIndices(first, count), 0)
+}
+
+// DrawArraysInstanced renders multiple instances of primitives from array
+// data.
+func (b *Backend) DrawArraysInstanced(mode uint32, first int32, count int32, primcount int32) {
+	b.draw(mode, arrayIndices(first, count), primcount)
+}
+
+// DrawElements renders primitives from indexed array data.
+func (b *Backend) DrawElements(mode uint32, count int32, typ uint32, byteOffset int) {
+	b.draw(mode, b.elementIndices(count, typ, byteOffset), 0)
+}
+
+// DrawElementsInstanced renders multiple instances of primitives from indexed
+// array data.
+func (b *Backend) DrawElementsInstanced(mode uint32, count int32, typ uint32, byteOffset int, primcount int32) {
+	b.draw(mode, b.elementIndices(count, typ, byteOffset), primcount)
+}
+
+// BeginTechnique is a no-op, markers do not affect rendering.
+func (b *Backend) BeginTechnique(marker *render.TechniqueMarker) {}
+
+// EndTechnique is a no-op, markers do not affect rendering.
+func (b *Backend) EndTechnique() {}
+
+// BeginCommand is a no-op, markers do not affect rendering.
+func (b *Backend) BeginCommand(marker *render.CommandMarker) {}
+
+// EndCommand is a no-op, markers do not affect rendering.
+func (b *Backend) EndCommand() {}
+
+func (b *Backend) drawTargets() (*texture, *texture) {
+	if b.drawFramebuffer == 0 {
+		return b.color, b.depth
+	}
+	fbo, ok := b.framebuffers[b.drawFramebuffer]
+	if !ok {
+		return nil, nil
+	}
+	color := b.textures[fbo.attachments[gl.COLOR_ATTACHMENT0]]
+	depth := b.textures[fbo.attachments[gl.DEPTH_ATTACHMENT]]
+	if depth == nil {
+		depth = b.textures[fbo.attachments[gl.DEPTH_STENCIL_ATTACHMENT]]
+	}
+	if color != nil && color.pix == nil {
+		color = nil
+	}
+	if depth != nil && depth.depth == nil {
+		depth = nil
+	}
+	return color, depth
+}
+
+func (b *Backend) elementIndices(count int32, typ uint32, byteOffset int) []uint32 {
+	data := b.buffers[b.vertexArray.elementBuffer]
+	indices := make([]uint32, 0, count)
+	for i := 0; i < int(count); i++ {
+		switch typ {
+		case gl.UNSIGNED_BYTE:
+			offset := byteOffset + i
+			if offset >= len(data) {
+				return indices
+			}
+			indices = append(indices, uint32(data[offset]))
+		case gl.UNSIGNED_SHORT:
+			offset := byteOffset + i*2
+			if offset+2 > len(data) {
+				return indices
+			}
+			indices = append(indices, uint32(readUint16(data[offset:])))
+		case gl.UNSIGNED_INT:
+			offset := byteOffset + i*4
+			if offset+4 > len(data) {
+				return indices
+			}
+			indices = append(indices, readUint32(data[offset:]))
+		}
+	}
+	return indices
+}
+
+func arrayIndices(first int32, count int32) []uint32 {
+	indices := make([]uint32, count)
+	for i := range indices {
+		indices[i] = uint32(first) + uint32(i)
+	}
+	return indices
+}
+
+func isDepthFormat(format uint32) bool {
+	return format == gl.DEPTH_COMPONENT ||
+		format == gl.DEPTH_STENCIL
+}
+
+func toFloat32s(value *float32, n int) []float32 {
+	values := make([]float32, n)
+	copy(values, (*[maxSliceLen]float32)(unsafe.Pointer(value))[:n:n])
+	return values
+}
@@ -0,0 +1,61 @@
+//go:build gl && linux
+// +build gl,linux
+
+package software_test
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/offscreen"
+	"github.com/kbirk/cauldron/render/software"
+)
+
+var (
+	update = flag.Bool("update", false, "rewrite the golden image from the GL backend")
+)
+
+func writeGolden(t *testing.T, img *image.RGBA) {
+	file, err := os.Create(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	err = png.Encode(file, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestGLMatchesGolden renders the default shaders with the GL backend to an
+// offscreen context. Run with `-tags gl -update` to rewrite the golden image.
+func TestGLMatchesGolden(t *testing.T) {
+	// the context is current on the locked thread only
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	context, err := offscreen.NewContext(size, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer context.Destroy()
+	err = render.InitContext(context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	render.SetBackend(&render.GLBackend{})
+	drawDefaultShaders(t)
+	img := context.Image()
+	if *update {
+		writeGolden(t, img)
+		return
+	}
+	golden := readGolden(t)
+	if !software.Equal(img, golden, tolerance) {
+		t.Errorf("GL rendering differs from %s by more than %d, run with -update to rewrite it", goldenFile, tolerance)
+	}
+}
@@ -0,0 +1,212 @@
+package software_test
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/software"
+	"github.com/kbirk/cauldron/shape"
+)
+
+const (
+	shaderDir = "../../resources/shaders"
+	// goldenFile is the scene rendered by the GL backend to an offscreen
+	// context, rewritten by `make golden`.
+	goldenFile = "testdata/default_shaders.png"
+	// tolerance allows for rounding differences between the GL and software
+	// blending of each channel.
+	tolerance = 2
+	size      = 64
+)
+
+func newRenderable(t *testing.T, positions []float32, indices []uint16, instances []float32, attributes []uint32, sizes []int32) *render.Renderable {
+	vertices := &render.VertexBuffer{}
+	err := vertices.BufferFloat32(positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements := &render.IndexBuffer{}
+	err = elements.BufferUint16(indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderable := &render.Renderable{}
+	renderable.SetVertexBuffer(vertices)
+	renderable.SetIndexBuffer(elements)
+	renderable.SetPointer(0, &render.AttributePointer{
+		Type: gl.FLOAT,
+		Size: 3,
+	})
+	renderable.SetDrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_SHORT, 0)
+	if len(instances) > 0 {
+		buffer := &render.VertexBuffer{}
+		err = buffer.BufferFloat32(instances)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stride := int32(0)
+		for _, size := range sizes {
+			stride += size
+		}
+		offset := 0
+		for i, location := range attributes {
+			renderable.SetPointer(location, &render.AttributePointer{
+				Type:       gl.FLOAT,
+				Size:       sizes[i],
+				ByteStride: stride * 4,
+				ByteOffset: offset * 4,
+				Buffer:     buffer,
+			})
+			offset += int(sizes[i])
+		}
+		renderable.SetInstancedAttributes(attributes)
+		renderable.SetDrawElementsInstanced(
+			gl.TRIANGLES,
+			int32(len(indices)),
+			gl.UNSIGNED_SHORT,
+			0,
+			int32(len(instances))/stride)
+	}
+	err = renderable.Upload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return renderable
+}
+
+func newTechnique(t *testing.T, name string, sfactor uint32, dfactor uint32, camera *render.UniformBuffer) *render.Technique {
+	shader, err := render.NewVertFragShader(
+		shaderDir+"/"+name+".vert",
+		shaderDir+"/"+name+".frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	technique := render.NewTechnique()
+	technique.Shader(shader)
+	technique.UniformBuffer(camera)
+	technique.Viewport(&render.Viewport{
+		Width:  size,
+		Height: size,
+	})
+	technique.Enable(gl.BLEND)
+	technique.BlendFunc(sfactor, dfactor)
+	return technique
+}
+
+func draw(t *testing.T, technique *render.Technique, renderable *render.Renderable, uniforms map[string]interface{}) {
+	command := &render.Command{}
+	for name, value := range uniforms {
+		command.Uniform(name, value)
+	}
+	command.Renderable(renderable)
+	err := technique.Draw([]*render.Command{command})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// drawDefaultShaders draws a scene using each of the default shaders with a
+// Go port to the default framebuffer of the current backend.
+func drawDefaultShaders(t *testing.T) {
+	camera := render.NewUniformBuffer(render.NewUniformBlockDescriptor("Camera", []render.UniformDescriptor{
+		{Name: "uProjection", Type: gl.FLOAT_MAT4, Count: 1},
+		{Name: "uView", Type: gl.FLOAT_MAT4, Count: 1},
+	}), 0)
+	err := camera.Set("uProjection", mgl32.Ortho(0, size, 0, size, -1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = camera.Set("uView", mgl32.Ident4())
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat := newTechnique(t, "flat", gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, camera)
+	flat.Clear(gl.COLOR_BUFFER_BIT, render.ClearOncePerFrame)
+	flat.ClearColor(0.1, 0.1, 0.1, 1)
+	particle := newTechnique(t, "particle", gl.SRC_ALPHA, gl.ONE, camera)
+	shockwave := newTechnique(t, "shockwave", gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, camera)
+
+	// overlapping opaque and translucent quads
+	positions, indices := shape.Quad(16, true, false)
+	quad := newRenderable(t, positions, indices, nil, nil, nil)
+	draw(t, flat, quad, map[string]interface{}{
+		"uModel": mgl32.Translate3D(16, 16, 0),
+		"uColor": mgl32.Vec4{0.8, 0.2, 0.2, 1},
+	})
+	draw(t, flat, quad, map[string]interface{}{
+		"uModel": mgl32.Translate3D(24, 24, 0),
+		"uColor": mgl32.Vec4{0.2, 0.6, 0.9, 0.5},
+	})
+
+	// a shockwave expanding from the top left
+	positions, indices = shape.Circle(1, 32, true, false)
+	circle := newRenderable(t, positions, indices,
+		[]float32{16, 48, 0},
+		[]uint32{1, 2},
+		[]int32{2, 1})
+	draw(t, shockwave, circle, map[string]interface{}{
+		"uModel": mgl32.Ident4(),
+		"uColor": mgl32.Vec4{1, 0.98, 0.96, 0.8},
+		"uForce": float32(40),
+		"uTime":  float32(0.1),
+	})
+
+	// additive particles of an explosion in the bottom right, each instance
+	// is an offset, velocity, size, origin and start time. The particle noise
+	// hashes the red and green of the color with sin, which is less precise
+	// on the GPU, so they are zero to keep the noise zero on both backends.
+	positions, indices = shape.Quad(4, true, false)
+	particles := newRenderable(t, positions, indices,
+		[]float32{
+			0, 0, 20, 10, 2, 44, 16, 0,
+			-4, 2, -10, 20, 1.5, 44, 16, 0,
+			3, -3, 5, -20, 1, 44, 16, 0,
+		},
+		[]uint32{1, 2, 3, 5, 6},
+		[]int32{2, 2, 1, 2, 1})
+	draw(t, particle, particles, map[string]interface{}{
+		"uModel":   mgl32.Ident4(),
+		"uColor":   mgl32.Vec4{0, 0, 0.9, 0.8},
+		"uGravity": mgl32.Vec2{0, -50},
+		"uTime":    float32(0.25),
+	})
+}
+
+func readGolden(t *testing.T) *image.RGBA {
+	file, err := os.Open(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			rgba.Set(x, y, img.At(x, y))
+		}
+	}
+	return rgba
+}
+
+func TestDefaultShadersMatchGolden(t *testing.T) {
+	backend := software.NewBackend(size, size)
+	err := backend.RegisterDefaultShaders(shaderDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	render.SetBackend(backend)
+	drawDefaultShaders(t)
+	golden := readGolden(t)
+	if !software.Equal(backend.Image(), golden, tolerance) {
+		t.Errorf("software rendering differs from %s by more than %d", goldenFile, tolerance)
+	}
+}
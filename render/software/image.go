@@ -0,0 +1,42 @@
+package software
+
+import (
+	"image"
+)
+
+// Equal returns true if the images are the same size and every channel of
+// every pixel differs by no more than the provided tolerance.
+func Equal(a *image.RGBA, b *image.RGBA, tolerance uint8) bool {
+	if a.Bounds().Size() != b.Bounds().Size() {
+		return false
+	}
+	size := a.Bounds().Size()
+	for y := 0; y < size.Y; y++ {
+		rowA := a.Pix[y*a.Stride : y*a.Stride+size.X*4]
+		rowB := b.Pix[y*b.Stride : y*b.Stride+size.X*4]
+		for i := range rowA {
+			if absDiff(rowA[i], rowB[i]) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func absDiff(a uint8, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func toImage(tex *texture) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, tex.width, tex.height))
+	stride := tex.width * 4
+	for y := 0; y < tex.height; y++ {
+		// textures are stored bottom-up
+		src := tex.pix[(tex.height-1-y)*stride : (tex.height-y)*stride]
+		copy(img.Pix[y*img.Stride:y*img.Stride+stride], src)
+	}
+	return img
+}
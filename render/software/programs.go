@@ -0,0 +1,349 @@
+package software
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// DefaultShader represents a default shader file, compiled with the provided
+// defines, and its Go port.
+type DefaultShader struct {
+	File     string
+	Defines  map[string]string
+	Vertex   *VertexShader
+	Fragment *FragmentShader
+}
+
+var (
+	// DefaultShaders lists the default shader files and permutations that
+	// have Go ports.
+	DefaultShaders = []DefaultShader{
+		{File: "flat.vert", Vertex: FlatVertex},
+		{File: "flat.frag", Fragment: FlatFragment},
+		{File: "particle.vert", Vertex: ParticleVertex},
+		{File: "particle.frag", Fragment: ParticleFragment},
+		{File: "particle.vert", Defines: smokeDefines, Vertex: SmokeVertex},
+		{File: "particle.frag", Defines: smokeDefines, Fragment: SmokeFragment},
+		{File: "particle.vert", Defines: simulatedDefines, Vertex: SimulatedParticleVertex},
+		{File: "particle.frag", Defines: simulatedDefines, Fragment: ParticleFragment},
+		{File: "particle_step.vert", Vertex: ParticleStepVertex},
+		{File: "shockwave.vert", Vertex: ShockwaveVertex},
+		{File: "shockwave.frag", Fragment: ShockwaveFragment},
+	}
+
+	smokeDefines = map[string]string{
+		"RISE": "",
+		"FADE": "",
+	}
+
+	simulatedDefines = map[string]string{
+		"SIMULATED": "",
+	}
+)
+
+var (
+	particleAttributes = []render.AttributeDescriptor{
+		{Name: "aPosition", Type: gl.FLOAT_VEC2, Count: 1, Location: 0},
+		{Name: "aOffset", Type: gl.FLOAT_VEC2, Count: 1, Location: 1},
+		{Name: "aVelocity", Type: gl.FLOAT_VEC2, Count: 1, Location: 2},
+		{Name: "aSize", Type: gl.FLOAT, Count: 1, Location: 3},
+		{Name: "aOrigin", Type: gl.FLOAT_VEC2, Count: 1, Location: 5},
+		{Name: "aStart", Type: gl.FLOAT, Count: 1, Location: 6},
+	}
+	mvpUniforms = []render.UniformDescriptor{
+		{Name: "uModel", Type: gl.FLOAT_MAT4, Count: 1},
+	}
+	cameraBlocks = []UniformBlock{
+		{
+			Name: "Camera",
+			Uniforms: []render.UniformDescriptor{
+				{Name: "uProjection", Type: gl.FLOAT_MAT4, Count: 1},
+				{Name: "uView", Type: gl.FLOAT_MAT4, Count: 1},
+			},
+		},
+	}
+)
+
+// FlatVertex is a port of flat.vert.
+var FlatVertex = &VertexShader{
+	Attributes: []render.AttributeDescriptor{
+		{Name: "aPosition", Type: gl.FLOAT_VEC3, Count: 1, Location: 0},
+	},
+	Uniforms: mvpUniforms,
+	Blocks:   cameraBlocks,
+	Main: func(u *Uniforms, a []mgl32.Vec4, out []float32) mgl32.Vec4 {
+		return mvp(u).Mul4x1(mgl32.Vec4{a[0][0], a[0][1], a[0][2], 1})
+	},
+}
+
+// FlatFragment is a port of flat.frag.
+var FlatFragment = &FragmentShader{
+	Uniforms: []render.UniformDescriptor{
+		{Name: "uColor", Type: gl.FLOAT_VEC4, Count: 1},
+	},
+	Main: func(u *Uniforms, in []float32) mgl32.Vec4 {
+		return u.Vec4("uColor")
+	},
+}
+
+// ParticleVertex is a port of particle.vert.
+var ParticleVertex = &VertexShader{
+	Attributes: particleAttributes,
+	Uniforms: append([]render.UniformDescriptor{
+		{Name: "uTime", Type: gl.FLOAT, Count: 1},
+		{Name: "uGravity", Type: gl.FLOAT_VEC2, Count: 1},
+	}, mvpUniforms...),
+	Blocks:   cameraBlocks,
+	Varyings: 1,
+	Main: func(u *Uniforms, a []mgl32.Vec4, out []float32) mgl32.Vec4 {
+		position := a[0].Vec2()
+		offset := a[1].Vec2()
+		velocity := a[2].Vec2()
+		aSize := a[3][0]
+		origin := a[5].Vec2()
+		t := u.Float("uTime") - a[6][0]
+		gravity := u.Vec2("uGravity")
+		displacement := velocity.Mul(t).Add(gravity.Mul(0.5 * aSize * (t * t)))
+		size := maxf(0, aSize-(aSize*t))
+		world := origin.Add(position.Mul(size)).Add(offset).Add(displacement)
+		out[0] = size / 4
+		return mvp(u).Mul4x1(mgl32.Vec4{world[0], world[1], 0, 1})
+	},
+}
+
+// ParticleFragment is a port of particle.frag.
+var ParticleFragment = &FragmentShader{
+	Uniforms: []render.UniformDescriptor{
+		{Name: "uColor", Type: gl.FLOAT_VEC4, Count: 1},
+	},
+	Varyings: 1,
+	Main: func(u *Uniforms, in []float32) mgl32.Vec4 {
+		color := u.Vec4("uColor")
+		size := in[0]
+		r := rand(mgl32.Vec2{color[0] * size, color[1] * size})
+		rgb := color.Vec3().Mul(size + r)
+		return rgb.Vec4(color[3])
+	},
+}
+
+// SmokeVertex is a port of particle.vert with RISE defined.
+var SmokeVertex = &VertexShader{
+	Attributes: particleAttributes,
+	Uniforms: append([]render.UniformDescriptor{
+		{Name: "uTime", Type: gl.FLOAT, Count: 1},
+		{Name: "uRise", Type: gl.FLOAT_VEC2, Count: 1},
+	}, mvpUniforms...),
+	Blocks:   cameraBlocks,
+	Varyings: 1,
+	Main: func(u *Uniforms, a []mgl32.Vec4, out []float32) mgl32.Vec4 {
+		position := a[0].Vec2()
+		offset := a[1].Vec2()
+		velocity := a[2].Vec2()
+		aSize := a[3][0]
+		origin := a[5].Vec2()
+		t := u.Float("uTime") - a[6][0]
+		rise := u.Vec2("uRise")
+		displacement := velocity.Mul(t).Add(rise.Mul(t * 0.2 * aSize))
+		size := aSize * 0.5 * t
+		world := origin.Add(position.Mul(size)).Add(offset).Add(displacement)
+		out[0] = size
+		return mvp(u).Mul4x1(mgl32.Vec4{world[0], world[1], 0, 1})
+	},
+}
+
+// SmokeFragment is a port of particle.frag with FADE defined.
+var SmokeFragment = &FragmentShader{
+	Uniforms: []render.UniformDescriptor{
+		{Name: "uColor", Type: gl.FLOAT_VEC4, Count: 1},
+	},
+	Varyings: 1,
+	Main: func(u *Uniforms, in []float32) mgl32.Vec4 {
+		color := u.Vec4("uColor")
+		size := in[0]
+		r := rand(mgl32.Vec2{color[0] * size, color[1] * size}) * 0.5
+		factor := minf(1.0, 0.2*size)
+		intensity := maxf(0.4, 1.0-factor)
+		alpha := maxf(0, 1.0-factor)
+		rgb := color.Vec3().Mul(intensity + r)
+		return rgb.Vec4(color[3] * alpha)
+	},
+}
+
+// SimulatedParticleVertex is a port of particle.vert with SIMULATED defined.
+var SimulatedParticleVertex = &VertexShader{
+	Attributes: []render.AttributeDescriptor{
+		{Name: "aPosition", Type: gl.FLOAT_VEC2, Count: 1, Location: 0},
+		{Name: "aOffset", Type: gl.FLOAT_VEC2, Count: 1, Location: 1},
+		{Name: "aSize", Type: gl.FLOAT, Count: 1, Location: 3},
+		{Name: "aAge", Type: gl.FLOAT, Count: 1, Location: 4},
+	},
+	Uniforms: append([]render.UniformDescriptor{
+		{Name: "uLifetime", Type: gl.FLOAT, Count: 1},
+	}, mvpUniforms...),
+	Blocks:   cameraBlocks,
+	Varyings: 1,
+	Main: func(u *Uniforms, a []mgl32.Vec4, out []float32) mgl32.Vec4 {
+		position := a[0].Vec2()
+		offset := a[1].Vec2()
+		aSize := a[3][0]
+		age := a[4][0]
+		size := float32(0)
+		if age > 0 {
+			size = maxf(0, aSize-(aSize*(age/u.Float("uLifetime"))))
+		}
+		world := position.Mul(size).Add(offset)
+		out[0] = size / 4
+		return mvp(u).Mul4x1(mgl32.Vec4{world[0], world[1], 0, 1})
+	},
+}
+
+// ParticleStepVertex is a port of particle_step.vert. Its outputs are
+// captured by transform feedback.
+var ParticleStepVertex = &VertexShader{
+	Attributes: []render.AttributeDescriptor{
+		{Name: "aPosition", Type: gl.FLOAT_VEC2, Count: 1, Location: 0},
+		{Name: "aVelocity", Type: gl.FLOAT_VEC2, Count: 1, Location: 1},
+		{Name: "aAge", Type: gl.FLOAT, Count: 1, Location: 2},
+		{Name: "aSize", Type: gl.FLOAT, Count: 1, Location: 3},
+	},
+	Uniforms: []render.UniformDescriptor{
+		{Name: "uDelta", Type: gl.FLOAT, Count: 1},
+		{Name: "uLifetime", Type: gl.FLOAT, Count: 1},
+		{Name: "uOrigin", Type: gl.FLOAT_VEC2, Count: 1},
+		{Name: "uSpeed", Type: gl.FLOAT, Count: 1},
+		{Name: "uSpread", Type: gl.FLOAT, Count: 1},
+		{Name: "uGravity", Type: gl.FLOAT_VEC2, Count: 1},
+		{Name: "uWind", Type: gl.FLOAT_VEC2, Count: 1},
+		{Name: "uDrag", Type: gl.FLOAT, Count: 1},
+	},
+	Varyings: 6,
+	Outputs: []Output{
+		{Name: "vPosition", Offset: 0, Components: 2},
+		{Name: "vVelocity", Offset: 2, Components: 2},
+		{Name: "vAge", Offset: 4, Components: 1},
+		{Name: "vSize", Offset: 5, Components: 1},
+	},
+	Main: func(u *Uniforms, a []mgl32.Vec4, out []float32) mgl32.Vec4 {
+		position := a[0].Vec2()
+		velocity := a[1].Vec2()
+		aAge := a[2][0]
+		aSize := a[3][0]
+		delta := u.Float("uDelta")
+		lifetime := u.Float("uLifetime")
+		age := aAge + delta
+		if age >= lifetime {
+			// respawn at the origin in a random direction within the spread
+			angle := 1.5707963 + (rand(velocity.Add(mgl32.Vec2{aAge, aSize}))-0.5)*u.Float("uSpread")
+			speed := (0.5 + 0.5*rand(position.Add(mgl32.Vec2{aSize, aSize}))) * u.Float("uSpeed")
+			position = u.Vec2("uOrigin")
+			velocity = mgl32.Vec2{cos(angle), sin(angle)}.Mul(speed)
+			age -= lifetime
+		} else if age > 0 {
+			acceleration := u.Vec2("uGravity").Mul(aSize).Add(u.Vec2("uWind")).Sub(velocity.Mul(u.Float("uDrag")))
+			velocity = velocity.Add(acceleration.Mul(delta))
+			position = position.Add(velocity.Mul(delta))
+		}
+		out[0], out[1] = position[0], position[1]
+		out[2], out[3] = velocity[0], velocity[1]
+		out[4] = age
+		out[5] = aSize
+		return mgl32.Vec4{0, 0, 0, 1}
+	},
+}
+
+// ShockwaveVertex is a port of shockwave.vert.
+var ShockwaveVertex = &VertexShader{
+	Attributes: []render.AttributeDescriptor{
+		{Name: "aPosition", Type: gl.FLOAT_VEC2, Count: 1, Location: 0},
+		{Name: "aOrigin", Type: gl.FLOAT_VEC2, Count: 1, Location: 1},
+		{Name: "aStart", Type: gl.FLOAT, Count: 1, Location: 2},
+	},
+	Uniforms: append([]render.UniformDescriptor{
+		{Name: "uForce", Type: gl.FLOAT, Count: 1},
+		{Name: "uTime", Type: gl.FLOAT, Count: 1},
+	}, mvpUniforms...),
+	Blocks:   cameraBlocks,
+	Varyings: 2,
+	Main: func(u *Uniforms, a []mgl32.Vec4, out []float32) mgl32.Vec4 {
+		position := a[0].Vec2()
+		force := u.Float("uForce")
+		t := u.Float("uTime") - a[2][0]
+		world := a[1].Vec2().Add(mgl32.Vec2{
+			position[0] * force * easeOut(t),
+			position[1] * force * easeOut(t) / 3,
+		})
+		out[0] = position.Len()
+		out[1] = t
+		return mvp(u).Mul4x1(mgl32.Vec4{world[0], world[1], 0, 1})
+	},
+}
+
+// ShockwaveFragment is a port of shockwave.frag.
+var ShockwaveFragment = &FragmentShader{
+	Uniforms: []render.UniformDescriptor{
+		{Name: "uColor", Type: gl.FLOAT_VEC4, Count: 1},
+	},
+	Varyings: 2,
+	Main: func(u *Uniforms, in []float32) mgl32.Vec4 {
+		color := u.Vec4("uColor")
+		t := in[1]
+		intensity := maxf(0, 1.0-(t/0.5))
+		opacity := cube(in[0])
+		rgb := color.Vec3().Mul(intensity)
+		return rgb.Vec4(color[3] * intensity * opacity)
+	},
+}
+
+func mvp(u *Uniforms) mgl32.Mat4 {
+	return u.Mat4("uProjection").Mul4(u.Mat4("uView")).Mul4(u.Mat4("uModel"))
+}
+
+func rand(co mgl32.Vec2) float32 {
+	return fract(sin(co.Dot(mgl32.Vec2{12.9898, 78.233})) * 43758.5453)
+}
+
+func easeOut(t float32) float32 {
+	t -= 1.0
+	return 1.0 + t*t*t*t*t
+}
+
+func cube(v float32) float32 {
+	return v * v * v
+}
+
+func sin(v float32) float32 {
+	return float32(math.Sin(float64(v)))
+}
+
+func cos(v float32) float32 {
+	return float32(math.Cos(float64(v)))
+}
+
+func fract(v float32) float32 {
+	return v - float32(math.Floor(float64(v)))
+}
+
+func minf(a float32, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxf(a float32, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func absf(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,653 @@
+package software
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	maxAttributes = 16
+)
+
+type vertex struct {
+	// window space position, w holds the reciprocal of clip space w
+	position mgl32.Vec4
+	varyings []float32
+}
+
+func (b *Backend) draw(mode uint32, indices []uint32, primcount int32) {
+	prog := b.program
+	if prog == nil || !prog.linked {
+		return
+	}
+	color, depth := b.drawTargets()
+	rasterizing := color != nil && prog.fragment != nil && !b.enables[gl.RASTERIZER_DISCARD]
+	if !rasterizing && !b.feedbackActive {
+		return
+	}
+	prog.readBlocks(b)
+	instances := int(primcount)
+	if instances < 1 {
+		instances = 1
+	}
+	for instance := 0; instance < instances; instance++ {
+		// run the vertex shader once per unique index
+		vertices := make(map[uint32]*vertex)
+		shade := func(index uint32) *vertex {
+			v, ok := vertices[index]
+			if !ok {
+				v = b.shadeVertex(prog, index, uint32(instance))
+				vertices[index] = v
+			}
+			return v
+		}
+		if b.feedbackActive {
+			b.capture(prog, mode, indices, shade)
+		}
+		if !rasterizing {
+			continue
+		}
+		// assemble and rasterize triangles
+		forEachTriangle(mode, len(indices), func(i0, i1, i2 int) {
+			b.rasterize(
+				prog,
+				color,
+				depth,
+				shade(indices[i0]),
+				shade(indices[i1]),
+				shade(indices[i2]))
+		})
+	}
+}
+
+// capture writes the captured outputs of every vertex of the assembled
+// primitives into the transform feedback buffer. Capture stops once the
+// buffer is full.
+func (b *Backend) capture(prog *program, mode uint32, indices []uint32, shade func(uint32) *vertex) {
+	data := b.buffers[b.feedbackBuffer]
+	write := func(v *vertex) {
+		for _, output := range prog.captured {
+			for i := 0; i < output.Components; i++ {
+				if b.feedbackOffset+4 > len(data) {
+					return
+				}
+				bits := math.Float32bits(v.varyings[output.Offset+i])
+				binary.LittleEndian.PutUint32(data[b.feedbackOffset:], bits)
+				b.feedbackOffset += 4
+			}
+		}
+	}
+	if mode == gl.POINTS {
+		for _, index := range indices {
+			write(shade(index))
+		}
+		return
+	}
+	forEachTriangle(mode, len(indices), func(i0, i1, i2 int) {
+		write(shade(indices[i0]))
+		write(shade(indices[i1]))
+		write(shade(indices[i2]))
+	})
+}
+
+func forEachTriangle(mode uint32, count int, fn func(i0, i1, i2 int)) {
+	switch mode {
+	case gl.TRIANGLES:
+		for i := 0; i+2 < count; i += 3 {
+			fn(i, i+1, i+2)
+		}
+	case gl.TRIANGLE_STRIP:
+		for i := 0; i+2 < count; i++ {
+			if i%2 == 0 {
+				fn(i, i+1, i+2)
+			} else {
+				fn(i+1, i, i+2)
+			}
+		}
+	case gl.TRIANGLE_FAN:
+		for i := 1; i+1 < count; i++ {
+			fn(0, i, i+1)
+		}
+	}
+}
+
+func (b *Backend) shadeVertex(prog *program, index uint32, instance uint32) *vertex {
+	// fetch attributes
+	attributes := make([]mgl32.Vec4, maxAttributes)
+	for i := range attributes {
+		attributes[i] = mgl32.Vec4{0, 0, 0, 1}
+	}
+	for location, attr := range b.vertexArray.attributes {
+		if int(location) >= len(attributes) || !attr.enabled {
+			continue
+		}
+		element := index
+		if attr.divisor > 0 {
+			element = instance / attr.divisor
+		}
+		b.fetchAttribute(attr, element, &attributes[location])
+	}
+	// run vertex shader
+	varyings := make([]float32, prog.vertex.Varyings)
+	clip := prog.vertex.Main(prog.uniforms, attributes, varyings)
+	// perspective divide and viewport transform
+	if clip[3] <= 0 {
+		return &vertex{
+			position: mgl32.Vec4{0, 0, 0, 0},
+			varyings: varyings,
+		}
+	}
+	invW := 1 / clip[3]
+	vx := float32(b.viewport[0])
+	vy := float32(b.viewport[1])
+	vw := float32(b.viewport[2])
+	vh := float32(b.viewport[3])
+	return &vertex{
+		position: mgl32.Vec4{
+			vx + (clip[0]*invW+1)*0.5*vw,
+			vy + (clip[1]*invW+1)*0.5*vh,
+			(clip[2]*invW + 1) * 0.5,
+			invW,
+		},
+		varyings: varyings,
+	}
+}
+
+func (b *Backend) fetchAttribute(attr *attribute, element uint32, out *mgl32.Vec4) {
+	data := b.buffers[attr.buffer]
+	size := typeSize(attr.typ)
+	stride := int(attr.stride)
+	if stride == 0 {
+		stride = int(attr.size) * size
+	}
+	offset := attr.offset + int(element)*stride
+	for i := 0; i < int(attr.size) && i < 4; i++ {
+		start := offset + i*size
+		if start+size > len(data) {
+			return
+		}
+		out[i] = readComponent(data[start:], attr.typ, attr.normalized)
+	}
+}
+
+func (b *Backend) rasterize(prog *program, color *texture, depth *texture, v0, v1, v2 *vertex) {
+	// triangles behind the eye are discarded rather than clipped
+	if v0.position[3] <= 0 || v1.position[3] <= 0 || v2.position[3] <= 0 {
+		return
+	}
+	p0, p1, p2 := v0.position, v1.position, v2.position
+	area := edge(p0, p1, p2[0], p2[1])
+	if area == 0 {
+		return
+	}
+	// cull faces, counter-clockwise is front facing
+	front := area > 0
+	if b.enables[gl.CULL_FACE] {
+		if b.cullFace == gl.FRONT_AND_BACK ||
+			(b.cullFace == gl.BACK && !front) ||
+			(b.cullFace == gl.FRONT && front) {
+			return
+		}
+	}
+	// ensure counter-clockwise winding
+	if !front {
+		v1, v2 = v2, v1
+		p1, p2 = p2, p1
+		area = -area
+	}
+	offset := b.depthOffset(p0, p1, p2, area)
+	switch b.polygonMode {
+	case gl.LINE:
+		b.rasterizeLine(prog, color, depth, v0, v1, offset)
+		b.rasterizeLine(prog, color, depth, v1, v2, offset)
+		b.rasterizeLine(prog, color, depth, v2, v0, offset)
+		return
+	case gl.POINT:
+		for _, v := range []*vertex{v0, v1, v2} {
+			x := int(floor(v.position[0]))
+			y := int(floor(v.position[1]))
+			b.fragment(prog, color, depth, x, y, v.position[2]+offset, v.varyings)
+		}
+		return
+	}
+	// bounding box clamped to the viewport and target
+	minX, minY, maxX, maxY := b.bounds(color)
+	minX = maxInt(int(floor(min3(p0[0], p1[0], p2[0]))), minX)
+	minY = maxInt(int(floor(min3(p0[1], p1[1], p2[1]))), minY)
+	maxX = minInt(int(ceil(max3(p0[0], p1[0], p2[0]))), maxX)
+	maxY = minInt(int(ceil(max3(p0[1], p1[1], p2[1]))), maxY)
+	// top-left fill rule
+	bias0 := topLeft(p1, p2)
+	bias1 := topLeft(p2, p0)
+	bias2 := topLeft(p0, p1)
+	varyings := make([]float32, prog.fragment.Varyings)
+	for y := minY; y < maxY; y++ {
+		for x := minX; x < maxX; x++ {
+			px := float32(x) + 0.5
+			py := float32(y) + 0.5
+			w0 := edge(p1, p2, px, py)
+			w1 := edge(p2, p0, px, py)
+			w2 := edge(p0, p1, px, py)
+			if !inside(w0, bias0) || !inside(w1, bias1) || !inside(w2, bias2) {
+				continue
+			}
+			l0 := w0 / area
+			l1 := w1 / area
+			l2 := w2 / area
+			z := l0*p0[2] + l1*p1[2] + l2*p2[2] + offset
+			// perspective correct interpolation
+			q0 := l0 * p0[3]
+			q1 := l1 * p1[3]
+			q2 := l2 * p2[3]
+			q := q0 + q1 + q2
+			for i := range varyings {
+				varyings[i] = (q0*v0.varyings[i] + q1*v1.varyings[i] + q2*v2.varyings[i]) / q
+			}
+			b.fragment(prog, color, depth, x, y, z, varyings)
+		}
+	}
+}
+
+// rasterizeLine rasterizes the edge between two vertices of a polygon drawn
+// with the line polygon mode, as a square brush of the line width.
+func (b *Backend) rasterizeLine(prog *program, color *texture, depth *texture, v0, v1 *vertex, offset float32) {
+	p0, p1 := v0.position, v1.position
+	steps := int(ceil(maxf(absf(p1[0]-p0[0]), absf(p1[1]-p0[1]))))
+	xMajor := absf(p1[0]-p0[0]) > absf(p1[1]-p0[1])
+	yMajor := absf(p1[1]-p0[1]) > absf(p1[0]-p0[0])
+	if steps < 1 {
+		steps = 1
+	}
+	width := int(b.lineWidth + 0.5)
+	if width < 1 {
+		width = 1
+	}
+	varyings := make([]float32, prog.fragment.Varyings)
+	for step := 0; step < steps; step++ {
+		t := float32(step) / float32(steps)
+		// perspective correct interpolation
+		q0 := (1 - t) * p0[3]
+		q1 := t * p1[3]
+		q := q0 + q1
+		for i := range varyings {
+			varyings[i] = (q0*v0.varyings[i] + q1*v1.varyings[i]) / q
+		}
+		// snap to sub-pixel precision so lines on pixel boundaries are stable
+		x := snap(p0[0] + (p1[0]-p0[0])*t)
+		y := snap(p0[1] + (p1[1]-p0[1])*t)
+		z := p0[2] + (p1[2]-p0[2])*t + offset
+		minX := int(floor(x - float32(width)*0.5 + 0.5))
+		minY := int(floor(y - float32(width)*0.5 + 0.5))
+		// lines on a pixel boundary cover the pixel below or to their left
+		if xMajor {
+			minY = int(ceil(y-float32(width)*0.5+0.5)) - 1
+		} else if yMajor {
+			minX = int(ceil(x-float32(width)*0.5+0.5)) - 1
+		}
+		for dy := 0; dy < width; dy++ {
+			for dx := 0; dx < width; dx++ {
+				b.fragment(prog, color, depth, minX+dx, minY+dy, z, varyings)
+			}
+		}
+	}
+}
+
+// bounds returns the rectangle of the target fragments may be written to,
+// the intersection of the viewport, the scissor rectangle and the target.
+func (b *Backend) bounds(color *texture) (int, int, int, int) {
+	minX := maxInt(int(b.viewport[0]), 0)
+	minY := maxInt(int(b.viewport[1]), 0)
+	maxX := minInt(int(b.viewport[0]+b.viewport[2]), color.width)
+	maxY := minInt(int(b.viewport[1]+b.viewport[3]), color.height)
+	if b.enables[gl.SCISSOR_TEST] {
+		minX = maxInt(minX, int(b.scissor[0]))
+		minY = maxInt(minY, int(b.scissor[1]))
+		maxX = minInt(maxX, int(b.scissor[0]+b.scissor[2]))
+		maxY = minInt(maxY, int(b.scissor[1]+b.scissor[3]))
+	}
+	return minX, minY, maxX, maxY
+}
+
+// depthOffset returns the polygon offset of a triangle if it is enabled for
+// the polygon mode.
+func (b *Backend) depthOffset(p0, p1, p2 mgl32.Vec4, area float32) float32 {
+	var enabled bool
+	switch b.polygonMode {
+	case gl.LINE:
+		enabled = b.enables[gl.POLYGON_OFFSET_LINE]
+	case gl.POINT:
+		enabled = b.enables[gl.POLYGON_OFFSET_POINT]
+	default:
+		enabled = b.enables[gl.POLYGON_OFFSET_FILL]
+	}
+	if !enabled {
+		return 0
+	}
+	// maximum depth slope of the triangle
+	dzdx := ((p1[2]-p0[2])*(p2[1]-p0[1]) - (p2[2]-p0[2])*(p1[1]-p0[1])) / area
+	dzdy := ((p2[2]-p0[2])*(p1[0]-p0[0]) - (p1[2]-p0[2])*(p2[0]-p0[0])) / area
+	slope := maxf(absf(dzdx), absf(dzdy))
+	// the smallest resolvable difference of a 24 bit depth buffer
+	const r = 1.0 / (1 << 24)
+	return b.polygonOffset[0]*slope + b.polygonOffset[1]*r
+}
+
+// fragment shades and writes a single fragment after the scissor, stencil
+// and depth tests.
+func (b *Backend) fragment(prog *program, color *texture, depth *texture, x, y int, z float32, varyings []float32) {
+	minX, minY, maxX, maxY := b.bounds(color)
+	if x < minX || y < minY || x >= maxX || y >= maxY {
+		return
+	}
+	index := y*color.width + x
+	if depth != nil && index >= len(depth.depth) {
+		depth = nil
+	}
+	// stencil test
+	stencil := depth != nil && depth.stencil != nil && b.enables[gl.STENCIL_TEST]
+	if stencil && !b.stencilTest(depth.stencil[index]) {
+		b.updateStencil(depth, index, b.stencilOp[0])
+		return
+	}
+	// depth test
+	if depth != nil && b.enables[gl.DEPTH_TEST] {
+		if !compareDepth(b.depthFunc, z, depth.depth[index]) {
+			if stencil {
+				b.updateStencil(depth, index, b.stencilOp[1])
+			}
+			return
+		}
+		if b.depthMask {
+			depth.depth[index] = z
+		}
+	}
+	if stencil {
+		b.updateStencil(depth, index, b.stencilOp[2])
+	}
+	// run fragment shader
+	frag := prog.fragment.Main(prog.uniforms, varyings)
+	b.writeFragment(color, index, frag)
+}
+
+func (b *Backend) stencilTest(value uint8) bool {
+	mask := uint8(b.stencilMask)
+	ref := uint8(b.stencilRef) & mask
+	current := value & mask
+	switch b.stencilFunc {
+	case gl.NEVER:
+		return false
+	case gl.LESS:
+		return ref < current
+	case gl.LEQUAL:
+		return ref <= current
+	case gl.GREATER:
+		return ref > current
+	case gl.GEQUAL:
+		return ref >= current
+	case gl.EQUAL:
+		return ref == current
+	case gl.NOTEQUAL:
+		return ref != current
+	}
+	return true
+}
+
+func (b *Backend) updateStencil(depth *texture, index int, op uint32) {
+	current := depth.stencil[index]
+	value := current
+	switch op {
+	case gl.ZERO:
+		value = 0
+	case gl.REPLACE:
+		value = uint8(b.stencilRef)
+	case gl.INCR:
+		if value < 0xff {
+			value++
+		}
+	case gl.INCR_WRAP:
+		value++
+	case gl.DECR:
+		if value > 0 {
+			value--
+		}
+	case gl.DECR_WRAP:
+		value--
+	case gl.INVERT:
+		value = ^value
+	}
+	write := uint8(b.stencilWrite)
+	depth.stencil[index] = current&^write | value&write
+}
+
+func (b *Backend) writeFragment(color *texture, index int, frag mgl32.Vec4) {
+	// fixed point color buffers clamp fragment colors
+	for i := range frag {
+		frag[i] = clamp(frag[i])
+	}
+	pix := color.pix[index*4 : index*4+4]
+	if b.enables[gl.BLEND] {
+		dst := mgl32.Vec4{
+			float32(pix[0]) / 255,
+			float32(pix[1]) / 255,
+			float32(pix[2]) / 255,
+			float32(pix[3]) / 255,
+		}
+		srcRGB := blendFactor(b.blendFunc[0], frag, dst, b.blendColor)
+		dstRGB := blendFactor(b.blendFunc[1], frag, dst, b.blendColor)
+		srcAlpha := blendFactor(b.blendFunc[2], frag, dst, b.blendColor)
+		dstAlpha := blendFactor(b.blendFunc[3], frag, dst, b.blendColor)
+		for i := 0; i < 3; i++ {
+			frag[i] = clamp(blendEquation(b.blendEquation[0], frag[i], dst[i], srcRGB[i], dstRGB[i]))
+		}
+		frag[3] = clamp(blendEquation(b.blendEquation[1], frag[3], dst[3], srcAlpha[3], dstAlpha[3]))
+	}
+	for i, write := range b.colorMask {
+		if write {
+			pix[i] = toUint8(frag[i])
+		}
+	}
+}
+
+func blendEquation(mode uint32, src float32, dst float32, sf float32, df float32) float32 {
+	switch mode {
+	case gl.FUNC_SUBTRACT:
+		return src*sf - dst*df
+	case gl.FUNC_REVERSE_SUBTRACT:
+		return dst*df - src*sf
+	case gl.MIN:
+		return minf(src, dst)
+	case gl.MAX:
+		return maxf(src, dst)
+	}
+	return src*sf + dst*df
+}
+
+func blendFactor(factor uint32, src mgl32.Vec4, dst mgl32.Vec4, constant mgl32.Vec4) mgl32.Vec4 {
+	switch factor {
+	case gl.ZERO:
+		return mgl32.Vec4{0, 0, 0, 0}
+	case gl.ONE:
+		return mgl32.Vec4{1, 1, 1, 1}
+	case gl.SRC_COLOR:
+		return src
+	case gl.ONE_MINUS_SRC_COLOR:
+		return mgl32.Vec4{1 - src[0], 1 - src[1], 1 - src[2], 1 - src[3]}
+	case gl.DST_COLOR:
+		return dst
+	case gl.ONE_MINUS_DST_COLOR:
+		return mgl32.Vec4{1 - dst[0], 1 - dst[1], 1 - dst[2], 1 - dst[3]}
+	case gl.SRC_ALPHA:
+		return mgl32.Vec4{src[3], src[3], src[3], src[3]}
+	case gl.ONE_MINUS_SRC_ALPHA:
+		a := 1 - src[3]
+		return mgl32.Vec4{a, a, a, a}
+	case gl.DST_ALPHA:
+		return mgl32.Vec4{dst[3], dst[3], dst[3], dst[3]}
+	case gl.ONE_MINUS_DST_ALPHA:
+		a := 1 - dst[3]
+		return mgl32.Vec4{a, a, a, a}
+	case gl.CONSTANT_COLOR:
+		return constant
+	case gl.ONE_MINUS_CONSTANT_COLOR:
+		return mgl32.Vec4{1 - constant[0], 1 - constant[1], 1 - constant[2], 1 - constant[3]}
+	case gl.CONSTANT_ALPHA:
+		return mgl32.Vec4{constant[3], constant[3], constant[3], constant[3]}
+	case gl.ONE_MINUS_CONSTANT_ALPHA:
+		a := 1 - constant[3]
+		return mgl32.Vec4{a, a, a, a}
+	}
+	return mgl32.Vec4{1, 1, 1, 1}
+}
+
+func compareDepth(xfunc uint32, z float32, current float32) bool {
+	switch xfunc {
+	case gl.NEVER:
+		return false
+	case gl.LESS:
+		return z < current
+	case gl.EQUAL:
+		return z == current
+	case gl.LEQUAL:
+		return z <= current
+	case gl.GREATER:
+		return z > current
+	case gl.NOTEQUAL:
+		return z != current
+	case gl.GEQUAL:
+		return z >= current
+	}
+	return true
+}
+
+func snap(v float32) float32 {
+	return floor(v*256+0.5) / 256
+}
+
+func edge(a mgl32.Vec4, b mgl32.Vec4, x float32, y float32) float32 {
+	return (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+}
+
+func topLeft(a mgl32.Vec4, b mgl32.Vec4) bool {
+	// for counter-clockwise triangles with y up, left edges point down and
+	// top edges point left
+	return b[1] < a[1] || (b[1] == a[1] && b[0] < a[0])
+}
+
+func inside(w float32, topLeft bool) bool {
+	return w > 0 || (w == 0 && topLeft)
+}
+
+func typeSize(typ uint32) int {
+	switch typ {
+	case gl.BYTE, gl.UNSIGNED_BYTE:
+		return 1
+	case gl.SHORT, gl.UNSIGNED_SHORT, gl.HALF_FLOAT:
+		return 2
+	}
+	return 4
+}
+
+func readComponent(data []byte, typ uint32, normalized bool) float32 {
+	switch typ {
+	case gl.FLOAT:
+		return math.Float32frombits(readUint32(data))
+	case gl.BYTE:
+		v := float32(int8(data[0]))
+		if normalized {
+			return maxf(v/127, -1)
+		}
+		return v
+	case gl.UNSIGNED_BYTE:
+		v := float32(data[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case gl.SHORT:
+		v := float32(int16(readUint16(data)))
+		if normalized {
+			return maxf(v/32767, -1)
+		}
+		return v
+	case gl.UNSIGNED_SHORT:
+		v := float32(readUint16(data))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case gl.INT:
+		v := float32(int32(readUint32(data)))
+		if normalized {
+			return maxf(v/2147483647, -1)
+		}
+		return v
+	case gl.UNSIGNED_INT:
+		v := float32(readUint32(data))
+		if normalized {
+			return v / 4294967295
+		}
+		return v
+	}
+	return 0
+}
+
+func readUint16(data []byte) uint16 {
+	return binary.LittleEndian.Uint16(data)
+}
+
+func readUint32(data []byte) uint32 {
+	return binary.LittleEndian.Uint32(data)
+}
+
+func clamp(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func toUint8(v float32) uint8 {
+	return uint8(clamp(v)*255 + 0.5)
+}
+
+func floor(v float32) float32 {
+	return float32(math.Floor(float64(v)))
+}
+
+func ceil(v float32) float32 {
+	return float32(math.Ceil(float64(v)))
+}
+
+func min3(a float32, b float32, c float32) float32 {
+	return minf(a, minf(b, c))
+}
+
+func max3(a float32, b float32, c float32) float32 {
+	return maxf(a, maxf(b, c))
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func maxInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
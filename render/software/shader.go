@@ -0,0 +1,323 @@
+package software
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// UniformBlock declares a `layout(std140)` uniform block read by a shader
+// port. The uniforms of the block are read by name like any other uniform.
+type UniformBlock struct {
+	Name     string
+	Uniforms []render.UniformDescriptor
+}
+
+// VertexShader represents a Go port of a GLSL vertex shader.
+type VertexShader struct {
+	// Attributes declares the vertex attributes read by the shader.
+	Attributes []render.AttributeDescriptor
+	// Uniforms declares the uniforms read by the shader.
+	Uniforms []render.UniformDescriptor
+	// Blocks declares the uniform blocks read by the shader.
+	Blocks []UniformBlock
+	// Varyings is the number of float components written to the varyings.
+	Varyings int
+	// Outputs declares the named outputs transform feedback can capture.
+	Outputs []Output
+	// Main is invoked once per vertex with the attributes indexed by location
+	// and returns the clip space position.
+	Main func(uniforms *Uniforms, attributes []mgl32.Vec4, varyings []float32) mgl32.Vec4
+}
+
+// Output declares a named vertex shader output as a range of the varyings.
+type Output struct {
+	Name       string
+	Offset     int
+	Components int
+}
+
+// FragmentShader represents a Go port of a GLSL fragment shader.
+type FragmentShader struct {
+	// Uniforms declares the uniforms read by the shader.
+	Uniforms []render.UniformDescriptor
+	// Blocks declares the uniform blocks read by the shader.
+	Blocks []UniformBlock
+	// Varyings is the number of float components read from the varyings.
+	Varyings int
+	// Main is invoked once per fragment with the interpolated varyings and
+	// returns the output color.
+	Main func(uniforms *Uniforms, varyings []float32) mgl32.Vec4
+}
+
+// Uniforms represents the uniform values of a linked program.
+type Uniforms struct {
+	values map[string][]float32
+}
+
+func (u *Uniforms) get(name string, n int) []float32 {
+	values := u.values[name]
+	if len(values) < n {
+		padded := make([]float32, n)
+		copy(padded, values)
+		return padded
+	}
+	return values
+}
+
+// Float returns the value of a float uniform.
+func (u *Uniforms) Float(name string) float32 {
+	return u.get(name, 1)[0]
+}
+
+// Int returns the value of an int or sampler uniform.
+func (u *Uniforms) Int(name string) int32 {
+	return int32(u.get(name, 1)[0])
+}
+
+// Vec2 returns the value of a vec2 uniform.
+func (u *Uniforms) Vec2(name string) mgl32.Vec2 {
+	v := u.get(name, 2)
+	return mgl32.Vec2{v[0], v[1]}
+}
+
+// Vec3 returns the value of a vec3 uniform.
+func (u *Uniforms) Vec3(name string) mgl32.Vec3 {
+	v := u.get(name, 3)
+	return mgl32.Vec3{v[0], v[1], v[2]}
+}
+
+// Vec4 returns the value of a vec4 uniform.
+func (u *Uniforms) Vec4(name string) mgl32.Vec4 {
+	v := u.get(name, 4)
+	return mgl32.Vec4{v[0], v[1], v[2], v[3]}
+}
+
+// Mat3 returns the value of a mat3 uniform.
+func (u *Uniforms) Mat3(name string) mgl32.Mat3 {
+	var m mgl32.Mat3
+	copy(m[:], u.get(name, 9))
+	return m
+}
+
+// Mat4 returns the value of a mat4 uniform.
+func (u *Uniforms) Mat4(name string) mgl32.Mat4 {
+	var m mgl32.Mat4
+	copy(m[:], u.get(name, 16))
+	return m
+}
+
+type program struct {
+	vertex      *VertexShader
+	fragment    *FragmentShader
+	linked      bool
+	descriptors []render.UniformDescriptor
+	names       map[int32]string
+	blocks      []*programBlock
+	uniforms    *Uniforms
+	// transform feedback
+	feedback []string
+	captured []Output
+}
+
+type programBlock struct {
+	descriptor *render.UniformBlockDescriptor
+	uniforms   []render.UniformDescriptor
+	binding    uint32
+}
+
+func (p *program) link() error {
+	p.linked = false
+	if p.vertex == nil {
+		return fmt.Errorf("program has no vertex shader attached")
+	}
+	// programs capturing varyings may only run the vertex shader
+	fragment := p.fragment
+	if fragment == nil {
+		if len(p.feedback) == 0 {
+			return fmt.Errorf("program has no fragment shader attached")
+		}
+		fragment = &FragmentShader{}
+	}
+	if p.vertex.Varyings < fragment.Varyings {
+		return fmt.Errorf("fragment shader reads %d varying components but vertex shader only writes %d",
+			fragment.Varyings,
+			p.vertex.Varyings)
+	}
+	// resolve the captured outputs
+	p.captured = nil
+	for _, name := range p.feedback {
+		output, ok := p.output(name)
+		if !ok {
+			return fmt.Errorf("varying `%s` is not an output of the vertex shader", name)
+		}
+		p.captured = append(p.captured, output)
+	}
+	// merge uniform declarations and assign locations
+	p.descriptors = make([]render.UniformDescriptor, 0)
+	p.names = make(map[int32]string)
+	seen := make(map[string]render.UniformDescriptor)
+	declared := append(append([]render.UniformDescriptor{}, p.vertex.Uniforms...), fragment.Uniforms...)
+	for _, descriptor := range declared {
+		prev, ok := seen[descriptor.Name]
+		if ok {
+			if prev.Type != descriptor.Type || prev.Count != descriptor.Count {
+				return fmt.Errorf("uniform `%s` is declared with mismatched types", descriptor.Name)
+			}
+			continue
+		}
+		if descriptor.Count == 0 {
+			descriptor.Count = 1
+		}
+		descriptor.Location = int32(len(p.descriptors))
+		seen[descriptor.Name] = descriptor
+		p.names[descriptor.Location] = descriptor.Name
+		p.descriptors = append(p.descriptors, descriptor)
+	}
+	// merge uniform block declarations and assign indices
+	p.blocks = nil
+	blocks := make(map[string]*programBlock)
+	for _, block := range append(append([]UniformBlock{}, p.vertex.Blocks...), fragment.Blocks...) {
+		if _, ok := blocks[block.Name]; ok {
+			continue
+		}
+		descriptor := render.NewUniformBlockDescriptor(block.Name, block.Uniforms)
+		descriptor.Index = uint32(len(p.blocks))
+		blocks[block.Name] = &programBlock{
+			descriptor: descriptor,
+			uniforms:   block.Uniforms,
+		}
+		p.blocks = append(p.blocks, blocks[block.Name])
+	}
+	p.uniforms = &Uniforms{
+		values: make(map[string][]float32),
+	}
+	p.linked = true
+	return nil
+}
+
+func (p *program) output(name string) (Output, bool) {
+	for _, output := range p.vertex.Outputs {
+		if output.Name == name {
+			return output, true
+		}
+	}
+	return Output{}, false
+}
+
+// readBlocks reads the uniforms of every block from the buffers bound to the
+// binding points of the blocks.
+func (p *program) readBlocks(b *Backend) {
+	for _, block := range p.blocks {
+		data := b.buffers[b.uniformBindings[block.binding]]
+		for _, uniform := range block.uniforms {
+			offset, ok := block.descriptor.Offsets[uniform.Name]
+			if !ok {
+				offset = block.descriptor.Offsets[uniform.Name+"[0]"]
+			}
+			p.uniforms.values[uniform.Name] = readStd140(data, int(offset), uniform)
+		}
+	}
+}
+
+// readStd140 reads the components of a uniform packed with the std140 rules,
+// array elements and matrix columns are padded to a vec4 stride.
+func readStd140(data []byte, offset int, uniform render.UniformDescriptor) []float32 {
+	columns, rows := 1, 1
+	switch uniform.Type {
+	case gl.FLOAT_VEC2, gl.INT_VEC2, gl.UNSIGNED_INT_VEC2, gl.BOOL_VEC2:
+		rows = 2
+	case gl.FLOAT_VEC3, gl.INT_VEC3, gl.UNSIGNED_INT_VEC3, gl.BOOL_VEC3:
+		rows = 3
+	case gl.FLOAT_VEC4, gl.INT_VEC4, gl.UNSIGNED_INT_VEC4, gl.BOOL_VEC4:
+		rows = 4
+	case gl.FLOAT_MAT3:
+		columns, rows = 3, 3
+	case gl.FLOAT_MAT4:
+		columns, rows = 4, 4
+	}
+	count := int(uniform.Count)
+	if count < 1 {
+		count = 1
+	}
+	stride := 4 * rows
+	if count > 1 || columns > 1 {
+		stride = 16
+	}
+	values := make([]float32, 0, count*columns*rows)
+	for i := 0; i < count*columns; i++ {
+		for j := 0; j < rows; j++ {
+			start := offset + i*stride + j*4
+			if start+4 > len(data) {
+				values = append(values, 0)
+				continue
+			}
+			bits := binary.LittleEndian.Uint32(data[start:])
+			switch uniform.Type {
+			case gl.INT, gl.INT_VEC2, gl.INT_VEC3, gl.INT_VEC4:
+				values = append(values, float32(int32(bits)))
+			case gl.UNSIGNED_INT, gl.UNSIGNED_INT_VEC2, gl.UNSIGNED_INT_VEC3, gl.UNSIGNED_INT_VEC4,
+				gl.BOOL, gl.BOOL_VEC2, gl.BOOL_VEC3, gl.BOOL_VEC4:
+				values = append(values, float32(bits))
+			default:
+				values = append(values, math.Float32frombits(bits))
+			}
+		}
+	}
+	return values
+}
+
+func (p *program) set(location int32, values []float32) {
+	if !p.linked {
+		return
+	}
+	name, ok := p.names[location]
+	if !ok {
+		return
+	}
+	p.uniforms.values[name] = values
+}
+
+// RegisterVertexShader registers a Go port for the provided GLSL vertex
+// shader source.
+func (b *Backend) RegisterVertexShader(source string, shader *VertexShader) {
+	b.vertexShaders[sourceKey(source)] = shader
+}
+
+// RegisterFragmentShader registers a Go port for the provided GLSL fragment
+// shader source.
+func (b *Backend) RegisterFragmentShader(source string, shader *FragmentShader) {
+	b.fragmentShaders[sourceKey(source)] = shader
+}
+
+// RegisterDefaultShaders reads the default shader sources from the provided
+// directory of the asset file system and registers their Go ports. Sources
+// are preprocessed by the default preprocessor with the permutation defines,
+// so they match the source the shaders are compiled from.
+func (b *Backend) RegisterDefaultShaders(dir string) error {
+	for _, shader := range DefaultShaders {
+		preprocessor := render.DefaultPreprocessor.WithDefines(shader.Defines)
+		source, err := preprocessor.ProcessFile(path.Join(dir, shader.File))
+		if err != nil {
+			return err
+		}
+		if shader.Vertex != nil {
+			b.RegisterVertexShader(source.Text, shader.Vertex)
+		}
+		if shader.Fragment != nil {
+			b.RegisterFragmentShader(source.Text, shader.Fragment)
+		}
+	}
+	return nil
+}
+
+func sourceKey(source string) string {
+	return strings.TrimSpace(strings.Replace(source, "\r\n", "\n", -1))
+}
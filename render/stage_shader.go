@@ -0,0 +1,114 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/unchartedsoftware/plog"
+)
+
+var (
+	// stages lists the supported shader stages in pipeline order.
+	stages = []uint32{
+		gl.VERTEX_SHADER,
+		gl.TESS_CONTROL_SHADER,
+		gl.TESS_EVALUATION_SHADER,
+		gl.GEOMETRY_SHADER,
+		gl.FRAGMENT_SHADER,
+	}
+)
+
+// NewShader instantiates a new shader object from the provided stages, keyed
+// by shader type: gl.VERTEX_SHADER, gl.TESS_CONTROL_SHADER,
+// gl.TESS_EVALUATION_SHADER, gl.GEOMETRY_SHADER and gl.FRAGMENT_SHADER. Each
+// stage is a file path or GLSL. A vertex stage is required.
+func NewShader(sources map[uint32]string) (*Shader, error) {
+	return newShader(sources, nil, nil)
+}
+
+// NewShaderWithDefines instantiates a new shader object from the provided
+// stages with the provided defines injected into every stage.
+func NewShaderWithDefines(sources map[uint32]string, defines map[string]string) (*Shader, error) {
+	return newShader(sources, DefaultPreprocessor.WithDefines(defines), nil)
+}
+
+// NewFeedbackShader instantiates a new shader object from the provided stages
+// whose listed outputs are captured by transform feedback, interleaved in the
+// order provided. The fragment stage may be omitted.
+func NewFeedbackShader(sources map[uint32]string, varyings []string) (*Shader, error) {
+	if len(varyings) == 0 {
+		return nil, fmt.Errorf("feedback shader captures no varyings")
+	}
+	return newShader(sources, nil, varyings)
+}
+
+func newShader(sources map[uint32]string, preprocessor *Preprocessor, varyings []string) (*Shader, error) {
+	ordered, err := orderStages(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(ordered))
+	for i, source := range ordered {
+		names[i] = source.source
+	}
+	log.Infof("--- %s ---", strings.Join(names, ", "))
+
+	shader := &Shader{
+		preprocessor: preprocessor,
+		varyings:     varyings,
+	}
+	err = shader.build(ordered)
+	if err != nil {
+		return nil, err
+	}
+	return shader, nil
+}
+
+// orderStages checks the provided stages form a valid pipeline and returns
+// them in pipeline order.
+func orderStages(sources map[uint32]string) ([]shaderSource, error) {
+	for typ := range sources {
+		if stageName(typ) == "" {
+			return nil, fmt.Errorf("shader type `%d` is not a supported stage", typ)
+		}
+	}
+	if _, ok := sources[gl.VERTEX_SHADER]; !ok {
+		return nil, fmt.Errorf("shader has no vertex stage")
+	}
+	_, control := sources[gl.TESS_CONTROL_SHADER]
+	_, evaluation := sources[gl.TESS_EVALUATION_SHADER]
+	if control && !evaluation {
+		return nil, fmt.Errorf("shader has a tessellation control stage but no tessellation evaluation stage")
+	}
+	ordered := make([]shaderSource, 0, len(sources))
+	for _, typ := range stages {
+		source, ok := sources[typ]
+		if ok {
+			ordered = append(ordered, shaderSource{
+				typ:    typ,
+				source: source,
+			})
+		}
+	}
+	return ordered, nil
+}
+
+// stageName returns the name of a shader stage, or an empty string if the
+// type is not a supported stage.
+func stageName(typ uint32) string {
+	switch typ {
+	case gl.VERTEX_SHADER:
+		return "vertex"
+	case gl.TESS_CONTROL_SHADER:
+		return "tessellation control"
+	case gl.TESS_EVALUATION_SHADER:
+		return "tessellation evaluation"
+	case gl.GEOMETRY_SHADER:
+		return "geometry"
+	case gl.FRAGMENT_SHADER:
+		return "fragment"
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package render
+
+var (
+	stats Stats
+)
+
+// Stats represents the render statistics collected since the last reset.
+type Stats struct {
+	// DrawCalls is the number of draw calls issued.
+	DrawCalls int
+	// Instances is the number of instances drawn, a non-instanced draw call
+	// counts as a single instance.
+	Instances int
+	// Vertices is the number of vertices submitted across all instances.
+	Vertices int
+	// ShaderSwitches is the number of times a different shader was bound.
+	ShaderSwitches int
+	// StateChanges is the number of state changes issued by techniques.
+	StateChanges int
+	// SkippedStateChanges is the number of redundant state changes skipped by
+	// the technique state cache, including shader switches.
+	SkippedStateChanges int
+	// TextureBinds is the number of textures bound by commands.
+	TextureBinds int
+	// BytesUploaded is the number of bytes uploaded to buffers and textures.
+	BytesUploaded int
+	// UniformCacheHits is the number of uniform uploads skipped because the
+	// value matched the last value uploaded to the location.
+	UniformCacheHits int
+	// UniformCacheMisses is the number of uniform values uploaded.
+	UniformCacheMisses int
+}
+
+// FrameStats returns a snapshot of the render statistics collected since the
+// last call to ResetStats.
+func FrameStats() Stats {
+	return stats
+}
+
+// ResetStats resets the render statistics. It should be called once at the
+// start of each frame.
+func ResetStats() {
+	stats = Stats{}
+}
+
+func countStateChange(issued bool) {
+	if issued {
+		stats.StateChanges++
+	} else {
+		stats.SkippedStateChanges++
+	}
+}
+
+func countDraw(count int32, primcount int32) {
+	instances := 1
+	if primcount > 0 {
+		instances = int(primcount)
+	}
+	stats.DrawCalls++
+	stats.Instances += instances
+	stats.Vertices += int(count) * instances
+}
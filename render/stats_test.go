@@ -0,0 +1,124 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+func newFlatTechnique(t *testing.T) *render.Technique {
+	shader, err := render.NewVertFragShader(
+		"../resources/shaders/flat.vert",
+		"../resources/shaders/flat.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	technique := render.NewTechnique()
+	technique.Shader(shader)
+	technique.Viewport(&render.Viewport{
+		Width:  testWidth,
+		Height: testHeight,
+	})
+	return technique
+}
+
+func TestFrameStats(t *testing.T) {
+	b := newRecordingBackend(t)
+	blended := newFlatTechnique(t)
+	blended.Enable(gl.BLEND)
+	blended.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	opaque := newFlatTechnique(t)
+
+	b.reset()
+	render.ResetStats()
+	// each quad uploads 4 vec3 positions and 6 uint16 indices
+	quad := newQuad(t, 8)
+	instanced := newQuad(t, 8)
+	instanced.SetDrawElementsInstanced(gl.TRIANGLES, 6, gl.UNSIGNED_SHORT, 0, 3)
+	stats := render.FrameStats()
+	if stats.BytesUploaded != 2*(4*3*4+6*2) {
+		t.Errorf("expected %d bytes uploaded, got %d", 2*(4*3*4+6*2), stats.BytesUploaded)
+	}
+	expectCalls(t, "upload", filterCalls(b.calls, "BufferData"), []string{
+		"BufferData 48", "BufferData 12",
+		"BufferData 48", "BufferData 12",
+	})
+
+	tests := []struct {
+		name        string
+		technique   *render.Technique
+		renderables []*render.Renderable
+		// expected totals since the reset
+		drawCalls      int
+		instances      int
+		shaderSwitches int
+		// skipsAll expects every state of the technique to be skipped
+		skipsAll bool
+	}{
+		{
+			name:           "first draw",
+			technique:      blended,
+			renderables:    []*render.Renderable{quad, instanced},
+			drawCalls:      2,
+			instances:      4,
+			shaderSwitches: 1,
+		},
+		{
+			name:           "repeated draw",
+			technique:      blended,
+			renderables:    []*render.Renderable{quad},
+			drawCalls:      3,
+			instances:      5,
+			shaderSwitches: 1,
+			skipsAll:       true,
+		},
+		{
+			name:           "other shader",
+			technique:      opaque,
+			renderables:    []*render.Renderable{quad},
+			drawCalls:      4,
+			instances:      6,
+			shaderSwitches: 2,
+		},
+	}
+	for _, test := range tests {
+		var commands []*render.Command
+		for _, renderable := range test.renderables {
+			command := &render.Command{}
+			command.Renderable(renderable)
+			commands = append(commands, command)
+		}
+		before := render.FrameStats()
+		b.reset()
+		err := test.technique.Draw(commands)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := render.FrameStats()
+		if stats.DrawCalls != test.drawCalls ||
+			stats.Instances != test.instances ||
+			stats.ShaderSwitches != test.shaderSwitches {
+			t.Errorf("%s: expected %d draw calls, %d instances and %d shader switches, got %d, %d and %d",
+				test.name, test.drawCalls, test.instances, test.shaderSwitches,
+				stats.DrawCalls, stats.Instances, stats.ShaderSwitches)
+		}
+		if stats.BytesUploaded != before.BytesUploaded {
+			t.Errorf("%s: expected no bytes uploaded, got %d", test.name, stats.BytesUploaded-before.BytesUploaded)
+		}
+		issued := stats.StateChanges - before.StateChanges
+		skipped := stats.SkippedStateChanges - before.SkippedStateChanges
+		if test.skipsAll {
+			// the state and shader set by the previous draw are all skipped
+			expected := before.StateChanges + before.SkippedStateChanges + before.ShaderSwitches
+			if issued != 0 || skipped != expected {
+				t.Errorf("%s: expected 0 state changes and %d skipped, got %d and %d",
+					test.name, expected, issued, skipped)
+			}
+			expectCalls(t, test.name, filterCalls(b.calls, "Enable", "BlendFunc", "UseProgram"), nil)
+		} else if issued == 0 {
+			t.Errorf("%s: expected state changes to be issued", test.name)
+		}
+	}
+}
@@ -4,26 +4,60 @@ import (
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
-var (
-	prevBlendFunc   *blendFunc
-	prevCullFace    *cullFace
-	prevDepthMask   *depthMask
-	prevDepthFunc   *depthFunc
-	prevViewport    *Viewport
-	prevShader      *Shader
-	prevFrameBuffer *FrameBuffer
-	prevEnables     = make(map[uint32]bool)
-)
-
 type blendFunc struct {
-	sfactor uint32
-	dfactor uint32
+	srcRGB   uint32
+	dstRGB   uint32
+	srcAlpha uint32
+	dstAlpha uint32
 }
 
 func (b *blendFunc) Equals(other *blendFunc) bool {
 	return other != nil &&
-		b.sfactor == other.sfactor &&
-		b.dfactor == other.dfactor
+		b.srcRGB == other.srcRGB &&
+		b.dstRGB == other.dstRGB &&
+		b.srcAlpha == other.srcAlpha &&
+		b.dstAlpha == other.dstAlpha
+}
+
+type blendEquation struct {
+	modeRGB   uint32
+	modeAlpha uint32
+}
+
+func (b *blendEquation) Equals(other *blendEquation) bool {
+	return other != nil &&
+		b.modeRGB == other.modeRGB &&
+		b.modeAlpha == other.modeAlpha
+}
+
+type blendColor struct {
+	r float32
+	g float32
+	b float32
+	a float32
+}
+
+func (b *blendColor) Equals(other *blendColor) bool {
+	return other != nil &&
+		b.r == other.r &&
+		b.g == other.g &&
+		b.b == other.b &&
+		b.a == other.a
+}
+
+type colorMask struct {
+	r bool
+	g bool
+	b bool
+	a bool
+}
+
+func (c *colorMask) Equals(other *colorMask) bool {
+	return other != nil &&
+		c.r == other.r &&
+		c.g == other.g &&
+		c.b == other.b &&
+		c.a == other.a
 }
 
 type cullFace struct {
@@ -53,6 +87,85 @@ func (d *depthFunc) Equals(other *depthFunc) bool {
 		d.xfunc == other.xfunc
 }
 
+type stencilFunc struct {
+	xfunc uint32
+	ref   int32
+	mask  uint32
+}
+
+func (s *stencilFunc) Equals(other *stencilFunc) bool {
+	return other != nil &&
+		s.xfunc == other.xfunc &&
+		s.ref == other.ref &&
+		s.mask == other.mask
+}
+
+type stencilOp struct {
+	sfail  uint32
+	dpfail uint32
+	dppass uint32
+}
+
+func (s *stencilOp) Equals(other *stencilOp) bool {
+	return other != nil &&
+		s.sfail == other.sfail &&
+		s.dpfail == other.dpfail &&
+		s.dppass == other.dppass
+}
+
+type stencilMask struct {
+	mask uint32
+}
+
+func (s *stencilMask) Equals(other *stencilMask) bool {
+	return other != nil &&
+		s.mask == other.mask
+}
+
+type scissor struct {
+	x      int32
+	y      int32
+	width  int32
+	height int32
+}
+
+func (s *scissor) Equals(other *scissor) bool {
+	return other != nil &&
+		s.x == other.x &&
+		s.y == other.y &&
+		s.width == other.width &&
+		s.height == other.height
+}
+
+type polygonOffset struct {
+	factor float32
+	units  float32
+}
+
+func (p *polygonOffset) Equals(other *polygonOffset) bool {
+	return other != nil &&
+		p.factor == other.factor &&
+		p.units == other.units
+}
+
+type lineWidth struct {
+	width float32
+}
+
+func (l *lineWidth) Equals(other *lineWidth) bool {
+	return other != nil &&
+		l.width == other.width
+}
+
+type polygonMode struct {
+	mode uint32
+}
+
+func (p *polygonMode) Equals(other *polygonMode) bool {
+	return other != nil &&
+		p.mode == other.mode
+}
+
 type clearColor struct {
 	r float32
 	g float32
@@ -60,25 +173,94 @@ type clearColor struct {
 	a float32
 }
 
-// Technique represents a render technique.
+func (c *clearColor) Equals(other *clearColor) bool {
+	return other != nil &&
+		c.r == other.r &&
+		c.g == other.g &&
+		c.b == other.b &&
+		c.a == other.a
+}
+
+type clearDepth struct {
+	depth float32
+}
+
+func (c *clearDepth) Equals(other *clearDepth) bool {
+	return other != nil &&
+		c.depth == other.depth
+}
+
+type clearStencil struct {
+	s int32
+}
+
+func (c *clearStencil) Equals(other *clearStencil) bool {
+	return other != nil &&
+		c.s == other.s
+}
+
+// ClearMode represents when a technique clears its target.
+type ClearMode int
+
+const (
+	// ClearEveryDraw clears the target every time the technique is drawn.
+	ClearEveryDraw ClearMode = iota
+	// ClearOncePerFrame clears the target the first time the technique is
+	// drawn in a frame, frames are started with Context.BeginFrame.
+	ClearOncePerFrame
+)
+
+// Technique represents a render technique. Every state of the technique is
+// applied when it is drawn, states it does not enable are disabled and
+// states it does not set are reset to their defaults.
 type Technique struct {
-	enables     []uint32
-	shader      *Shader
-	viewport    *Viewport
-	framebuffer *FrameBuffer
-	blendFunc   *blendFunc
-	cullFace    *cullFace
-	depthMask   *depthMask
-	depthFunc   *depthFunc
-	clearColor  *clearColor
+	enables       []uint32
+	shader        *Shader
+	library       *ShaderLibrary
+	defines       map[string]string
+	buffers       []*UniformBuffer
+	viewport      *Viewport
+	framebuffer   *FrameBuffer
+	blendFunc     *blendFunc
+	blendEquation *blendEquation
+	blendColor    *blendColor
+	colorMask     *colorMask
+	cullFace      *cullFace
+	depthMask     *depthMask
+	depthFunc     *depthFunc
+	stencilFunc   *stencilFunc
+	stencilOp     *stencilOp
+	stencilMask   *stencilMask
+	scissor       *scissor
+	polygonOffset *polygonOffset
+	lineWidth     *lineWidth
+	polygonMode   *polygonMode
+	clearMask     uint32
+	clearMode     ClearMode
+	clearColor    *clearColor
+	clearDepth    *clearDepth
+	clearStencil  *clearStencil
 }
 
 // NewTechnique instantiates and returns a new technique instance.
 func NewTechnique() *Technique {
 	return &Technique{
 		blendFunc: &blendFunc{
-			sfactor: gl.ONE,
-			dfactor: gl.ZERO,
+			srcRGB:   gl.ONE,
+			dstRGB:   gl.ZERO,
+			srcAlpha: gl.ONE,
+			dstAlpha: gl.ZERO,
+		},
+		blendEquation: &blendEquation{
+			modeRGB:   gl.FUNC_ADD,
+			modeAlpha: gl.FUNC_ADD,
+		},
+		blendColor: &blendColor{},
+		colorMask: &colorMask{
+			r: true,
+			g: true,
+			b: true,
+			a: true,
 		},
 		cullFace: &cullFace{
 			mode: gl.BACK,
@@ -89,29 +271,135 @@ func NewTechnique() *Technique {
 		depthFunc: &depthFunc{
 			xfunc: gl.LESS,
 		},
+		stencilFunc: &stencilFunc{
+			xfunc: gl.ALWAYS,
+			mask:  0xffffffff,
+		},
+		stencilOp: &stencilOp{
+			sfail:  gl.KEEP,
+			dpfail: gl.KEEP,
+			dppass: gl.KEEP,
+		},
+		stencilMask: &stencilMask{
+			mask: 0xffffffff,
+		},
+		polygonOffset: &polygonOffset{},
+		lineWidth: &lineWidth{
+			width: 1,
+		},
+		polygonMode: &polygonMode{
+			mode: gl.FILL,
+		},
+		clearColor: &clearColor{},
+		clearDepth: &clearDepth{
+			depth: 1,
+		},
+		clearStencil: &clearStencil{},
 	}
 }
 
 // Enable enables the rendering states for the technique.
 func (t *Technique) Enable(enable uint32) {
+	for _, state := range t.enables {
+		if state == enable {
+			return
+		}
+	}
 	t.enables = append(t.enables, enable)
 }
 
+// Disable removes a rendering state enabled for the technique.
+func (t *Technique) Disable(enable uint32) {
+	for i, state := range t.enables {
+		if state == enable {
+			t.enables = append(t.enables[:i], t.enables[i+1:]...)
+			return
+		}
+	}
+}
+
 // Shader sets the shader for the technique.
 func (t *Technique) Shader(shader *Shader) {
 	t.shader = shader
 }
 
-// Viewport sets the viewport for the technique.
+// ShaderLibrary sets the shader library for the technique. The permutation
+// selected by Variant is compiled and used when drawing.
+func (t *Technique) ShaderLibrary(library *ShaderLibrary) {
+	t.library = library
+}
+
+// Variant selects the permutation of the shader library drawn with, by its
+// feature defines.
+func (t *Technique) Variant(defines map[string]string) {
+	t.defines = defines
+}
+
+// UniformBuffer adds a uniform buffer bound for every command drawn with the
+// technique.
+func (t *Technique) UniformBuffer(buffer *UniformBuffer) {
+	t.buffers = append(t.buffers, buffer)
+}
+
+// Viewport sets the viewport for the technique. Without a viewport the
+// technique draws to the whole of its target, following its size.
 func (t *Technique) Viewport(viewport *Viewport) {
 	t.viewport = viewport
 }
 
+// FrameBuffer sets the framebuffer the technique draws to. A nil
+// framebuffer draws to the default framebuffer.
+func (t *Technique) FrameBuffer(frameBuffer *FrameBuffer) {
+	t.framebuffer = frameBuffer
+}
+
 // BlendFunc sets the blend func for the technique.
 func (t *Technique) BlendFunc(sfactor uint32, dfactor uint32) {
+	t.BlendFuncSeparate(sfactor, dfactor, sfactor, dfactor)
+}
+
+// BlendFuncSeparate sets separate blend funcs for the RGB and alpha
+// components, for example to blend premultiplied alpha.
+func (t *Technique) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32, dstAlpha uint32) {
 	t.blendFunc = &blendFunc{
-		sfactor: sfactor,
-		dfactor: dfactor,
+		srcRGB:   srcRGB,
+		dstRGB:   dstRGB,
+		srcAlpha: srcAlpha,
+		dstAlpha: dstAlpha,
+	}
+}
+
+// BlendEquation sets the blend equation for the technique.
+func (t *Technique) BlendEquation(mode uint32) {
+	t.BlendEquationSeparate(mode, mode)
+}
+
+// BlendEquationSeparate sets separate blend equations for the RGB and alpha
+// components.
+func (t *Technique) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
+	t.blendEquation = &blendEquation{
+		modeRGB:   modeRGB,
+		modeAlpha: modeAlpha,
+	}
+}
+
+// BlendColor sets the constant color of the constant blend funcs.
+func (t *Technique) BlendColor(r, g, b, a float32) {
+	t.blendColor = &blendColor{
+		r: r,
+		g: g,
+		b: b,
+		a: a,
+	}
+}
+
+// ColorMask sets which color components are written by the technique.
+func (t *Technique) ColorMask(r, g, b, a bool) {
+	t.colorMask = &colorMask{
+		r: r,
+		g: g,
+		b: b,
+		a: a,
 	}
 }
 
@@ -136,7 +424,80 @@ func (t *Technique) DepthFunc(xfunc uint32) {
 	}
 }
 
-// ClearColor sets the clear color for the frame.
+// StencilFunc sets the stencil test func, reference value and mask for the
+// technique. The test is only performed if gl.STENCIL_TEST is enabled.
+func (t *Technique) StencilFunc(xfunc uint32, ref int32, mask uint32) {
+	t.stencilFunc = &stencilFunc{
+		xfunc: xfunc,
+		ref:   ref,
+		mask:  mask,
+	}
+}
+
+// StencilOp sets the actions taken on the stencil buffer when the stencil
+// test fails, when the depth test fails, and when both pass.
+func (t *Technique) StencilOp(sfail uint32, dpfail uint32, dppass uint32) {
+	t.stencilOp = &stencilOp{
+		sfail:  sfail,
+		dpfail: dpfail,
+		dppass: dppass,
+	}
+}
+
+// StencilMask sets which bits of the stencil buffer are written by the
+// technique.
+func (t *Technique) StencilMask(mask uint32) {
+	t.stencilMask = &stencilMask{
+		mask: mask,
+	}
+}
+
+// Scissor sets the scissor rectangle for the technique. The rectangle is
+// only applied if gl.SCISSOR_TEST is enabled.
+func (t *Technique) Scissor(x, y, width, height int32) {
+	t.scissor = &scissor{
+		x:      x,
+		y:      y,
+		width:  width,
+		height: height,
+	}
+}
+
+// PolygonOffset sets the depth offset for the technique. The offset is only
+// applied if gl.POLYGON_OFFSET_FILL, or the line or point equivalent, is
+// enabled.
+func (t *Technique) PolygonOffset(factor float32, units float32) {
+	t.polygonOffset = &polygonOffset{
+		factor: factor,
+		units:  units,
+	}
+}
+
+// LineWidth sets the width of lines drawn with the technique.
+func (t *Technique) LineWidth(width float32) {
+	t.lineWidth = &lineWidth{
+		width: width,
+	}
+}
+
+// PolygonMode sets how polygons drawn with the technique are rasterized,
+// gl.LINE draws them as wireframes.
+func (t *Technique) PolygonMode(mode uint32) {
+	t.polygonMode = &polygonMode{
+		mode: mode,
+	}
+}
+
+// Clear sets the buffers of the target cleared by the technique, as a mask
+// of gl.COLOR_BUFFER_BIT, gl.DEPTH_BUFFER_BIT and gl.STENCIL_BUFFER_BIT, and
+// when they are cleared. The clear happens before the first command is
+// drawn, within the scissor rectangle and write masks of the technique.
+func (t *Technique) Clear(mask uint32, mode ClearMode) {
+	t.clearMask = mask
+	t.clearMode = mode
+}
+
+// ClearColor sets the color the technique clears its target to.
 func (t *Technique) ClearColor(r, g, b, a float32) {
 	t.clearColor = &clearColor{
 		r: r,
@@ -146,77 +507,263 @@ func (t *Technique) ClearColor(r, g, b, a float32) {
 	}
 }
 
-// Draw renders all commands using the technique.
-func (t *Technique) Draw(commands []*Command) {
-	t.setup()
-	for _, command := range commands {
-		command.Execute(t.shader)
+// ClearDepth sets the depth the technique clears its target to.
+func (t *Technique) ClearDepth(depth float32) {
+	t.clearDepth = &clearDepth{
+		depth: depth,
+	}
+}
+
+// ClearStencil sets the stencil value the technique clears its target to.
+func (t *Technique) ClearStencil(s int32) {
+	t.clearStencil = &clearStencil{
+		s: s,
 	}
 }
 
-func (t *Technique) setup() {
+// Draw renders all commands using the technique against the default
+// context. Drawing stops at the first command that fails.
+func (t *Technique) Draw(commands []*Command) error {
+	return defaultContext.Draw(t, commands)
+}
 
-	// bind framebuffer
-	if t.framebuffer == nil && prevFrameBuffer != nil {
-		prevFrameBuffer.Unbind()
+// ValidateRenderable checks the attribute pointers of the renderable against
+// the active vertex attributes of the technique's shader.
+func (t *Technique) ValidateRenderable(renderable *Renderable) error {
+	err := t.selectVariant()
+	if err != nil {
+		return err
 	}
-	if t.framebuffer != nil && t.framebuffer != prevFrameBuffer {
-		t.framebuffer.Bind()
-		prevFrameBuffer = t.framebuffer
+	return t.shader.ValidateRenderable(renderable)
+}
+
+// selectVariant selects the shader permutation if a library is set.
+func (t *Technique) selectVariant() error {
+	if t.library == nil {
+		return nil
 	}
+	shader, err := t.library.Variant(t.defines)
+	if err != nil {
+		return err
+	}
+	t.shader = shader
+	return nil
+}
+
+func (t *Technique) setup(c *Context) {
+
+	// bind framebuffer
+	c.bindFrameBuffer(t.framebuffer)
 
 	// use shader
-	if prevShader != t.shader {
-		t.shader.Use()
-		prevShader = t.shader
-	}
+	c.useShader(t.shader)
 
-	// track previous enables to determine which are stale
+	// track enabled and unknown states to determine which are stale
 	staleEnables := make(map[uint32]bool)
-	for state := range prevEnables {
-		staleEnables[state] = true
+	for state, enabled := range c.enables {
+		if enabled {
+			staleEnables[state] = true
+		}
+	}
+	if c.unknownEnables {
+		for _, state := range capabilities {
+			if _, ok := c.enables[state]; !ok {
+				staleEnables[state] = true
+			}
+		}
 	}
 
 	// enable state
 	for _, state := range t.enables {
-		if !prevEnables[state] {
-			gl.Enable(state)
-			prevEnables[state] = true
-		}
+		c.enable(state)
 		delete(staleEnables, state)
 	}
 
 	// disable stale state
 	for state := range staleEnables {
-		gl.Disable(state)
-		delete(prevEnables, state)
+		c.disable(state)
 	}
+	c.unknownEnables = false
 
 	// update state functions
-	if t.blendFunc != nil && !t.blendFunc.Equals(prevBlendFunc) {
-		gl.BlendFunc(t.blendFunc.sfactor, t.blendFunc.dfactor)
-		prevBlendFunc = t.blendFunc
+	if t.blendFunc != nil {
+		issued := !t.blendFunc.Equals(c.blendFunc)
+		if issued {
+			if t.blendFunc.srcRGB == t.blendFunc.srcAlpha &&
+				t.blendFunc.dstRGB == t.blendFunc.dstAlpha {
+				backend.BlendFunc(t.blendFunc.srcRGB, t.blendFunc.dstRGB)
+			} else {
+				backend.BlendFuncSeparate(
+					t.blendFunc.srcRGB,
+					t.blendFunc.dstRGB,
+					t.blendFunc.srcAlpha,
+					t.blendFunc.dstAlpha)
+			}
+			c.blendFunc = t.blendFunc
+		}
+		countStateChange(issued)
 	}
-	if t.cullFace != nil && !t.cullFace.Equals(prevCullFace) {
-		gl.CullFace(t.cullFace.mode)
-		prevCullFace = t.cullFace
+	if t.blendEquation != nil {
+		issued := !t.blendEquation.Equals(c.blendEquation)
+		if issued {
+			backend.BlendEquationSeparate(t.blendEquation.modeRGB, t.blendEquation.modeAlpha)
+			c.blendEquation = t.blendEquation
+		}
+		countStateChange(issued)
 	}
-	if t.depthMask != nil && !t.depthMask.Equals(prevDepthMask) {
-		gl.DepthMask(t.depthMask.flag)
-		prevDepthMask = t.depthMask
+	if t.blendColor != nil {
+		issued := !t.blendColor.Equals(c.blendColor)
+		if issued {
+			backend.BlendColor(t.blendColor.r, t.blendColor.g, t.blendColor.b, t.blendColor.a)
+			c.blendColor = t.blendColor
+		}
+		countStateChange(issued)
+	}
+	if t.colorMask != nil {
+		issued := !t.colorMask.Equals(c.colorMask)
+		if issued {
+			backend.ColorMask(t.colorMask.r, t.colorMask.g, t.colorMask.b, t.colorMask.a)
+			c.colorMask = t.colorMask
+		}
+		countStateChange(issued)
+	}
+	if t.cullFace != nil {
+		issued := !t.cullFace.Equals(c.cullFace)
+		if issued {
+			backend.CullFace(t.cullFace.mode)
+			c.cullFace = t.cullFace
+		}
+		countStateChange(issued)
+	}
+	if t.depthMask != nil {
+		issued := !t.depthMask.Equals(c.depthMask)
+		if issued {
+			backend.DepthMask(t.depthMask.flag)
+			c.depthMask = t.depthMask
+		}
+		countStateChange(issued)
+	}
+	if t.depthFunc != nil {
+		issued := !t.depthFunc.Equals(c.depthFunc)
+		if issued {
+			backend.DepthFunc(t.depthFunc.xfunc)
+			c.depthFunc = t.depthFunc
+		}
+		countStateChange(issued)
 	}
-	if t.depthFunc != nil && !t.depthFunc.Equals(prevDepthFunc) {
-		gl.DepthFunc(t.depthFunc.xfunc)
-		prevDepthFunc = t.depthFunc
+	if t.stencilFunc != nil {
+		issued := !t.stencilFunc.Equals(c.stencilFunc)
+		if issued {
+			backend.StencilFunc(t.stencilFunc.xfunc, t.stencilFunc.ref, t.stencilFunc.mask)
+			c.stencilFunc = t.stencilFunc
+		}
+		countStateChange(issued)
+	}
+	if t.stencilOp != nil {
+		issued := !t.stencilOp.Equals(c.stencilOp)
+		if issued {
+			backend.StencilOp(t.stencilOp.sfail, t.stencilOp.dpfail, t.stencilOp.dppass)
+			c.stencilOp = t.stencilOp
+		}
+		countStateChange(issued)
+	}
+	if t.stencilMask != nil {
+		issued := !t.stencilMask.Equals(c.stencilMask)
+		if issued {
+			backend.StencilMask(t.stencilMask.mask)
+			c.stencilMask = t.stencilMask
+		}
+		countStateChange(issued)
+	}
+	if t.scissor != nil {
+		issued := !t.scissor.Equals(c.scissor)
+		if issued {
+			backend.Scissor(t.scissor.x, t.scissor.y, t.scissor.width, t.scissor.height)
+			c.scissor = t.scissor
+		}
+		countStateChange(issued)
+	}
+	if t.polygonOffset != nil {
+		issued := !t.polygonOffset.Equals(c.polygonOffset)
+		if issued {
+			backend.PolygonOffset(t.polygonOffset.factor, t.polygonOffset.units)
+			c.polygonOffset = t.polygonOffset
+		}
+		countStateChange(issued)
+	}
+	if t.lineWidth != nil {
+		issued := !t.lineWidth.Equals(c.lineWidth)
+		if issued {
+			backend.LineWidth(t.lineWidth.width)
+			c.lineWidth = t.lineWidth
+		}
+		countStateChange(issued)
+	}
+	if t.polygonMode != nil {
+		issued := !t.polygonMode.Equals(c.polygonMode)
+		if issued {
+			backend.PolygonMode(gl.FRONT_AND_BACK, t.polygonMode.mode)
+			c.polygonMode = t.polygonMode
+		}
+		countStateChange(issued)
 	}
 
 	// update viewport
-	if t.viewport != nil && !t.viewport.Equals(prevViewport) {
-		gl.Viewport(
-			t.viewport.X,
-			t.viewport.Y,
-			t.viewport.Width,
-			t.viewport.Height)
-		prevViewport = t.viewport
+	viewport := t.viewport
+	if viewport == nil {
+		viewport = c.targetViewport(t.framebuffer)
+	}
+	if viewport != nil {
+		issued := !viewport.Equals(c.viewport)
+		if issued {
+			backend.Viewport(
+				viewport.X,
+				viewport.Y,
+				viewport.Width,
+				viewport.Height)
+			// cache a copy, viewports may be modified in place
+			cached := *viewport
+			c.viewport = &cached
+		}
+		countStateChange(issued)
+	}
+
+	// clear target
+	if t.clearMask != 0 {
+		t.clear(c)
+	}
+}
+
+func (t *Technique) clear(c *Context) {
+	if t.clearMode == ClearOncePerFrame {
+		if c.cleared[t] {
+			return
+		}
+		c.cleared[t] = true
+	}
+	if t.clearMask&gl.COLOR_BUFFER_BIT != 0 {
+		issued := !t.clearColor.Equals(c.clearColor)
+		if issued {
+			backend.ClearColor(t.clearColor.r, t.clearColor.g, t.clearColor.b, t.clearColor.a)
+			c.clearColor = t.clearColor
+		}
+		countStateChange(issued)
+	}
+	if t.clearMask&gl.DEPTH_BUFFER_BIT != 0 {
+		issued := !t.clearDepth.Equals(c.clearDepth)
+		if issued {
+			backend.ClearDepth(t.clearDepth.depth)
+			c.clearDepth = t.clearDepth
+		}
+		countStateChange(issued)
+	}
+	if t.clearMask&gl.STENCIL_BUFFER_BIT != 0 {
+		issued := !t.clearStencil.Equals(c.clearStencil)
+		if issued {
+			backend.ClearStencil(t.clearStencil.s)
+			c.clearStencil = t.clearStencil
+		}
+		countStateChange(issued)
 	}
+	backend.Clear(t.clearMask)
 }
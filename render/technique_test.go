@@ -0,0 +1,109 @@
+package render_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+func TestTechniqueSkipsRedundantState(t *testing.T) {
+	b := newRecordingBackend(t)
+	shader, err := render.NewVertFragShader(
+		"../resources/shaders/flat.vert",
+		"../resources/shaders/flat.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quad := newQuad(t, 8)
+	viewport := &render.Viewport{
+		Width:  testWidth,
+		Height: testHeight,
+	}
+	blended := render.NewTechnique()
+	blended.Shader(shader)
+	blended.Viewport(viewport)
+	blended.Enable(gl.BLEND)
+	blended.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	masked := render.NewTechnique()
+	masked.Shader(shader)
+	masked.Viewport(viewport)
+	masked.Enable(gl.BLEND)
+	masked.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	masked.DepthMask(false)
+	opaque := render.NewTechnique()
+	opaque.Shader(shader)
+	opaque.Viewport(viewport)
+
+	draw := []string{
+		fmt.Sprintf("BindVertexArray %d", b.vertexArrays[0]),
+		"DrawElements 6",
+		"BindVertexArray 0",
+	}
+	tests := []struct {
+		name      string
+		technique *render.Technique
+		color     mgl32.Vec4
+		expected  []string
+	}{
+		{
+			name:      "first draw",
+			technique: blended,
+			color:     mgl32.Vec4{1, 0, 0, 1},
+			expected: append([]string{
+				"UseProgram",
+				"Enable 3042",
+				"BlendFunc 770 771",
+				"CullFace 1029",
+				"DepthMask true",
+				"DepthFunc 513",
+				"Viewport 0 0 32 32",
+				"Uniform4fv 1",
+				"UniformMatrix4fv 1",
+			}, draw...),
+		},
+		{
+			name:      "same technique and uniforms",
+			technique: blended,
+			color:     mgl32.Vec4{1, 0, 0, 1},
+			expected:  draw,
+		},
+		{
+			name:      "changed uniform",
+			technique: blended,
+			color:     mgl32.Vec4{0, 1, 0, 1},
+			expected:  append([]string{"Uniform4fv 1"}, draw...),
+		},
+		{
+			name:      "changed depth mask",
+			technique: masked,
+			color:     mgl32.Vec4{0, 1, 0, 1},
+			expected:  append([]string{"DepthMask false"}, draw...),
+		},
+		{
+			name:      "disabled blending",
+			technique: opaque,
+			color:     mgl32.Vec4{0, 1, 0, 1},
+			expected: append([]string{
+				"Disable 3042",
+				"BlendFunc 1 0",
+				"DepthMask true",
+			}, draw...),
+		},
+	}
+	for _, test := range tests {
+		b.reset()
+		command := &render.Command{}
+		command.Uniform("uColor", test.color)
+		command.Uniform("uModel", mgl32.Ident4())
+		command.Renderable(quad)
+		err := test.technique.Draw([]*render.Command{command})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		expectCalls(t, test.name, b.calls, test.expected)
+	}
+}
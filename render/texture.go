@@ -6,7 +6,6 @@ import (
 	"image/draw"
 	// register png decoder
 	_ "image/png"
-	"os"
 	"unsafe"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
@@ -41,13 +40,15 @@ type TextureParams struct {
 	MagFilter int32
 }
 
-// LoadRGBATexture loads an image file into an RGBA texture.
+// LoadRGBATexture loads an image file into an RGBA texture. The file is read
+// through the asset file system.
 func LoadRGBATexture(filename string) (*Texture, error) {
 	// load / decode image
-	file, err := os.Open(filename)
+	file, err := assets.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("texture file `%s` not found on disk: %v", filename, err)
+		return nil, fmt.Errorf("texture file `%s` not found: %v", filename, err)
 	}
+	defer file.Close()
 	img, _, err := image.Decode(file)
 	if err != nil {
 		return nil, err
@@ -68,20 +69,36 @@ func LoadRGBATexture(filename string) (*Texture, error) {
 			WrapT:     gl.CLAMP_TO_EDGE,
 			MinFilter: gl.LINEAR_MIPMAP_LINEAR,
 			MagFilter: gl.LINEAR,
-		}), nil
+		})
 }
 
 // NewRGBATexture returns a new RGBA texture.
-func NewRGBATexture(rgba []uint8, width uint32, height uint32, params *TextureParams) *Texture {
+func NewRGBATexture(rgba []uint8, width uint32, height uint32, params *TextureParams) (*Texture, error) {
+	var data unsafe.Pointer
+	if rgba != nil {
+		data = gl.Ptr(rgba)
+		stats.BytesUploaded += len(rgba)
+	}
+	return newTexture(data, width, height, gl.RGBA, gl.RGBA, gl.UNSIGNED_BYTE, params)
+}
+
+// NewFloatTexture returns a new RGBA texture of half float components
+// without contents. It holds colors outside of [0, 1] when rendered to, such
+// as a scene before tone mapping.
+func NewFloatTexture(width uint32, height uint32, params *TextureParams) (*Texture, error) {
+	return newTexture(nil, width, height, gl.RGBA16F, gl.RGBA, gl.HALF_FLOAT, params)
+}
+
+func newTexture(data unsafe.Pointer, width uint32, height uint32, internalFormat int32, format uint32, typ uint32, params *TextureParams) (*Texture, error) {
 	texture := &Texture{
 		width:          width,
 		height:         height,
-		typ:            gl.UNSIGNED_BYTE,
-		format:         gl.RGBA,
-		internalFormat: gl.RGBA,
+		typ:            typ,
+		format:         format,
+		internalFormat: internalFormat,
 	}
-	gl.GenTextures(1, &texture.id)
-	gl.BindTexture(gl.TEXTURE_2D, texture.id)
+	texture.id = backend.CreateTexture()
+	backend.BindTexture(gl.TEXTURE_2D, texture.id)
 	// default params
 	if params == nil {
 		params = &TextureParams{}
@@ -99,25 +116,18 @@ func NewRGBATexture(rgba []uint8, width uint32, height uint32, params *TexturePa
 		params.MagFilter = DefaultMagFilter
 	}
 	// set params
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, params.MinFilter)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, params.MagFilter)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, params.WrapS)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, params.WrapT)
-
-	// get pointer
-	var data unsafe.Pointer
-	if rgba != nil {
-		data = gl.Ptr(rgba)
-	}
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, params.MinFilter)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, params.MagFilter)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, params.WrapS)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, params.WrapT)
 
 	// buffer texture
-	gl.TexImage2D(
+	backend.TexImage2D(
 		gl.TEXTURE_2D,
 		0,
 		texture.internalFormat,
 		int32(texture.width),
 		int32(texture.height),
-		0,
 		texture.format,
 		texture.typ,
 		data)
@@ -127,10 +137,15 @@ func NewRGBATexture(rgba []uint8, width uint32, height uint32, params *TexturePa
 		params.MinFilter == gl.LINEAR_MIPMAP_NEAREST ||
 		params.MinFilter == gl.NEAREST_MIPMAP_LINEAR ||
 		params.MinFilter == gl.NEAREST_MIPMAP_NEAREST {
-		gl.GenerateMipmap(gl.TEXTURE_2D)
+		backend.GenerateMipmap(gl.TEXTURE_2D)
 	}
-	gl.BindTexture(gl.TEXTURE_2D, 0)
-	return texture
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	err := checkError(texture.id)
+	if err != nil {
+		texture.Destroy()
+		return nil, err
+	}
+	return texture, nil
 }
 
 // Width returns the width of the texture.
@@ -150,35 +165,35 @@ func (t *Texture) ID() uint32 {
 
 // Bind activates the provided texture unit and binds the texture.
 func (t *Texture) Bind(location uint32) {
-	gl.ActiveTexture(location)
-	gl.BindTexture(gl.TEXTURE_2D, t.id)
+	backend.ActiveTexture(location)
+	backend.BindTexture(gl.TEXTURE_2D, t.id)
 }
 
 // Unbind will unbind the texture.
 func (t *Texture) Unbind() {
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	backend.BindTexture(gl.TEXTURE_2D, 0)
 }
 
 // Resize will resize the texture, removing it's current buffer.
-func (t *Texture) Resize(width uint32, height uint32) {
+func (t *Texture) Resize(width uint32, height uint32) error {
 	t.width = width
 	t.height = height
-	gl.BindTexture(gl.TEXTURE_2D, t.id)
-	gl.TexImage2D(
+	backend.BindTexture(gl.TEXTURE_2D, t.id)
+	backend.TexImage2D(
 		gl.TEXTURE_2D,
 		0,
 		t.internalFormat,
 		int32(t.width),
 		int32(t.height),
-		0,
 		t.format,
 		t.typ,
 		nil)
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	return checkError(t.id)
 }
 
 // Destroy deallocates the texture buffer.
 func (t *Texture) Destroy() {
-	gl.DeleteTextures(1, &t.id)
+	backend.DeleteTexture(t.id)
 	t.id = 0
 }
@@ -0,0 +1,162 @@
+package trace
+
+import (
+	"github.com/kbirk/cauldron/render"
+)
+
+func (e *encoder) writeUint32Array(values []uint32) {
+	for _, v := range values {
+		e.writeUint32(v)
+	}
+}
+
+func (e *encoder) writeFloat32Array(values []float32) {
+	for _, v := range values {
+		e.writeFloat32(v)
+	}
+}
+
+func (e *encoder) writeBuffers(buffers []render.MarkerBuffer) {
+	e.writeUint32(uint32(len(buffers)))
+	for _, buffer := range buffers {
+		e.writeUint32(buffer.Binding)
+		e.writeUint32(buffer.Buffer)
+	}
+}
+
+func (e *encoder) writeTechniqueMarker(m *render.TechniqueMarker) {
+	e.writeUint32(m.Program)
+	e.writeUint32(m.Framebuffer)
+	for _, v := range m.Viewport {
+		e.writeInt32(v)
+	}
+	e.writeUint32(uint32(len(m.Enables)))
+	e.writeUint32Array(m.Enables)
+	e.writeUint32Array(m.BlendFunc[:])
+	e.writeUint32Array(m.BlendEquation[:])
+	e.writeFloat32Array(m.BlendColor[:])
+	for _, v := range m.ColorMask {
+		e.writeBool(v)
+	}
+	e.writeUint32(m.CullFace)
+	e.writeBool(m.DepthMask)
+	e.writeUint32(m.DepthFunc)
+	e.writeUint32Array(m.StencilFunc[:])
+	e.writeUint32Array(m.StencilOp[:])
+	e.writeUint32(m.StencilMask)
+	for _, v := range m.Scissor {
+		e.writeInt32(v)
+	}
+	e.writeFloat32Array(m.PolygonOffset[:])
+	e.writeFloat32(m.LineWidth)
+	e.writeUint32(m.PolygonMode)
+	e.writeUint32(m.ClearMask)
+	e.writeFloat32Array(m.ClearColor[:])
+	e.writeFloat32(m.ClearDepth)
+	e.writeInt32(m.ClearStencil)
+	e.writeBuffers(m.UniformBuffers)
+}
+
+func (e *encoder) writeCommandMarker(m *render.CommandMarker) {
+	e.writeUint32(uint32(len(m.Uniforms)))
+	for _, uniform := range m.Uniforms {
+		e.writeString(uniform.Name)
+		e.writeUint32(uniform.Type)
+		e.writeInt32(uniform.Count)
+		e.writeUint32(uint32(len(uniform.Words)))
+		e.writeUint32Array(uniform.Words)
+	}
+	e.writeUint32(uint32(len(m.Textures)))
+	for _, texture := range m.Textures {
+		e.writeUint32(texture.Unit)
+		e.writeUint32(texture.Texture)
+	}
+	e.writeBuffers(m.UniformBuffers)
+	e.writeUint32(m.VertexArray)
+}
+
+func (d *decoder) readUint32Array(values []uint32) {
+	for i := range values {
+		values[i] = d.readUint32()
+	}
+}
+
+func (d *decoder) readFloat32Array(values []float32) {
+	for i := range values {
+		values[i] = d.readFloat32()
+	}
+}
+
+func (d *decoder) readBuffers() []render.MarkerBuffer {
+	var buffers []render.MarkerBuffer
+	n := d.readLength()
+	for i := 0; i < n && d.err == nil; i++ {
+		binding := d.readUint32()
+		buffers = append(buffers, render.MarkerBuffer{
+			Binding: binding,
+			Buffer:  d.readUint32(),
+		})
+	}
+	return buffers
+}
+
+func (d *decoder) readTechniqueMarker() *render.TechniqueMarker {
+	m := &render.TechniqueMarker{}
+	m.Program = d.readUint32()
+	m.Framebuffer = d.readUint32()
+	for i := range m.Viewport {
+		m.Viewport[i] = d.readInt32()
+	}
+	m.Enables = d.readUint32s()
+	d.readUint32Array(m.BlendFunc[:])
+	d.readUint32Array(m.BlendEquation[:])
+	d.readFloat32Array(m.BlendColor[:])
+	for i := range m.ColorMask {
+		m.ColorMask[i] = d.readBool()
+	}
+	m.CullFace = d.readUint32()
+	m.DepthMask = d.readBool()
+	m.DepthFunc = d.readUint32()
+	d.readUint32Array(m.StencilFunc[:])
+	d.readUint32Array(m.StencilOp[:])
+	m.StencilMask = d.readUint32()
+	for i := range m.Scissor {
+		m.Scissor[i] = d.readInt32()
+	}
+	d.readFloat32Array(m.PolygonOffset[:])
+	m.LineWidth = d.readFloat32()
+	m.PolygonMode = d.readUint32()
+	m.ClearMask = d.readUint32()
+	d.readFloat32Array(m.ClearColor[:])
+	m.ClearDepth = d.readFloat32()
+	m.ClearStencil = d.readInt32()
+	m.UniformBuffers = d.readBuffers()
+	return m
+}
+
+func (d *decoder) readCommandMarker() *render.CommandMarker {
+	m := &render.CommandMarker{}
+	n := d.readLength()
+	for i := 0; i < n && d.err == nil; i++ {
+		name := d.readString()
+		typ := d.readUint32()
+		count := d.readInt32()
+		m.Uniforms = append(m.Uniforms, render.MarkerUniform{
+			Name:  name,
+			Type:  typ,
+			Count: count,
+			Words: d.readUint32s(),
+		})
+	}
+	n = d.readLength()
+	for i := 0; i < n && d.err == nil; i++ {
+		unit := d.readUint32()
+		m.Textures = append(m.Textures, render.MarkerTexture{
+			Unit:    unit,
+			Texture: d.readUint32(),
+		})
+	}
+	m.UniformBuffers = d.readBuffers()
+	m.VertexArray = d.readUint32()
+	return m
+}
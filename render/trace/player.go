@@ -0,0 +1,593 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// Player represents the replay of a trace against a backend. Object names
+// and uniform locations are remapped, so a trace recorded against one
+// backend may be replayed against any other. Technique and command markers
+// are reported to the backend with their object names remapped.
+type Player struct {
+	backend  render.Backend
+	dec      *decoder
+	header   Header
+	frames   int
+	shaders  map[uint32]uint32
+	programs map[uint32]*program
+	buffers  map[uint32]uint32
+	arrays   map[uint32]uint32
+	textures map[uint32]uint32
+	fbos     map[uint32]uint32
+	current  *program
+}
+
+type program struct {
+	id        uint32
+	locations map[int32]int32
+	indices   map[uint32]uint32
+}
+
+// NewPlayer reads the trace header from the provided reader and returns a
+// player that replays its frames against the provided backend.
+func NewPlayer(r io.Reader, backend render.Backend) (*Player, error) {
+	dec := newDecoder(r)
+	buf := make([]byte, len(magic))
+	dec.read(buf)
+	if dec.err != nil {
+		return nil, fmt.Errorf("failed to read trace header: %v", dec.err)
+	}
+	if string(buf) != magic {
+		return nil, fmt.Errorf("not a trace file")
+	}
+	header := Header{
+		Version: dec.readUint32(),
+		Width:   dec.readInt32(),
+		Height:  dec.readInt32(),
+	}
+	if dec.err != nil {
+		return nil, fmt.Errorf("failed to read trace header: %v", dec.err)
+	}
+	if header.Version != Version {
+		return nil, fmt.Errorf("unsupported trace version %d, expected %d",
+			header.Version,
+			Version)
+	}
+	return &Player{
+		backend:  backend,
+		dec:      dec,
+		header:   header,
+		shaders:  make(map[uint32]uint32),
+		programs: make(map[uint32]*program),
+		buffers:  make(map[uint32]uint32),
+		arrays:   make(map[uint32]uint32),
+		textures: make(map[uint32]uint32),
+		fbos:     make(map[uint32]uint32),
+	}, nil
+}
+
+// Header returns the header of the trace.
+func (p *Player) Header() Header {
+	return p.header
+}
+
+// Frames returns the number of frames replayed.
+func (p *Player) Frames() int {
+	return p.frames
+}
+
+// ReplayFrame re-issues the next frame of the trace against the backend. It
+// returns io.EOF once all frames have been replayed. A trailing frame that
+// was not ended, for example due to a crash while recording, is replayed as
+// is.
+func (p *Player) ReplayFrame() error {
+	calls := 0
+	for {
+		op, err := p.dec.readOpcode()
+		if err == io.EOF {
+			if calls > 0 {
+				p.frames++
+				return nil
+			}
+			return io.EOF
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read trace frame %d: %v", p.frames, err)
+		}
+		if op == opEndFrame {
+			p.frames++
+			return nil
+		}
+		if op >= numOpcodes {
+			return fmt.Errorf("failed to replay trace frame %d: unrecognized opcode %d",
+				p.frames,
+				op)
+		}
+		err = p.replay(op)
+		if err == nil {
+			err = p.dec.err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to replay trace frame %d: %v", p.frames, err)
+		}
+		calls++
+	}
+}
+
+// Replay replays every frame of the provided trace file against the backend
+// and returns the number of frames replayed.
+func Replay(filename string, backend render.Backend) (int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	player, err := NewPlayer(file, backend)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		err := player.ReplayFrame()
+		if err == io.EOF {
+			return player.Frames(), nil
+		}
+		if err != nil {
+			return player.Frames(), err
+		}
+	}
+}
+
+func (p *Player) location(location int32) int32 {
+	if p.current == nil {
+		return location
+	}
+	mapped, ok := p.current.locations[location]
+	if !ok {
+		// uniform is not active in the replaying backend
+		return -1
+	}
+	return mapped
+}
+
+func (p *Player) replay(op opcode) error {
+	d := p.dec
+	b := p.backend
+	switch op {
+
+	// state
+	case opEnable:
+		b.Enable(d.readUint32())
+	case opDisable:
+		b.Disable(d.readUint32())
+	case opBlendFunc:
+		sfactor := d.readUint32()
+		dfactor := d.readUint32()
+		b.BlendFunc(sfactor, dfactor)
+	case opCullFace:
+		b.CullFace(d.readUint32())
+	case opDepthMask:
+		b.DepthMask(d.readBool())
+	case opDepthFunc:
+		b.DepthFunc(d.readUint32())
+	case opViewport:
+		x := d.readInt32()
+		y := d.readInt32()
+		width := d.readInt32()
+		height := d.readInt32()
+		b.Viewport(x, y, width, height)
+	case opClearColor:
+		red := d.readFloat32()
+		green := d.readFloat32()
+		blue := d.readFloat32()
+		alpha := d.readFloat32()
+		b.ClearColor(red, green, blue, alpha)
+	case opClear:
+		b.Clear(d.readUint32())
+	case opClearDepth:
+		b.ClearDepth(d.readFloat32())
+	case opClearStencil:
+		b.ClearStencil(d.readInt32())
+	case opBlendFuncSeparate:
+		srcRGB := d.readUint32()
+		dstRGB := d.readUint32()
+		srcAlpha := d.readUint32()
+		b.BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, d.readUint32())
+	case opBlendEquationSeparate:
+		modeRGB := d.readUint32()
+		b.BlendEquationSeparate(modeRGB, d.readUint32())
+	case opBlendColor:
+		red := d.readFloat32()
+		green := d.readFloat32()
+		blue := d.readFloat32()
+		b.BlendColor(red, green, blue, d.readFloat32())
+	case opColorMask:
+		red := d.readBool()
+		green := d.readBool()
+		blue := d.readBool()
+		b.ColorMask(red, green, blue, d.readBool())
+	case opStencilFunc:
+		xfunc := d.readUint32()
+		ref := d.readInt32()
+		b.StencilFunc(xfunc, ref, d.readUint32())
+	case opStencilOp:
+		sfail := d.readUint32()
+		dpfail := d.readUint32()
+		b.StencilOp(sfail, dpfail, d.readUint32())
+	case opStencilMask:
+		b.StencilMask(d.readUint32())
+	case opScissor:
+		x := d.readInt32()
+		y := d.readInt32()
+		width := d.readInt32()
+		b.Scissor(x, y, width, d.readInt32())
+	case opPolygonOffset:
+		factor := d.readFloat32()
+		b.PolygonOffset(factor, d.readFloat32())
+	case opLineWidth:
+		b.LineWidth(d.readFloat32())
+	case opPolygonMode:
+		face := d.readUint32()
+		b.PolygonMode(face, d.readUint32())
+
+	// shaders
+	case opCreateShader:
+		recorded := d.readUint32()
+		typ := d.readUint32()
+		source := d.readString()
+		if d.err != nil {
+			return d.err
+		}
+		shader, err := b.CreateShader(typ, source)
+		if err != nil && recorded != 0 {
+			return fmt.Errorf("failed to compile shader %d: %v", recorded, err)
+		}
+		p.shaders[recorded] = shader
+	case opDeleteShader:
+		recorded := d.readUint32()
+		b.DeleteShader(p.shaders[recorded])
+		delete(p.shaders, recorded)
+	case opCreateProgram:
+		recorded := d.readUint32()
+		p.programs[recorded] = &program{
+			id: b.CreateProgram(),
+		}
+	case opAttachShader:
+		prog := p.programs[d.readUint32()]
+		shader := p.shaders[d.readUint32()]
+		if prog == nil {
+			return fmt.Errorf("unrecognized program")
+		}
+		b.AttachShader(prog.id, shader)
+	case opDetachShader:
+		prog := p.programs[d.readUint32()]
+		shader := p.shaders[d.readUint32()]
+		if prog == nil {
+			return fmt.Errorf("unrecognized program")
+		}
+		b.DetachShader(prog.id, shader)
+	case opTransformFeedbackVaryings:
+		prog := p.programs[d.readUint32()]
+		varyings := make([]string, d.readLength())
+		for i := range varyings {
+			varyings[i] = d.readString()
+		}
+		bufferMode := d.readUint32()
+		if prog == nil {
+			return fmt.Errorf("unrecognized program")
+		}
+		b.TransformFeedbackVaryings(prog.id, varyings, bufferMode)
+	case opLinkProgram:
+		prog := p.programs[d.readUint32()]
+		linked := d.readBool()
+		if prog == nil {
+			return fmt.Errorf("unrecognized program")
+		}
+		err := b.LinkProgram(prog.id)
+		if err != nil && linked {
+			return fmt.Errorf("failed to link program: %v", err)
+		}
+	case opProgramUniforms:
+		prog := p.programs[d.readUint32()]
+		if prog == nil {
+			return fmt.Errorf("unrecognized program")
+		}
+		locations := make(map[string]int32)
+		for _, uniform := range b.ActiveUniforms(prog.id) {
+			locations[uniform.Name] = uniform.Location
+		}
+		indices := make(map[string]uint32)
+		for _, block := range b.ActiveUniformBlocks(prog.id) {
+			indices[block.Name] = block.Index
+		}
+		prog.locations = make(map[int32]int32)
+		for i, n := 0, d.readLength(); i < n; i++ {
+			name := d.readString()
+			location := d.readInt32()
+			if mapped, ok := locations[name]; ok {
+				prog.locations[location] = mapped
+			}
+		}
+		prog.indices = make(map[uint32]uint32)
+		for i, n := 0, d.readLength(); i < n; i++ {
+			name := d.readString()
+			index := d.readUint32()
+			if mapped, ok := indices[name]; ok {
+				prog.indices[index] = mapped
+			}
+		}
+	case opUseProgram:
+		recorded := d.readUint32()
+		if recorded == 0 {
+			p.current = nil
+			b.UseProgram(0)
+			break
+		}
+		prog := p.programs[recorded]
+		if prog == nil {
+			return fmt.Errorf("unrecognized program")
+		}
+		p.current = prog
+		b.UseProgram(prog.id)
+	case opDeleteProgram:
+		recorded := d.readUint32()
+		prog := p.programs[recorded]
+		if prog == nil {
+			return fmt.Errorf("unrecognized program")
+		}
+		if p.current == prog {
+			p.current = nil
+		}
+		b.DeleteProgram(prog.id)
+		delete(p.programs, recorded)
+	case opUniformBlockBinding:
+		prog := p.programs[d.readUint32()]
+		index := d.readUint32()
+		binding := d.readUint32()
+		if prog == nil {
+			return fmt.Errorf("unrecognized program")
+		}
+		if mapped, ok := prog.indices[index]; ok {
+			b.UniformBlockBinding(prog.id, mapped, binding)
+		}
+
+	// uniforms
+	case opUniform1i:
+		location := p.location(d.readInt32())
+		b.Uniform1i(location, d.readInt32())
+	case opUniform1ui:
+		location := p.location(d.readInt32())
+		b.Uniform1ui(location, d.readUint32())
+	case opUniform1f:
+		location := p.location(d.readInt32())
+		b.Uniform1f(location, d.readFloat32())
+	case opUniform1iv:
+		location := p.location(d.readInt32())
+		values := d.readInt32s()
+		if len(values) > 0 {
+			b.Uniform1iv(location, int32(len(values)), &values[0])
+		}
+	case opUniform1uiv:
+		location := p.location(d.readInt32())
+		values := d.readUint32s()
+		if len(values) > 0 {
+			b.Uniform1uiv(location, int32(len(values)), &values[0])
+		}
+	case opUniform1fv,
+		opUniform2fv,
+		opUniform3fv,
+		opUniform4fv,
+		opUniformMatrix3fv,
+		opUniformMatrix4fv:
+		location := p.location(d.readInt32())
+		count := d.readInt32()
+		values := d.readFloat32s()
+		if len(values) == 0 {
+			break
+		}
+		switch op {
+		case opUniform1fv:
+			b.Uniform1fv(location, count, &values[0])
+		case opUniform2fv:
+			b.Uniform2fv(location, count, &values[0])
+		case opUniform3fv:
+			b.Uniform3fv(location, count, &values[0])
+		case opUniform4fv:
+			b.Uniform4fv(location, count, &values[0])
+		case opUniformMatrix3fv:
+			b.UniformMatrix3fv(location, count, &values[0])
+		case opUniformMatrix4fv:
+			b.UniformMatrix4fv(location, count, &values[0])
+		}
+
+	// buffers
+	case opCreateBuffer:
+		p.buffers[d.readUint32()] = b.CreateBuffer()
+	case opBindBuffer:
+		target := d.readUint32()
+		b.BindBuffer(target, p.buffers[d.readUint32()])
+	case opBufferData:
+		target := d.readUint32()
+		size := d.readInt()
+		data := d.readPointer()
+		usage := d.readUint32()
+		if d.err != nil {
+			return d.err
+		}
+		b.BufferData(target, size, data, usage)
+	case opBufferSubData:
+		target := d.readUint32()
+		offset := d.readInt()
+		size := d.readInt()
+		data := d.readPointer()
+		if d.err != nil {
+			return d.err
+		}
+		b.BufferSubData(target, offset, size, data)
+	case opBindBufferBase:
+		target := d.readUint32()
+		index := d.readUint32()
+		b.BindBufferBase(target, index, p.buffers[d.readUint32()])
+	case opDeleteBuffer:
+		recorded := d.readUint32()
+		b.DeleteBuffer(p.buffers[recorded])
+		delete(p.buffers, recorded)
+
+	// vertex arrays
+	case opCreateVertexArray:
+		p.arrays[d.readUint32()] = b.CreateVertexArray()
+	case opBindVertexArray:
+		b.BindVertexArray(p.arrays[d.readUint32()])
+	case opEnableVertexAttribArray:
+		b.EnableVertexAttribArray(d.readUint32())
+	case opVertexAttribPointer:
+		index := d.readUint32()
+		size := d.readInt32()
+		typ := d.readUint32()
+		normalized := d.readBool()
+		stride := d.readInt32()
+		offset := d.readInt()
+		b.VertexAttribPointer(index, size, typ, normalized, stride, offset)
+	case opVertexAttribDivisor:
+		index := d.readUint32()
+		b.VertexAttribDivisor(index, d.readUint32())
+	case opDeleteVertexArray:
+		recorded := d.readUint32()
+		b.DeleteVertexArray(p.arrays[recorded])
+		delete(p.arrays, recorded)
+
+	// textures
+	case opCreateTexture:
+		p.textures[d.readUint32()] = b.CreateTexture()
+	case opActiveTexture:
+		b.ActiveTexture(d.readUint32())
+	case opBindTexture:
+		target := d.readUint32()
+		b.BindTexture(target, p.textures[d.readUint32()])
+	case opTexParameteri:
+		target := d.readUint32()
+		pname := d.readUint32()
+		b.TexParameteri(target, pname, d.readInt32())
+	case opTexImage2D:
+		target := d.readUint32()
+		level := d.readInt32()
+		internalFormat := d.readInt32()
+		width := d.readInt32()
+		height := d.readInt32()
+		format := d.readUint32()
+		typ := d.readUint32()
+		data := d.readPointer()
+		if d.err != nil {
+			return d.err
+		}
+		b.TexImage2D(target, level, internalFormat, width, height, format, typ, data)
+	case opGenerateMipmap:
+		b.GenerateMipmap(d.readUint32())
+	case opDeleteTexture:
+		recorded := d.readUint32()
+		b.DeleteTexture(p.textures[recorded])
+		delete(p.textures, recorded)
+
+	// framebuffers
+	case opCreateFramebuffer:
+		p.fbos[d.readUint32()] = b.CreateFramebuffer()
+	case opBindFramebuffer:
+		target := d.readUint32()
+		b.BindFramebuffer(target, p.fbos[d.readUint32()])
+	case opFramebufferTexture2D:
+		target := d.readUint32()
+		attachment := d.readUint32()
+		textarget := d.readUint32()
+		texture := p.textures[d.readUint32()]
+		level := d.readInt32()
+		b.FramebufferTexture2D(target, attachment, textarget, texture, level)
+	case opDrawBuffers:
+		b.DrawBuffers(d.readUint32s())
+	case opDeleteFramebuffer:
+		recorded := d.readUint32()
+		b.DeleteFramebuffer(p.fbos[recorded])
+		delete(p.fbos, recorded)
+
+	// draw calls
+	case opPatchParameteri:
+		pname := d.readUint32()
+		b.PatchParameteri(pname, d.readInt32())
+	case opBeginTransformFeedback:
+		b.BeginTransformFeedback(d.readUint32())
+	case opEndTransformFeedback:
+		b.EndTransformFeedback()
+	case opDrawArrays:
+		mode := d.readUint32()
+		first := d.readInt32()
+		b.DrawArrays(mode, first, d.readInt32())
+	case opDrawArraysInstanced:
+		mode := d.readUint32()
+		first := d.readInt32()
+		count := d.readInt32()
+		b.DrawArraysInstanced(mode, first, count, d.readInt32())
+	case opDrawElements:
+		mode := d.readUint32()
+		count := d.readInt32()
+		typ := d.readUint32()
+		b.DrawElements(mode, count, typ, d.readInt())
+	case opDrawElementsInstanced:
+		mode := d.readUint32()
+		count := d.readInt32()
+		typ := d.readUint32()
+		byteOffset := d.readInt()
+		b.DrawElementsInstanced(mode, count, typ, byteOffset, d.readInt32())
+
+	// markers
+	case opBeginTechnique:
+		marker := d.readTechniqueMarker()
+		if d.err != nil {
+			return d.err
+		}
+		b.BeginTechnique(p.remapTechnique(marker))
+	case opEndTechnique:
+		b.EndTechnique()
+	case opBeginCommand:
+		marker := d.readCommandMarker()
+		if d.err != nil {
+			return d.err
+		}
+		b.BeginCommand(p.remapCommand(marker))
+	case opEndCommand:
+		b.EndCommand()
+
+	default:
+		return fmt.Errorf("unrecognized opcode %d", op)
+	}
+	return nil
+}
+
+// remapTechnique renames the objects of a recorded technique marker to those
+// of the replaying backend.
+func (p *Player) remapTechnique(marker *render.TechniqueMarker) *render.TechniqueMarker {
+	if program, ok := p.programs[marker.Program]; ok {
+		marker.Program = program.id
+	}
+	marker.Framebuffer = p.fbos[marker.Framebuffer]
+	p.remapBuffers(marker.UniformBuffers)
+	return marker
+}
+
+// remapCommand renames the objects of a recorded command marker to those of
+// the replaying backend.
+func (p *Player) remapCommand(marker *render.CommandMarker) *render.CommandMarker {
+	for i := range marker.Textures {
+		marker.Textures[i].Texture = p.textures[marker.Textures[i].Texture]
+	}
+	p.remapBuffers(marker.UniformBuffers)
+	marker.VertexArray = p.arrays[marker.VertexArray]
+	return marker
+}
+
+func (p *Player) remapBuffers(buffers []render.MarkerBuffer) {
+	for i := range buffers {
+		buffers[i].Buffer = p.buffers[buffers[i].Buffer]
+	}
+}
@@ -0,0 +1,83 @@
+package trace
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kbirk/cauldron/render/software"
+)
+
+// encodeTrace returns a trace of the provided version holding a single
+// frame of the calls written by the provided function.
+func encodeTrace(version uint32, write func(enc *encoder)) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	enc := newEncoder(buf)
+	enc.w.WriteString(magic)
+	enc.writeUint32(version)
+	enc.writeInt32(4)
+	enc.writeInt32(4)
+	write(enc)
+	enc.writeOpcode(opEndFrame)
+	enc.flush()
+	return buf
+}
+
+func TestPlayerVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version uint32
+		write   func(enc *encoder)
+		err     string
+	}{
+		{
+			name:    "current version",
+			version: Version,
+			write: func(enc *encoder) {
+				enc.writeOpcode(opClearStencil)
+				enc.writeInt32(1)
+			},
+		},
+		{
+			name:    "older version",
+			version: Version - 1,
+			err:     "unsupported trace version",
+		},
+		{
+			name:    "newer version",
+			version: Version + 1,
+			err:     "unsupported trace version",
+		},
+		{
+			name:    "unknown opcode",
+			version: Version,
+			write: func(enc *encoder) {
+				enc.writeOpcode(numOpcodes)
+			},
+			err: "unrecognized opcode",
+		},
+	}
+	for _, test := range tests {
+		write := test.write
+		if write == nil {
+			write = func(enc *encoder) {}
+		}
+		player, err := NewPlayer(encodeTrace(test.version, write), software.NewBackend(4, 4))
+		if err == nil {
+			err = player.ReplayFrame()
+		}
+		if err == nil {
+			err = player.ReplayFrame()
+			if err == io.EOF {
+				err = nil
+			}
+		}
+		if test.err == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.err, err)
+		}
+	}
+}
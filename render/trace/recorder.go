@@ -0,0 +1,778 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// Recorder represents a backend that records every call that mutates render
+// state to a trace before forwarding it to the underlying backend. Since all
+// technique state, command uniforms, texture binds, renderables and buffer
+// uploads are issued through the backend, the trace holds everything required
+// to reproduce a frame. The technique and command markers issued around each
+// draw are recorded as well.
+//
+// The recorder must be installed with render.SetBackend before any render
+// objects are created, otherwise the trace will reference objects it never
+// saw created.
+type Recorder struct {
+	backend render.Backend
+	enc     *encoder
+	frames  int
+	err     error
+}
+
+// NewRecorder instantiates a new recorder forwarding to the provided backend
+// and writing the trace header to the provided writer. The width and height
+// are the size of the default framebuffer being rendered to.
+func NewRecorder(backend render.Backend, w io.Writer, width int, height int) (*Recorder, error) {
+	r := &Recorder{
+		backend: backend,
+		enc:     newEncoder(w),
+	}
+	r.enc.w.WriteString(magic)
+	r.enc.writeUint32(Version)
+	r.enc.writeInt32(int32(width))
+	r.enc.writeInt32(int32(height))
+	err := r.enc.flush()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write trace header: %v", err)
+	}
+	return r, nil
+}
+
+// EndFrame marks the end of the current frame and flushes it to the
+// underlying writer. It returns the first error encountered while recording.
+func (r *Recorder) EndFrame() error {
+	r.enc.writeOpcode(opEndFrame)
+	err := r.enc.flush()
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("failed to write trace frame %d: %v", r.frames, err)
+	}
+	r.frames++
+	return r.err
+}
+
+// Frames returns the number of frames recorded.
+func (r *Recorder) Frames() int {
+	return r.frames
+}
+
+// GetError queries the underlying backend, queries are not recorded.
+func (r *Recorder) GetError() uint32 {
+	return r.backend.GetError()
+}
+
+// GetString queries the underlying backend, queries are not recorded.
+func (r *Recorder) GetString(name uint32) string {
+	return r.backend.GetString(name)
+}
+
+// Enable enables the provided state.
+func (r *Recorder) Enable(state uint32) {
+	r.enc.writeOpcode(opEnable)
+	r.enc.writeUint32(state)
+	r.backend.Enable(state)
+}
+
+// Disable disables the provided state.
+func (r *Recorder) Disable(state uint32) {
+	r.enc.writeOpcode(opDisable)
+	r.enc.writeUint32(state)
+	r.backend.Disable(state)
+}
+
+// BlendFunc sets the blend function.
+func (r *Recorder) BlendFunc(sfactor uint32, dfactor uint32) {
+	r.enc.writeOpcode(opBlendFunc)
+	r.enc.writeUint32(sfactor)
+	r.enc.writeUint32(dfactor)
+	r.backend.BlendFunc(sfactor, dfactor)
+}
+
+// CullFace sets the cull face mode.
+func (r *Recorder) CullFace(mode uint32) {
+	r.enc.writeOpcode(opCullFace)
+	r.enc.writeUint32(mode)
+	r.backend.CullFace(mode)
+}
+
+// DepthMask sets the depth mask.
+func (r *Recorder) DepthMask(flag bool) {
+	r.enc.writeOpcode(opDepthMask)
+	r.enc.writeBool(flag)
+	r.backend.DepthMask(flag)
+}
+
+// DepthFunc sets the depth function.
+func (r *Recorder) DepthFunc(xfunc uint32) {
+	r.enc.writeOpcode(opDepthFunc)
+	r.enc.writeUint32(xfunc)
+	r.backend.DepthFunc(xfunc)
+}
+
+// BlendFuncSeparate sets the blend function of the RGB and alpha components.
+func (r *Recorder) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32, dstAlpha uint32) {
+	r.enc.writeOpcode(opBlendFuncSeparate)
+	r.enc.writeUint32(srcRGB)
+	r.enc.writeUint32(dstRGB)
+	r.enc.writeUint32(srcAlpha)
+	r.enc.writeUint32(dstAlpha)
+	r.backend.BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha)
+}
+
+// BlendEquationSeparate sets the blend equations.
+func (r *Recorder) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
+	r.enc.writeOpcode(opBlendEquationSeparate)
+	r.enc.writeUint32(modeRGB)
+	r.enc.writeUint32(modeAlpha)
+	r.backend.BlendEquationSeparate(modeRGB, modeAlpha)
+}
+
+// BlendColor sets the constant blend color.
+func (r *Recorder) BlendColor(red float32, green float32, blue float32, alpha float32) {
+	r.enc.writeOpcode(opBlendColor)
+	r.enc.writeFloat32(red)
+	r.enc.writeFloat32(green)
+	r.enc.writeFloat32(blue)
+	r.enc.writeFloat32(alpha)
+	r.backend.BlendColor(red, green, blue, alpha)
+}
+
+// ColorMask sets the color mask.
+func (r *Recorder) ColorMask(red bool, green bool, blue bool, alpha bool) {
+	r.enc.writeOpcode(opColorMask)
+	r.enc.writeBool(red)
+	r.enc.writeBool(green)
+	r.enc.writeBool(blue)
+	r.enc.writeBool(alpha)
+	r.backend.ColorMask(red, green, blue, alpha)
+}
+
+// StencilFunc sets the stencil function.
+func (r *Recorder) StencilFunc(xfunc uint32, ref int32, mask uint32) {
+	r.enc.writeOpcode(opStencilFunc)
+	r.enc.writeUint32(xfunc)
+	r.enc.writeInt32(ref)
+	r.enc.writeUint32(mask)
+	r.backend.StencilFunc(xfunc, ref, mask)
+}
+
+// StencilOp sets the stencil actions.
+func (r *Recorder) StencilOp(sfail uint32, dpfail uint32, dppass uint32) {
+	r.enc.writeOpcode(opStencilOp)
+	r.enc.writeUint32(sfail)
+	r.enc.writeUint32(dpfail)
+	r.enc.writeUint32(dppass)
+	r.backend.StencilOp(sfail, dpfail, dppass)
+}
+
+// StencilMask sets the stencil write mask.
+func (r *Recorder) StencilMask(mask uint32) {
+	r.enc.writeOpcode(opStencilMask)
+	r.enc.writeUint32(mask)
+	r.backend.StencilMask(mask)
+}
+
+// Scissor sets the scissor rectangle.
+func (r *Recorder) Scissor(x int32, y int32, width int32, height int32) {
+	r.enc.writeOpcode(opScissor)
+	r.enc.writeInt32(x)
+	r.enc.writeInt32(y)
+	r.enc.writeInt32(width)
+	r.enc.writeInt32(height)
+	r.backend.Scissor(x, y, width, height)
+}
+
+// PolygonOffset sets the depth offset.
+func (r *Recorder) PolygonOffset(factor float32, units float32) {
+	r.enc.writeOpcode(opPolygonOffset)
+	r.enc.writeFloat32(factor)
+	r.enc.writeFloat32(units)
+	r.backend.PolygonOffset(factor, units)
+}
+
+// LineWidth sets the line width.
+func (r *Recorder) LineWidth(width float32) {
+	r.enc.writeOpcode(opLineWidth)
+	r.enc.writeFloat32(width)
+	r.backend.LineWidth(width)
+}
+
+// PolygonMode sets the polygon rasterization mode.
+func (r *Recorder) PolygonMode(face uint32, mode uint32) {
+	r.enc.writeOpcode(opPolygonMode)
+	r.enc.writeUint32(face)
+	r.enc.writeUint32(mode)
+	r.backend.PolygonMode(face, mode)
+}
+
+// Viewport sets the viewport.
+func (r *Recorder) Viewport(x int32, y int32, width int32, height int32) {
+	r.enc.writeOpcode(opViewport)
+	r.enc.writeInt32(x)
+	r.enc.writeInt32(y)
+	r.enc.writeInt32(width)
+	r.enc.writeInt32(height)
+	r.backend.Viewport(x, y, width, height)
+}
+
+// ClearColor sets the clear color.
+func (r *Recorder) ClearColor(red float32, green float32, blue float32, alpha float32) {
+	r.enc.writeOpcode(opClearColor)
+	r.enc.writeFloat32(red)
+	r.enc.writeFloat32(green)
+	r.enc.writeFloat32(blue)
+	r.enc.writeFloat32(alpha)
+	r.backend.ClearColor(red, green, blue, alpha)
+}
+
+// ClearDepth sets the clear depth.
+func (r *Recorder) ClearDepth(depth float32) {
+	r.enc.writeOpcode(opClearDepth)
+	r.enc.writeFloat32(depth)
+	r.backend.ClearDepth(depth)
+}
+
+// ClearStencil sets the clear stencil value.
+func (r *Recorder) ClearStencil(s int32) {
+	r.enc.writeOpcode(opClearStencil)
+	r.enc.writeInt32(s)
+	r.backend.ClearStencil(s)
+}
+
+// Clear clears the provided buffers.
+func (r *Recorder) Clear(mask uint32) {
+	r.enc.writeOpcode(opClear)
+	r.enc.writeUint32(mask)
+	r.backend.Clear(mask)
+}
+
+// CreateShader creates and compiles a shader object. The source is recorded
+// even if compilation fails.
+func (r *Recorder) CreateShader(typ uint32, source string) (uint32, error) {
+	shader, err := r.backend.CreateShader(typ, source)
+	r.enc.writeOpcode(opCreateShader)
+	r.enc.writeUint32(shader)
+	r.enc.writeUint32(typ)
+	r.enc.writeString(source)
+	return shader, err
+}
+
+// DeleteShader deletes a shader object.
+func (r *Recorder) DeleteShader(shader uint32) {
+	r.enc.writeOpcode(opDeleteShader)
+	r.enc.writeUint32(shader)
+	r.backend.DeleteShader(shader)
+}
+
+// CreateProgram creates a shader program.
+func (r *Recorder) CreateProgram() uint32 {
+	program := r.backend.CreateProgram()
+	r.enc.writeOpcode(opCreateProgram)
+	r.enc.writeUint32(program)
+	return program
+}
+
+// AttachShader attaches a shader object to a program.
+func (r *Recorder) AttachShader(program uint32, shader uint32) {
+	r.enc.writeOpcode(opAttachShader)
+	r.enc.writeUint32(program)
+	r.enc.writeUint32(shader)
+	r.backend.AttachShader(program, shader)
+}
+
+// DetachShader detaches a shader object from a program.
+func (r *Recorder) DetachShader(program uint32, shader uint32) {
+	r.enc.writeOpcode(opDetachShader)
+	r.enc.writeUint32(program)
+	r.enc.writeUint32(shader)
+	r.backend.DetachShader(program, shader)
+}
+
+// TransformFeedbackVaryings specifies the varyings captured by transform
+// feedback.
+func (r *Recorder) TransformFeedbackVaryings(program uint32, varyings []string, bufferMode uint32) {
+	r.enc.writeOpcode(opTransformFeedbackVaryings)
+	r.enc.writeUint32(program)
+	r.enc.writeUint32(uint32(len(varyings)))
+	for _, varying := range varyings {
+		r.enc.writeString(varying)
+	}
+	r.enc.writeUint32(bufferMode)
+	r.backend.TransformFeedbackVaryings(program, varyings, bufferMode)
+}
+
+// LinkProgram links a program. On success the uniform locations and block
+// indices of the program are recorded so they can be remapped on replay.
+func (r *Recorder) LinkProgram(program uint32) error {
+	err := r.backend.LinkProgram(program)
+	r.enc.writeOpcode(opLinkProgram)
+	r.enc.writeUint32(program)
+	r.enc.writeBool(err == nil)
+	if err != nil {
+		return err
+	}
+	uniforms := r.backend.ActiveUniforms(program)
+	blocks := r.backend.ActiveUniformBlocks(program)
+	r.enc.writeOpcode(opProgramUniforms)
+	r.enc.writeUint32(program)
+	r.enc.writeUint32(uint32(len(uniforms)))
+	for _, uniform := range uniforms {
+		r.enc.writeString(uniform.Name)
+		r.enc.writeInt32(uniform.Location)
+	}
+	r.enc.writeUint32(uint32(len(blocks)))
+	for _, block := range blocks {
+		r.enc.writeString(block.Name)
+		r.enc.writeUint32(block.Index)
+	}
+	return nil
+}
+
+// GetProgramBinary queries the underlying backend, queries are not recorded.
+func (r *Recorder) GetProgramBinary(program uint32) (uint32, []byte, error) {
+	return r.backend.GetProgramBinary(program)
+}
+
+// ProgramBinary always fails. Binaries are specific to the recording driver,
+// so programs must be linked from source to replay elsewhere.
+func (r *Recorder) ProgramBinary(program uint32, format uint32, binary []byte) error {
+	return errors.New("program binaries are not recorded")
+}
+
+// UseProgram activates a program.
+func (r *Recorder) UseProgram(program uint32) {
+	r.enc.writeOpcode(opUseProgram)
+	r.enc.writeUint32(program)
+	r.backend.UseProgram(program)
+}
+
+// DeleteProgram deletes a program.
+func (r *Recorder) DeleteProgram(program uint32) {
+	r.enc.writeOpcode(opDeleteProgram)
+	r.enc.writeUint32(program)
+	r.backend.DeleteProgram(program)
+}
+
+// ActiveAttributes queries the underlying backend, queries are not recorded.
+func (r *Recorder) ActiveAttributes(program uint32) []*render.AttributeDescriptor {
+	return r.backend.ActiveAttributes(program)
+}
+
+// ActiveUniforms queries the underlying backend, queries are not recorded.
+func (r *Recorder) ActiveUniforms(program uint32) []*render.UniformDescriptor {
+	return r.backend.ActiveUniforms(program)
+}
+
+// ActiveUniformBlocks queries the underlying backend, queries are not
+// recorded.
+func (r *Recorder) ActiveUniformBlocks(program uint32) []*render.UniformBlockDescriptor {
+	return r.backend.ActiveUniformBlocks(program)
+}
+
+// UniformBlockBinding assigns a binding point to a uniform block.
+func (r *Recorder) UniformBlockBinding(program uint32, index uint32, binding uint32) {
+	r.enc.writeOpcode(opUniformBlockBinding)
+	r.enc.writeUint32(program)
+	r.enc.writeUint32(index)
+	r.enc.writeUint32(binding)
+	r.backend.UniformBlockBinding(program, index, binding)
+}
+
+// Uniform1i buffers a int32 by value.
+func (r *Recorder) Uniform1i(location int32, value int32) {
+	r.enc.writeOpcode(opUniform1i)
+	r.enc.writeInt32(location)
+	r.enc.writeInt32(value)
+	r.backend.Uniform1i(location, value)
+}
+
+// Uniform1ui buffers a uint32 by value.
+func (r *Recorder) Uniform1ui(location int32, value uint32) {
+	r.enc.writeOpcode(opUniform1ui)
+	r.enc.writeInt32(location)
+	r.enc.writeUint32(value)
+	r.backend.Uniform1ui(location, value)
+}
+
+// Uniform1f buffers a float32 by value.
+func (r *Recorder) Uniform1f(location int32, value float32) {
+	r.enc.writeOpcode(opUniform1f)
+	r.enc.writeInt32(location)
+	r.enc.writeFloat32(value)
+	r.backend.Uniform1f(location, value)
+}
+
+// Uniform1iv buffers one or more int32 by address.
+func (r *Recorder) Uniform1iv(location int32, count int32, value *int32) {
+	r.enc.writeOpcode(opUniform1iv)
+	r.enc.writeInt32(location)
+	r.enc.writeInt32s(value, int(count))
+	r.backend.Uniform1iv(location, count, value)
+}
+
+// Uniform1uiv buffers one or more uint32 by address.
+func (r *Recorder) Uniform1uiv(location int32, count int32, value *uint32) {
+	r.enc.writeOpcode(opUniform1uiv)
+	r.enc.writeInt32(location)
+	r.enc.writeUint32s(value, int(count))
+	r.backend.Uniform1uiv(location, count, value)
+}
+
+// Uniform1fv buffers one or more float32 by address.
+func (r *Recorder) Uniform1fv(location int32, count int32, value *float32) {
+	r.recordFloats(opUniform1fv, location, count, 1, value)
+	r.backend.Uniform1fv(location, count, value)
+}
+
+// Uniform2fv buffers one or more 2-component float32 by address.
+func (r *Recorder) Uniform2fv(location int32, count int32, value *float32) {
+	r.recordFloats(opUniform2fv, location, count, 2, value)
+	r.backend.Uniform2fv(location, count, value)
+}
+
+// Uniform3fv buffers one or more 3-component float32 by address.
+func (r *Recorder) Uniform3fv(location int32, count int32, value *float32) {
+	r.recordFloats(opUniform3fv, location, count, 3, value)
+	r.backend.Uniform3fv(location, count, value)
+}
+
+// Uniform4fv buffers one or more 4-component float32 by address.
+func (r *Recorder) Uniform4fv(location int32, count int32, value *float32) {
+	r.recordFloats(opUniform4fv, location, count, 4, value)
+	r.backend.Uniform4fv(location, count, value)
+}
+
+// UniformMatrix3fv buffers one or more 9-component float32 by address.
+func (r *Recorder) UniformMatrix3fv(location int32, count int32, value *float32) {
+	r.recordFloats(opUniformMatrix3fv, location, count, 9, value)
+	r.backend.UniformMatrix3fv(location, count, value)
+}
+
+// UniformMatrix4fv buffers one or more 16-component float32 by address.
+func (r *Recorder) UniformMatrix4fv(location int32, count int32, value *float32) {
+	r.recordFloats(opUniformMatrix4fv, location, count, 16, value)
+	r.backend.UniformMatrix4fv(location, count, value)
+}
+
+func (r *Recorder) recordFloats(op opcode, location int32, count int32, components int, value *float32) {
+	r.enc.writeOpcode(op)
+	r.enc.writeInt32(location)
+	r.enc.writeInt32(count)
+	r.enc.writeFloat32s(value, int(count)*components)
+}
+
+// CreateBuffer creates a buffer object.
+func (r *Recorder) CreateBuffer() uint32 {
+	buffer := r.backend.CreateBuffer()
+	r.enc.writeOpcode(opCreateBuffer)
+	r.enc.writeUint32(buffer)
+	return buffer
+}
+
+// BindBuffer binds a buffer object to the provided target.
+func (r *Recorder) BindBuffer(target uint32, buffer uint32) {
+	r.enc.writeOpcode(opBindBuffer)
+	r.enc.writeUint32(target)
+	r.enc.writeUint32(buffer)
+	r.backend.BindBuffer(target, buffer)
+}
+
+// BufferData allocates and optionally uploads the bound buffer.
+func (r *Recorder) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	r.enc.writeOpcode(opBufferData)
+	r.enc.writeUint32(target)
+	r.enc.writeInt(size)
+	r.enc.writePointer(data, size)
+	r.enc.writeUint32(usage)
+	r.backend.BufferData(target, size, data, usage)
+}
+
+// BufferSubData uploads a portion of the bound buffer.
+func (r *Recorder) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	r.enc.writeOpcode(opBufferSubData)
+	r.enc.writeUint32(target)
+	r.enc.writeInt(offset)
+	r.enc.writeInt(size)
+	r.enc.writePointer(data, size)
+	r.backend.BufferSubData(target, offset, size, data)
+}
+
+// BindBufferBase binds a buffer object to an indexed binding point.
+func (r *Recorder) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	r.enc.writeOpcode(opBindBufferBase)
+	r.enc.writeUint32(target)
+	r.enc.writeUint32(index)
+	r.enc.writeUint32(buffer)
+	r.backend.BindBufferBase(target, index, buffer)
+}
+
+// DeleteBuffer deletes a buffer object.
+func (r *Recorder) DeleteBuffer(buffer uint32) {
+	r.enc.writeOpcode(opDeleteBuffer)
+	r.enc.writeUint32(buffer)
+	r.backend.DeleteBuffer(buffer)
+}
+
+// CreateVertexArray creates a vertex array object.
+func (r *Recorder) CreateVertexArray() uint32 {
+	array := r.backend.CreateVertexArray()
+	r.enc.writeOpcode(opCreateVertexArray)
+	r.enc.writeUint32(array)
+	return array
+}
+
+// BindVertexArray binds a vertex array object.
+func (r *Recorder) BindVertexArray(array uint32) {
+	r.enc.writeOpcode(opBindVertexArray)
+	r.enc.writeUint32(array)
+	r.backend.BindVertexArray(array)
+}
+
+// EnableVertexAttribArray enables a vertex attribute.
+func (r *Recorder) EnableVertexAttribArray(index uint32) {
+	r.enc.writeOpcode(opEnableVertexAttribArray)
+	r.enc.writeUint32(index)
+	r.backend.EnableVertexAttribArray(index)
+}
+
+// VertexAttribPointer sets the layout of a vertex attribute.
+func (r *Recorder) VertexAttribPointer(index uint32, size int32, typ uint32, normalized bool, stride int32, offset int) {
+	r.enc.writeOpcode(opVertexAttribPointer)
+	r.enc.writeUint32(index)
+	r.enc.writeInt32(size)
+	r.enc.writeUint32(typ)
+	r.enc.writeBool(normalized)
+	r.enc.writeInt32(stride)
+	r.enc.writeInt(offset)
+	r.backend.VertexAttribPointer(index, size, typ, normalized, stride, offset)
+}
+
+// VertexAttribDivisor sets the instancing divisor of a vertex attribute.
+func (r *Recorder) VertexAttribDivisor(index uint32, divisor uint32) {
+	r.enc.writeOpcode(opVertexAttribDivisor)
+	r.enc.writeUint32(index)
+	r.enc.writeUint32(divisor)
+	r.backend.VertexAttribDivisor(index, divisor)
+}
+
+// DeleteVertexArray deletes a vertex array object.
+func (r *Recorder) DeleteVertexArray(array uint32) {
+	r.enc.writeOpcode(opDeleteVertexArray)
+	r.enc.writeUint32(array)
+	r.backend.DeleteVertexArray(array)
+}
+
+// CreateTexture creates a texture object.
+func (r *Recorder) CreateTexture() uint32 {
+	texture := r.backend.CreateTexture()
+	r.enc.writeOpcode(opCreateTexture)
+	r.enc.writeUint32(texture)
+	return texture
+}
+
+// ActiveTexture activates a texture unit.
+func (r *Recorder) ActiveTexture(unit uint32) {
+	r.enc.writeOpcode(opActiveTexture)
+	r.enc.writeUint32(unit)
+	r.backend.ActiveTexture(unit)
+}
+
+// BindTexture binds a texture object to the active unit.
+func (r *Recorder) BindTexture(target uint32, texture uint32) {
+	r.enc.writeOpcode(opBindTexture)
+	r.enc.writeUint32(target)
+	r.enc.writeUint32(texture)
+	r.backend.BindTexture(target, texture)
+}
+
+// TexParameteri sets a parameter of the bound texture.
+func (r *Recorder) TexParameteri(target uint32, pname uint32, param int32) {
+	r.enc.writeOpcode(opTexParameteri)
+	r.enc.writeUint32(target)
+	r.enc.writeUint32(pname)
+	r.enc.writeInt32(param)
+	r.backend.TexParameteri(target, pname, param)
+}
+
+// TexImage2D allocates and optionally uploads the bound texture.
+func (r *Recorder) TexImage2D(target uint32, level int32, internalFormat int32, width int32, height int32, format uint32, typ uint32, data unsafe.Pointer) {
+	size, err := pixelDataSize(width, height, format, typ)
+	if err != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("failed to record texture upload: %v", err)
+		}
+		// record the allocation without its contents
+		data = nil
+	}
+	r.enc.writeOpcode(opTexImage2D)
+	r.enc.writeUint32(target)
+	r.enc.writeInt32(level)
+	r.enc.writeInt32(internalFormat)
+	r.enc.writeInt32(width)
+	r.enc.writeInt32(height)
+	r.enc.writeUint32(format)
+	r.enc.writeUint32(typ)
+	r.enc.writePointer(data, size)
+	r.backend.TexImage2D(target, level, internalFormat, width, height, format, typ, data)
+}
+
+// GenerateMipmap generates mipmaps for the bound texture.
+func (r *Recorder) GenerateMipmap(target uint32) {
+	r.enc.writeOpcode(opGenerateMipmap)
+	r.enc.writeUint32(target)
+	r.backend.GenerateMipmap(target)
+}
+
+// DeleteTexture deletes a texture object.
+func (r *Recorder) DeleteTexture(texture uint32) {
+	r.enc.writeOpcode(opDeleteTexture)
+	r.enc.writeUint32(texture)
+	r.backend.DeleteTexture(texture)
+}
+
+// CreateFramebuffer creates a framebuffer object.
+func (r *Recorder) CreateFramebuffer() uint32 {
+	framebuffer := r.backend.CreateFramebuffer()
+	r.enc.writeOpcode(opCreateFramebuffer)
+	r.enc.writeUint32(framebuffer)
+	return framebuffer
+}
+
+// BindFramebuffer binds a framebuffer object.
+func (r *Recorder) BindFramebuffer(target uint32, framebuffer uint32) {
+	r.enc.writeOpcode(opBindFramebuffer)
+	r.enc.writeUint32(target)
+	r.enc.writeUint32(framebuffer)
+	r.backend.BindFramebuffer(target, framebuffer)
+}
+
+// FramebufferTexture2D attaches a texture to the bound framebuffer.
+func (r *Recorder) FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	r.enc.writeOpcode(opFramebufferTexture2D)
+	r.enc.writeUint32(target)
+	r.enc.writeUint32(attachment)
+	r.enc.writeUint32(textarget)
+	r.enc.writeUint32(texture)
+	r.enc.writeInt32(level)
+	r.backend.FramebufferTexture2D(target, attachment, textarget, texture, level)
+}
+
+// CheckFramebufferStatus queries the underlying backend, queries are not
+// recorded.
+func (r *Recorder) CheckFramebufferStatus(target uint32) uint32 {
+	return r.backend.CheckFramebufferStatus(target)
+}
+
+// DrawBuffers sets the draw buffers of the bound framebuffer.
+func (r *Recorder) DrawBuffers(buffers []uint32) {
+	r.enc.writeOpcode(opDrawBuffers)
+	r.enc.writeUint32(uint32(len(buffers)))
+	for _, buffer := range buffers {
+		r.enc.writeUint32(buffer)
+	}
+	r.backend.DrawBuffers(buffers)
+}
+
+// DeleteFramebuffer deletes a framebuffer object.
+func (r *Recorder) DeleteFramebuffer(framebuffer uint32) {
+	r.enc.writeOpcode(opDeleteFramebuffer)
+	r.enc.writeUint32(framebuffer)
+	r.backend.DeleteFramebuffer(framebuffer)
+}
+
+// PatchParameteri sets a parameter of patch primitives.
+func (r *Recorder) PatchParameteri(pname uint32, value int32) {
+	r.enc.writeOpcode(opPatchParameteri)
+	r.enc.writeUint32(pname)
+	r.enc.writeInt32(value)
+	r.backend.PatchParameteri(pname, value)
+}
+
+// BeginTransformFeedback starts capturing varyings.
+func (r *Recorder) BeginTransformFeedback(primitiveMode uint32) {
+	r.enc.writeOpcode(opBeginTransformFeedback)
+	r.enc.writeUint32(primitiveMode)
+	r.backend.BeginTransformFeedback(primitiveMode)
+}
+
+// EndTransformFeedback stops capturing varyings.
+func (r *Recorder) EndTransformFeedback() {
+	r.enc.writeOpcode(opEndTransformFeedback)
+	r.backend.EndTransformFeedback()
+}
+
+// DrawArrays renders primitives from the bound vertex array.
+func (r *Recorder) DrawArrays(mode uint32, first int32, count int32) {
+	r.enc.writeOpcode(opDrawArrays)
+	r.enc.writeUint32(mode)
+	r.enc.writeInt32(first)
+	r.enc.writeInt32(count)
+	r.backend.DrawArrays(mode, first, count)
+}
+
+// DrawArraysInstanced renders multiple instances of primitives from the bound
+// vertex array.
+func (r *Recorder) DrawArraysInstanced(mode uint32, first int32, count int32, primcount int32) {
+	r.enc.writeOpcode(opDrawArraysInstanced)
+	r.enc.writeUint32(mode)
+	r.enc.writeInt32(first)
+	r.enc.writeInt32(count)
+	r.enc.writeInt32(primcount)
+	r.backend.DrawArraysInstanced(mode, first, count, primcount)
+}
+
+// DrawElements renders indexed primitives from the bound vertex array.
+func (r *Recorder) DrawElements(mode uint32, count int32, typ uint32, byteOffset int) {
+	r.enc.writeOpcode(opDrawElements)
+	r.enc.writeUint32(mode)
+	r.enc.writeInt32(count)
+	r.enc.writeUint32(typ)
+	r.enc.writeInt(byteOffset)
+	r.backend.DrawElements(mode, count, typ, byteOffset)
+}
+
+// DrawElementsInstanced renders multiple instances of indexed primitives from
+// the bound vertex array.
+func (r *Recorder) DrawElementsInstanced(mode uint32, count int32, typ uint32, byteOffset int, primcount int32) {
+	r.enc.writeOpcode(opDrawElementsInstanced)
+	r.enc.writeUint32(mode)
+	r.enc.writeInt32(count)
+	r.enc.writeUint32(typ)
+	r.enc.writeInt(byteOffset)
+	r.enc.writeInt32(primcount)
+	r.backend.DrawElementsInstanced(mode, count, typ, byteOffset, primcount)
+}
+
+// BeginTechnique marks the start of a technique draw and records the state
+// it is drawn with.
+func (r *Recorder) BeginTechnique(marker *render.TechniqueMarker) {
+	r.enc.writeOpcode(opBeginTechnique)
+	r.enc.writeTechniqueMarker(marker)
+	r.backend.BeginTechnique(marker)
+}
+
+// EndTechnique marks the end of a technique draw.
+func (r *Recorder) EndTechnique() {
+	r.enc.writeOpcode(opEndTechnique)
+	r.backend.EndTechnique()
+}
+
+// BeginCommand marks the start of a command and records its uniforms and
+// bindings.
+func (r *Recorder) BeginCommand(marker *render.CommandMarker) {
+	r.enc.writeOpcode(opBeginCommand)
+	r.enc.writeCommandMarker(marker)
+	r.backend.BeginCommand(marker)
+}
+
+// EndCommand marks the end of a command.
+func (r *Recorder) EndCommand() {
+	r.enc.writeOpcode(opEndCommand)
+	r.backend.EndCommand()
+}
@@ -0,0 +1,233 @@
+package trace
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/software"
+	"github.com/kbirk/cauldron/shape"
+)
+
+// markerBackend represents a software backend that keeps the markers issued
+// through it and the objects bound while they are open.
+type markerBackend struct {
+	*software.Backend
+	events      []string
+	techniques  []*render.TechniqueMarker
+	commands    []*render.CommandMarker
+	program     uint32
+	vertexArray uint32
+	unit        uint32
+	textures    map[uint32]uint32
+	buffers     map[uint32]uint32
+}
+
+func newMarkerBackend(t *testing.T) *markerBackend {
+	b := &markerBackend{
+		Backend:  software.NewBackend(8, 8),
+		textures: make(map[uint32]uint32),
+		buffers:  make(map[uint32]uint32),
+	}
+	err := b.RegisterDefaultShaders("../../resources/shaders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func (b *markerBackend) UseProgram(program uint32) {
+	b.program = program
+	b.Backend.UseProgram(program)
+}
+
+func (b *markerBackend) BindVertexArray(array uint32) {
+	if array != 0 {
+		b.vertexArray = array
+	}
+	b.Backend.BindVertexArray(array)
+}
+
+func (b *markerBackend) ActiveTexture(unit uint32) {
+	b.unit = unit
+	b.Backend.ActiveTexture(unit)
+}
+
+func (b *markerBackend) BindTexture(target uint32, texture uint32) {
+	b.textures[b.unit] = texture
+	b.Backend.BindTexture(target, texture)
+}
+
+func (b *markerBackend) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	b.buffers[index] = buffer
+	b.Backend.BindBufferBase(target, index, buffer)
+}
+
+func (b *markerBackend) BeginTechnique(marker *render.TechniqueMarker) {
+	b.events = append(b.events, "BeginTechnique")
+	b.techniques = append(b.techniques, marker)
+}
+
+func (b *markerBackend) EndTechnique() {
+	b.events = append(b.events, "EndTechnique")
+}
+
+func (b *markerBackend) BeginCommand(marker *render.CommandMarker) {
+	b.events = append(b.events, "BeginCommand")
+	b.commands = append(b.commands, marker)
+}
+
+func (b *markerBackend) EndCommand() {
+	b.events = append(b.events, "EndCommand")
+}
+
+// recordMarkedFrame records a frame drawing a single command through a
+// technique with non-default state.
+func recordMarkedFrame(t *testing.T, b render.Backend) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	recorder, err := NewRecorder(b, buf, 8, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	render.SetBackend(recorder)
+
+	shader, err := render.NewVertFragShader(
+		"../../resources/shaders/flat.vert",
+		"../../resources/shaders/flat.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	texture, err := render.NewRGBATexture(nil, 4, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	camera := render.NewUniformBuffer(render.NewUniformBlockDescriptor("Camera", []render.UniformDescriptor{
+		{Name: "uProjection", Type: gl.FLOAT_MAT4, Count: 1},
+		{Name: "uView", Type: gl.FLOAT_MAT4, Count: 1},
+	}), 1)
+	positions, indices := shape.Quad(1, true, false)
+	vertices := &render.VertexBuffer{}
+	err = vertices.BufferFloat32(positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements := &render.IndexBuffer{}
+	err = elements.BufferUint16(indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quad := &render.Renderable{}
+	quad.SetVertexBuffer(vertices)
+	quad.SetIndexBuffer(elements)
+	quad.SetPointer(0, &render.AttributePointer{
+		Type: gl.FLOAT,
+		Size: 3,
+	})
+	quad.SetDrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_SHORT, 0)
+	err = quad.Upload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	technique := render.NewTechnique()
+	technique.Shader(shader)
+	technique.Enable(gl.BLEND)
+	technique.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	technique.DepthFunc(gl.LEQUAL)
+	technique.Viewport(&render.Viewport{X: 0, Y: 0, Width: 8, Height: 8})
+	technique.UniformBuffer(camera)
+
+	command := &render.Command{}
+	command.Texture(gl.TEXTURE0, texture)
+	command.Uniform("uColor", mgl32.Vec4{1, 0.5, 0.25, 1})
+	command.Uniform("uModel", mgl32.Ident4())
+	command.Renderable(quad)
+
+	err = technique.Draw([]*render.Command{command})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = recorder.EndFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestMarkers(t *testing.T) {
+	recorded := newMarkerBackend(t)
+	buf := recordMarkedFrame(t, recorded)
+
+	// create a name up front so replayed objects are renamed
+	replayed := newMarkerBackend(t)
+	replayed.CreateTexture()
+	player, err := NewPlayer(buf, replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = player.ReplayFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range []*markerBackend{recorded, replayed} {
+		expected := []string{"BeginTechnique", "BeginCommand", "EndCommand", "EndTechnique"}
+		if !reflect.DeepEqual(b.events, expected) {
+			t.Fatalf("expected markers %q, got %q", expected, b.events)
+		}
+		technique := b.techniques[0]
+		if technique.Program != b.program {
+			t.Errorf("expected technique program %d, got %d", b.program, technique.Program)
+		}
+		if !reflect.DeepEqual(technique.Enables, []uint32{gl.BLEND}) {
+			t.Errorf("expected technique enables [%d], got %v", gl.BLEND, technique.Enables)
+		}
+		blendFunc := [4]uint32{gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA}
+		if technique.BlendFunc != blendFunc {
+			t.Errorf("expected technique blend func %v, got %v", blendFunc, technique.BlendFunc)
+		}
+		if technique.DepthFunc != gl.LEQUAL {
+			t.Errorf("expected technique depth func %d, got %d", gl.LEQUAL, technique.DepthFunc)
+		}
+		if technique.Viewport != [4]int32{0, 0, 8, 8} {
+			t.Errorf("expected technique viewport [0 0 8 8], got %v", technique.Viewport)
+		}
+		buffers := []render.MarkerBuffer{{Binding: 1, Buffer: b.buffers[1]}}
+		if !reflect.DeepEqual(technique.UniformBuffers, buffers) {
+			t.Errorf("expected technique uniform buffers %v, got %v", buffers, technique.UniformBuffers)
+		}
+
+		command := b.commands[0]
+		if len(command.Uniforms) != 2 ||
+			command.Uniforms[0].Name != "uColor" ||
+			command.Uniforms[0].Type != gl.FLOAT_VEC4 ||
+			command.Uniforms[1].Name != "uModel" ||
+			command.Uniforms[1].Type != gl.FLOAT_MAT4 {
+			t.Fatalf("expected uColor and uModel uniforms, got %+v", command.Uniforms)
+		}
+		color := []uint32{
+			math.Float32bits(1),
+			math.Float32bits(0.5),
+			math.Float32bits(0.25),
+			math.Float32bits(1),
+		}
+		if !reflect.DeepEqual(command.Uniforms[0].Words, color) {
+			t.Errorf("expected uColor words %v, got %v", color, command.Uniforms[0].Words)
+		}
+		textures := []render.MarkerTexture{{Unit: gl.TEXTURE0, Texture: b.textures[gl.TEXTURE0]}}
+		if !reflect.DeepEqual(command.Textures, textures) {
+			t.Errorf("expected command textures %v, got %v", textures, command.Textures)
+		}
+		if command.VertexArray != b.vertexArray {
+			t.Errorf("expected command vertex array %d, got %d", b.vertexArray, command.VertexArray)
+		}
+	}
+	if replayed.techniques[0].Program == recorded.techniques[0].Program {
+		t.Errorf("expected replayed marker to name the replayed program")
+	}
+}
@@ -0,0 +1,383 @@
+// Package trace records the calls issued through a render backend to a file
+// and replays them against any backend.
+//
+// Every technique draw and command is bracketed by markers holding the
+// technique state and the command uniforms and bindings, so a replayed frame
+// reports which technique and command issued each state change, bind, upload
+// and draw.
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const (
+	// Version is the version of the trace format written by the recorder,
+	// the player only replays traces of the same version.
+	Version = 1
+
+	magic       = "CTRC"
+	maxSliceLen = 1 << 28
+	maxBytesLen = 1 << 30
+)
+
+type opcode uint8
+
+// every backend call that mutates state is recorded as an opcode followed by
+// its little-endian encoded arguments.
+const (
+	opEndFrame opcode = iota
+	// state
+	opEnable
+	opDisable
+	opBlendFunc
+	opBlendFuncSeparate
+	opBlendEquationSeparate
+	opBlendColor
+	opColorMask
+	opCullFace
+	opDepthMask
+	opDepthFunc
+	opStencilFunc
+	opStencilOp
+	opStencilMask
+	opScissor
+	opPolygonOffset
+	opLineWidth
+	opPolygonMode
+	opViewport
+	opClearColor
+	opClearDepth
+	opClearStencil
+	opClear
+	// shaders
+	opCreateShader
+	opDeleteShader
+	opCreateProgram
+	opAttachShader
+	opDetachShader
+	opTransformFeedbackVaryings
+	opLinkProgram
+	opProgramUniforms
+	opUseProgram
+	opDeleteProgram
+	opUniformBlockBinding
+	// uniforms
+	opUniform1i
+	opUniform1ui
+	opUniform1f
+	opUniform1iv
+	opUniform1uiv
+	opUniform1fv
+	opUniform2fv
+	opUniform3fv
+	opUniform4fv
+	opUniformMatrix3fv
+	opUniformMatrix4fv
+	// buffers
+	opCreateBuffer
+	opBindBuffer
+	opBufferData
+	opBufferSubData
+	opBindBufferBase
+	opDeleteBuffer
+	// vertex arrays
+	opCreateVertexArray
+	opBindVertexArray
+	opEnableVertexAttribArray
+	opVertexAttribPointer
+	opVertexAttribDivisor
+	opDeleteVertexArray
+	// textures
+	opCreateTexture
+	opActiveTexture
+	opBindTexture
+	opTexParameteri
+	opTexImage2D
+	opGenerateMipmap
+	opDeleteTexture
+	// framebuffers
+	opCreateFramebuffer
+	opBindFramebuffer
+	opFramebufferTexture2D
+	opDrawBuffers
+	opDeleteFramebuffer
+	// draw calls
+	opPatchParameteri
+	opBeginTransformFeedback
+	opEndTransformFeedback
+	opDrawArrays
+	opDrawArraysInstanced
+	opDrawElements
+	opDrawElementsInstanced
+	// markers
+	opBeginTechnique
+	opEndTechnique
+	opBeginCommand
+	opEndCommand
+	// numOpcodes is the number of opcodes
+	numOpcodes
+)
+
+// Header represents the header of a trace file.
+type Header struct {
+	Version uint32
+	Width   int32
+	Height  int32
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	buf [8]byte
+}
+
+func newEncoder(w io.Writer) *encoder {
+	return &encoder{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// NOTE: bufio.Writer errors are sticky, so they are only checked on flush.
+
+func (e *encoder) writeOpcode(op opcode) {
+	e.w.WriteByte(byte(op))
+}
+
+func (e *encoder) writeBool(v bool) {
+	if v {
+		e.w.WriteByte(1)
+	} else {
+		e.w.WriteByte(0)
+	}
+}
+
+func (e *encoder) writeUint32(v uint32) {
+	binary.LittleEndian.PutUint32(e.buf[:4], v)
+	e.w.Write(e.buf[:4])
+}
+
+func (e *encoder) writeInt32(v int32) {
+	e.writeUint32(uint32(v))
+}
+
+func (e *encoder) writeInt(v int) {
+	binary.LittleEndian.PutUint64(e.buf[:8], uint64(v))
+	e.w.Write(e.buf[:8])
+}
+
+func (e *encoder) writeFloat32(v float32) {
+	e.writeUint32(math.Float32bits(v))
+}
+
+func (e *encoder) writeString(v string) {
+	e.writeUint32(uint32(len(v)))
+	e.w.WriteString(v)
+}
+
+func (e *encoder) writeBytes(v []byte) {
+	e.writeUint32(uint32(len(v)))
+	e.w.Write(v)
+}
+
+// writePointer writes size bytes of the provided pointer, a nil pointer is
+// recorded as such rather than as zeroed memory.
+func (e *encoder) writePointer(data unsafe.Pointer, size int) {
+	if data == nil {
+		e.writeBool(false)
+		return
+	}
+	e.writeBool(true)
+	e.writeBytes((*[maxBytesLen]byte)(data)[:size:size])
+}
+
+func (e *encoder) writeInt32s(value *int32, n int) {
+	e.writeUint32(uint32(n))
+	for _, v := range (*[maxSliceLen]int32)(unsafe.Pointer(value))[:n:n] {
+		e.writeInt32(v)
+	}
+}
+
+func (e *encoder) writeUint32s(value *uint32, n int) {
+	e.writeUint32(uint32(n))
+	for _, v := range (*[maxSliceLen]uint32)(unsafe.Pointer(value))[:n:n] {
+		e.writeUint32(v)
+	}
+}
+
+func (e *encoder) writeFloat32s(value *float32, n int) {
+	e.writeUint32(uint32(n))
+	for _, v := range (*[maxSliceLen]float32)(unsafe.Pointer(value))[:n:n] {
+		e.writeFloat32(v)
+	}
+}
+
+func (e *encoder) flush() error {
+	return e.w.Flush()
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+	err error
+}
+
+func newDecoder(r io.Reader) *decoder {
+	return &decoder{
+		r: bufio.NewReader(r),
+	}
+}
+
+// NOTE: the first error encountered is sticky, all subsequent reads return
+// zero values.
+
+func (d *decoder) read(buf []byte) {
+	if d.err != nil {
+		return
+	}
+	_, err := io.ReadFull(d.r, buf)
+	if err == io.EOF {
+		// eof is only expected between records
+		err = io.ErrUnexpectedEOF
+	}
+	d.err = err
+}
+
+func (d *decoder) readOpcode() (opcode, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return opcode(b), nil
+}
+
+func (d *decoder) readBool() bool {
+	d.read(d.buf[:1])
+	return d.buf[0] != 0
+}
+
+func (d *decoder) readUint32() uint32 {
+	d.read(d.buf[:4])
+	if d.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(d.buf[:4])
+}
+
+func (d *decoder) readInt32() int32 {
+	return int32(d.readUint32())
+}
+
+func (d *decoder) readInt() int {
+	d.read(d.buf[:8])
+	if d.err != nil {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint64(d.buf[:8]))
+}
+
+func (d *decoder) readFloat32() float32 {
+	return math.Float32frombits(d.readUint32())
+}
+
+func (d *decoder) readLength() int {
+	n := d.readUint32()
+	if d.err == nil && n > maxBytesLen {
+		d.err = fmt.Errorf("invalid length %d", n)
+	}
+	if d.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) readBytes() []byte {
+	buf := make([]byte, d.readLength())
+	d.read(buf)
+	return buf
+}
+
+func (d *decoder) readString() string {
+	return string(d.readBytes())
+}
+
+// readPointer returns a pointer to the recorded bytes, or nil if a nil
+// pointer was recorded.
+func (d *decoder) readPointer() unsafe.Pointer {
+	if !d.readBool() {
+		return nil
+	}
+	buf := d.readBytes()
+	if len(buf) == 0 {
+		return nil
+	}
+	return gl.Ptr(buf)
+}
+
+func (d *decoder) readInt32s() []int32 {
+	values := make([]int32, d.readLength())
+	for i := range values {
+		values[i] = d.readInt32()
+	}
+	return values
+}
+
+func (d *decoder) readUint32s() []uint32 {
+	values := make([]uint32, d.readLength())
+	for i := range values {
+		values[i] = d.readUint32()
+	}
+	return values
+}
+
+func (d *decoder) readFloat32s() []float32 {
+	values := make([]float32, d.readLength())
+	for i := range values {
+		values[i] = d.readFloat32()
+	}
+	return values
+}
+
+// pixelDataSize returns the number of bytes read by TexImage2D for the
+// provided dimensions, format and type, assuming the default unpack
+// alignment of 4.
+func pixelDataSize(width int32, height int32, format uint32, typ uint32) (int, error) {
+	var components int
+	switch format {
+	case gl.RED, gl.RED_INTEGER, gl.DEPTH_COMPONENT, gl.STENCIL_INDEX:
+		components = 1
+	case gl.RG, gl.RG_INTEGER, gl.DEPTH_STENCIL:
+		components = 2
+	case gl.RGB, gl.BGR, gl.RGB_INTEGER, gl.BGR_INTEGER:
+		components = 3
+	case gl.RGBA, gl.BGRA, gl.RGBA_INTEGER, gl.BGRA_INTEGER:
+		components = 4
+	default:
+		return 0, fmt.Errorf("unsupported pixel format `%d`", format)
+	}
+	var size int
+	switch typ {
+	case gl.UNSIGNED_BYTE, gl.BYTE:
+		size = 1
+	case gl.UNSIGNED_SHORT, gl.SHORT, gl.HALF_FLOAT:
+		size = 2
+	case gl.UNSIGNED_INT, gl.INT, gl.FLOAT:
+		size = 4
+	case gl.UNSIGNED_INT_24_8:
+		// packed, one value per pixel
+		components = 1
+		size = 4
+	default:
+		return 0, fmt.Errorf("unsupported pixel type `%d`", typ)
+	}
+	row := int(width) * components * size
+	// rows are padded to the unpack alignment
+	row = (row + 3) &^ 3
+	return row * int(height), nil
+}
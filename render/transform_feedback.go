@@ -0,0 +1,164 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// TransformFeedback represents vertex data stepped on the GPU by a shader
+// whose outputs are captured with transform feedback. The data is double
+// buffered: each step reads the current vertexbuffer and writes the other,
+// with rasterization discarded, and then swaps them. The captured outputs
+// must be laid out like the vertices read, so each step feeds the next.
+type TransformFeedback struct {
+	shader   *Shader
+	pointers []*AttributePointer
+	stride   int32
+	buffers  [2]*VertexBuffer
+	sources  [2]*Renderable
+	command  *Command
+	current  int
+	count    int32
+}
+
+// NewTransformFeedback instantiates and returns a new transform feedback for
+// the provided feedback shader. The pointers describe the layout of a vertex
+// read by the shader, which is also the layout its varyings are captured in.
+func NewTransformFeedback(shader *Shader, pointers []*AttributePointer) (*TransformFeedback, error) {
+	if len(shader.varyings) == 0 {
+		return nil, fmt.Errorf("shader %d captures no varyings", shader.id)
+	}
+	if len(pointers) == 0 {
+		return nil, fmt.Errorf("transform feedback has no attribute pointers")
+	}
+	return &TransformFeedback{
+		shader:   shader,
+		pointers: pointers,
+		stride:   vertexStride(pointers),
+		buffers:  [2]*VertexBuffer{{}, {}},
+		command:  &Command{},
+	}, nil
+}
+
+// vertexStride returns the number of bytes between consecutive vertices.
+func vertexStride(pointers []*AttributePointer) int32 {
+	stride := int32(0)
+	for _, pointer := range pointers {
+		size := pointer.ByteStride
+		if size == 0 {
+			size = pointer.Size * 4
+		}
+		if size > stride {
+			stride = size
+		}
+	}
+	return stride
+}
+
+// BufferFloat32 buffers the initial vertices into the current vertexbuffer
+// and allocates the other to the same size.
+func (t *TransformFeedback) BufferFloat32(data []float32) error {
+	if len(data)*4%int(t.stride) != 0 {
+		return fmt.Errorf("%d floats is not a multiple of the %d byte vertex stride", len(data), t.stride)
+	}
+	err := t.buffers[t.current].BufferFloat32(data)
+	if err != nil {
+		return err
+	}
+	err = t.buffers[1-t.current].AllocateBuffer(len(data) * 4)
+	if err != nil {
+		return err
+	}
+	t.count = int32(len(data) * 4 / int(t.stride))
+	// read each vertexbuffer through its own renderable
+	for i, buffer := range t.buffers {
+		if t.sources[i] != nil {
+			t.sources[i].Destroy()
+		}
+		t.sources[i] = t.source(buffer)
+		err := t.sources[i].Upload()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// source returns a renderable reading the vertices of the provided
+// vertexbuffer.
+func (t *TransformFeedback) source(buffer *VertexBuffer) *Renderable {
+	renderable := &Renderable{}
+	for _, pointer := range t.pointers {
+		renderable.SetPointer(pointer.Index, &AttributePointer{
+			Index:      pointer.Index,
+			Size:       pointer.Size,
+			Type:       pointer.Type,
+			ByteStride: pointer.ByteStride,
+			ByteOffset: pointer.ByteOffset,
+			Buffer:     buffer,
+		})
+	}
+	renderable.SetDrawArrays(gl.POINTS, 0, t.count)
+	return renderable
+}
+
+// Uniform sets a uniform to be buffered for every step.
+func (t *TransformFeedback) Uniform(name string, value interface{}) {
+	t.command.Uniform(name, value)
+}
+
+// Step runs the shader over every vertex of the current vertexbuffer,
+// capturing its outputs into the other, and swaps them. The step runs
+// against the default context.
+func (t *TransformFeedback) Step() error {
+	return defaultContext.Step(t)
+}
+
+// step runs the shader, which must be in use.
+func (t *TransformFeedback) step(c *Context) error {
+	target := t.buffers[1-t.current]
+	t.command.Renderable(t.sources[t.current])
+	// only the captured outputs are needed
+	c.enable(gl.RASTERIZER_DISCARD)
+	backend.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, 0, target.id)
+	backend.BeginTransformFeedback(gl.POINTS)
+	err := t.command.execute(c, t.shader)
+	backend.EndTransformFeedback()
+	backend.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, 0, 0)
+	c.disable(gl.RASTERIZER_DISCARD)
+	if err != nil {
+		return err
+	}
+	t.current = 1 - t.current
+	return nil
+}
+
+// Buffers returns both vertexbuffers, the current vertexbuffer holds the
+// vertices of the last step.
+func (t *TransformFeedback) Buffers() [2]*VertexBuffer {
+	return t.buffers
+}
+
+// Current returns the index of the vertexbuffer holding the vertices of the
+// last step.
+func (t *TransformFeedback) Current() int {
+	return t.current
+}
+
+// Count returns the number of vertices stepped.
+func (t *TransformFeedback) Count() int32 {
+	return t.count
+}
+
+// Destroy deallocates the vertexbuffers and renderables.
+func (t *TransformFeedback) Destroy() {
+	for i := range t.buffers {
+		if t.sources[i] != nil {
+			t.sources[i].Destroy()
+			t.sources[i] = nil
+		}
+		t.buffers[i].Destroy()
+	}
+	t.count = 0
+}
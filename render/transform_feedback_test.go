@@ -0,0 +1,86 @@
+package render_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+func TestTransformFeedbackKeepsEnableCache(t *testing.T) {
+	b := newRecordingBackend(t)
+	step, err := render.NewFeedbackShader(map[uint32]string{
+		gl.VERTEX_SHADER: "../resources/shaders/particle_step.vert",
+	}, []string{"vPosition", "vVelocity", "vAge", "vSize"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedback, err := render.NewTransformFeedback(step, []*render.AttributePointer{
+		{Index: 0, Type: gl.FLOAT, Size: 2, ByteStride: 24, ByteOffset: 0},
+		{Index: 1, Type: gl.FLOAT, Size: 2, ByteStride: 24, ByteOffset: 8},
+		{Index: 2, Type: gl.FLOAT, Size: 1, ByteStride: 24, ByteOffset: 16},
+		{Index: 3, Type: gl.FLOAT, Size: 1, ByteStride: 24, ByteOffset: 20},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = feedback.BufferFloat32(make([]float32, 12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shader, err := render.NewVertFragShader(
+		"../resources/shaders/flat.vert",
+		"../resources/shaders/flat.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	technique := render.NewTechnique()
+	technique.Shader(shader)
+	technique.Viewport(&render.Viewport{
+		Width:  testWidth,
+		Height: testHeight,
+	})
+	technique.Enable(gl.RASTERIZER_DISCARD)
+	command := &render.Command{}
+	command.Renderable(newQuad(t, 8))
+
+	enable := fmt.Sprintf("Enable %d", gl.RASTERIZER_DISCARD)
+	disable := fmt.Sprintf("Disable %d", gl.RASTERIZER_DISCARD)
+	tests := []struct {
+		name     string
+		run      func() error
+		expected []string
+	}{
+		{
+			name: "draw",
+			run: func() error {
+				return technique.Draw([]*render.Command{command})
+			},
+			expected: []string{enable},
+		},
+		{
+			// already enabled by the technique, then disabled once stepped
+			name:     "step",
+			run:      feedback.Step,
+			expected: []string{disable},
+		},
+		{
+			// the step disabled it, so the technique enables it again
+			name: "draw after step",
+			run: func() error {
+				return technique.Draw([]*render.Command{command})
+			},
+			expected: []string{enable},
+		},
+	}
+	for _, test := range tests {
+		b.reset()
+		err := test.run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectCalls(t, test.name, filterCalls(b.calls, enable, disable), test.expected)
+	}
+}
@@ -2,14 +2,19 @@ package render
 
 import (
 	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
 // UniformBlockDescriptor represents a shader uniform blocks attributes.
 type UniformBlockDescriptor struct {
-	Name      string
-	Index     uint32
-	Size      int32
-	Offsets   map[string]int32
+	Name    string
+	Index   uint32
+	Size    int32
+	Offsets map[string]int32
+	// Uniforms holds the type and array size of each uniform, keyed like
+	// Offsets.
+	Uniforms  map[string]*UniformDescriptor
 	Alignment int32
 }
 
@@ -36,3 +41,71 @@ func (u *UniformBlockDescriptor) Offset(name string) (int32, error) {
 	}
 	return offset, nil
 }
+
+// NewUniformBlockDescriptor instantiates and returns a descriptor for a
+// `layout(std140)` uniform block declaring the provided uniforms in order. It
+// allows uniform buffers to be created before any shader using the block.
+func NewUniformBlockDescriptor(name string, uniforms []UniformDescriptor) *UniformBlockDescriptor {
+	offsets := make(map[string]int32)
+	members := make(map[string]*UniformDescriptor)
+	offset := int32(0)
+	for _, uniform := range uniforms {
+		align, size := std140Layout(uniform.Type, uniform.Count)
+		offset = alignUp(offset, align)
+		key := uniform.Name
+		if uniform.Count > 1 {
+			key += "[0]"
+		}
+		offsets[key] = offset
+		members[key] = &UniformDescriptor{
+			Name:     key,
+			Type:     uniform.Type,
+			Count:    uniform.Count,
+			Location: -1,
+		}
+		if uniform.Count < 1 {
+			members[key].Count = 1
+		}
+		offset += size
+	}
+	return &UniformBlockDescriptor{
+		Name:     name,
+		Size:     alignUp(offset, 16),
+		Offsets:  offsets,
+		Uniforms: members,
+		// the largest uniform buffer offset alignment of common drivers
+		Alignment: 256,
+	}
+}
+
+// std140Layout returns the base alignment and size of a uniform of the
+// provided type and count under the std140 rules.
+func std140Layout(typ uint32, count int32) (int32, int32) {
+	if count < 1 {
+		count = 1
+	}
+	columns, align, size := int32(1), int32(4), int32(4)
+	switch typ {
+	case gl.FLOAT_VEC2, gl.INT_VEC2, gl.UNSIGNED_INT_VEC2, gl.BOOL_VEC2:
+		align, size = 8, 8
+	case gl.FLOAT_VEC3, gl.INT_VEC3, gl.UNSIGNED_INT_VEC3, gl.BOOL_VEC3:
+		align, size = 16, 12
+	case gl.FLOAT_VEC4, gl.INT_VEC4, gl.UNSIGNED_INT_VEC4, gl.BOOL_VEC4:
+		align, size = 16, 16
+	case gl.FLOAT_MAT2:
+		columns, align, size = 2, 16, 16
+	case gl.FLOAT_MAT3:
+		columns, align, size = 3, 16, 16
+	case gl.FLOAT_MAT4:
+		columns, align, size = 4, 16, 16
+	}
+	if count == 1 && columns == 1 {
+		return align, size
+	}
+	// arrays and matrix columns are padded to a vec4 stride
+	return 16, 16 * columns * count
+}
+
+func alignUp(offset int32, align int32) int32 {
+	return (offset + align - 1) / align * align
+}
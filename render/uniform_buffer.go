@@ -0,0 +1,254 @@
+package render
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	blockBindings = make(map[string]uint32)
+)
+
+// SetUniformBlockBinding sets the binding point of every uniform block with
+// the provided name in shaders linked afterwards. Blocks without a binding
+// point use their block index.
+func SetUniformBlockBinding(name string, binding uint32) {
+	blockBindings[name] = binding
+}
+
+// UniformBuffer represents a uniform buffer object holding the values of a
+// uniform block. Values are packed with the std140 rules at the offsets of
+// the block descriptor and uploaded when the buffer is next bound.
+type UniformBuffer struct {
+	id         uint32
+	binding    uint32
+	descriptor *UniformBlockDescriptor
+	data       []byte
+	dirty      bool
+}
+
+// NewUniformBuffer instantiates and returns a new uniform buffer for the
+// block, bound to the provided binding point.
+func NewUniformBuffer(descriptor *UniformBlockDescriptor, binding uint32) *UniformBuffer {
+	return &UniformBuffer{
+		binding:    binding,
+		descriptor: descriptor,
+		data:       make([]byte, descriptor.Size),
+		dirty:      true,
+	}
+}
+
+// Binding returns the binding point of the uniform buffer.
+func (u *UniformBuffer) Binding() uint32 {
+	return u.binding
+}
+
+// Set packs the value of a single uniform of the block. Supported values are
+// float32, int32, uint32, bool, the mgl32 vector and matrix types, and slices
+// of those for arrays. The value is checked against the type and array size
+// of the uniform declared by the block.
+func (u *UniformBuffer) Set(name string, value interface{}) error {
+	key, ok := u.key(name)
+	if !ok {
+		return fmt.Errorf("uniform `%s` is not part of block `%s`", name, u.descriptor.Name)
+	}
+	err := u.check(key, value)
+	if err == nil {
+		err = u.pack(int(u.descriptor.Offsets[key]), reflect.ValueOf(value))
+	}
+	if err != nil {
+		return fmt.Errorf("uniform `%s` of block `%s`: %v", name, u.descriptor.Name, err)
+	}
+	u.dirty = true
+	return nil
+}
+
+// SetStruct packs every field of a struct tagged with `uniform:"name"`.
+func (u *UniformBuffer) SetStruct(value interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("%v is not a struct", value)
+	}
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("uniform")
+		if name == "" || name == "-" {
+			continue
+		}
+		err := u.Set(name, v.Field(i).Interface())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Upload uploads the packed values if they changed since the last upload.
+func (u *UniformBuffer) Upload() error {
+	if !u.dirty {
+		return nil
+	}
+	if u.id == 0 {
+		u.id = backend.CreateBuffer()
+		backend.BindBuffer(gl.UNIFORM_BUFFER, u.id)
+		backend.BufferData(gl.UNIFORM_BUFFER, len(u.data), gl.Ptr(u.data), gl.DYNAMIC_DRAW)
+	} else {
+		backend.BindBuffer(gl.UNIFORM_BUFFER, u.id)
+		backend.BufferSubData(gl.UNIFORM_BUFFER, 0, len(u.data), gl.Ptr(u.data))
+	}
+	backend.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	stats.BytesUploaded += len(u.data)
+	u.dirty = false
+	return checkError(u.id)
+}
+
+// Bind uploads any modified values and binds the buffer to its binding point
+// of the default context.
+func (u *UniformBuffer) Bind() error {
+	err := u.Upload()
+	if err != nil {
+		return err
+	}
+	backend.BindBufferBase(gl.UNIFORM_BUFFER, u.binding, u.id)
+	defaultContext.uniformBuffers[u.binding] = boundUniformBuffer{
+		buffer: u,
+		id:     u.id,
+	}
+	return checkError(u.id)
+}
+
+// Destroy deallocates the uniform buffer.
+func (u *UniformBuffer) Destroy() {
+	if u.id != 0 {
+		backend.DeleteBuffer(u.id)
+		u.id = 0
+		u.dirty = true
+	}
+}
+
+func (u *UniformBuffer) key(name string) (string, bool) {
+	// arrays are reflected by their first element, and members of named
+	// blocks are prefixed by the block name
+	for _, key := range []string{name, name + "[0]", u.descriptor.Name + "." + name} {
+		_, ok := u.descriptor.Offsets[key]
+		if ok {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// check returns an error if the value cannot be packed to the uniform of the
+// block, descriptors without uniform types are not checked.
+func (u *UniformBuffer) check(key string, arg interface{}) error {
+	descriptor, ok := u.descriptor.Uniforms[key]
+	if !ok {
+		return nil
+	}
+	value, err := newUniformValue(arg)
+	if err != nil {
+		return err
+	}
+	if value.typ == 0 || !value.matches(descriptor.Type) {
+		return fmt.Errorf("%T cannot be packed to a uniform of type %s", arg, glslTypeName(descriptor.Type))
+	}
+	if value.count > descriptor.Count {
+		return fmt.Errorf("%d elements exceed the %s[%d] array", value.count, glslTypeName(descriptor.Type), descriptor.Count)
+	}
+	return nil
+}
+
+func (u *UniformBuffer) pack(offset int, v reflect.Value) error {
+	if !v.IsValid() {
+		return fmt.Errorf("value is nil")
+	}
+	switch value := v.Interface().(type) {
+	case float32:
+		return u.write(offset, math.Float32bits(value))
+	case int32:
+		return u.write(offset, uint32(value))
+	case uint32:
+		return u.write(offset, value)
+	case bool:
+		if value {
+			return u.write(offset, 1)
+		}
+		return u.write(offset, 0)
+	case mgl32.Vec2:
+		return u.writeFloats(offset, value[:])
+	case mgl32.Vec3:
+		return u.writeFloats(offset, value[:])
+	case mgl32.Vec4:
+		return u.writeFloats(offset, value[:])
+	case mgl32.Mat3:
+		return u.writeColumns(offset, value[:], 3)
+	case mgl32.Mat4:
+		return u.writeColumns(offset, value[:], 4)
+	}
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("%v is not of a supported type", v.Interface())
+	}
+	// array elements are padded to a vec4 stride
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Slice {
+			return fmt.Errorf("arrays of arrays are not supported")
+		}
+		err := u.pack(offset, elem)
+		if err != nil {
+			return err
+		}
+		_, size := std140Layout(glType(elem.Interface()), 1)
+		offset += int(alignUp(size, 16))
+	}
+	return nil
+}
+
+func (u *UniformBuffer) write(offset int, bits uint32) error {
+	if offset < 0 || offset+4 > len(u.data) {
+		return fmt.Errorf("offset %d is outside of the %d byte block", offset, len(u.data))
+	}
+	binary.LittleEndian.PutUint32(u.data[offset:], bits)
+	return nil
+}
+
+func (u *UniformBuffer) writeFloats(offset int, values []float32) error {
+	for i, value := range values {
+		err := u.write(offset+i*4, math.Float32bits(value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *UniformBuffer) writeColumns(offset int, values []float32, columns int) error {
+	rows := len(values) / columns
+	for col := 0; col < columns; col++ {
+		err := u.writeFloats(offset+col*16, values[col*rows:(col+1)*rows])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func glType(value interface{}) uint32 {
+	switch value.(type) {
+	case mgl32.Vec2:
+		return gl.FLOAT_VEC2
+	case mgl32.Vec3:
+		return gl.FLOAT_VEC3
+	case mgl32.Vec4:
+		return gl.FLOAT_VEC4
+	case mgl32.Mat3:
+		return gl.FLOAT_MAT3
+	case mgl32.Mat4:
+		return gl.FLOAT_MAT4
+	}
+	return gl.FLOAT
+}
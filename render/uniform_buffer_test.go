@@ -0,0 +1,188 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// word represents a 32 bit value expected at a byte offset of a block.
+type word struct {
+	offset int
+	bits   uint32
+}
+
+func float(offset int, value float32) word {
+	return word{offset, math.Float32bits(value)}
+}
+
+func floats(offset int, values ...float32) []word {
+	words := make([]word, len(values))
+	for i, value := range values {
+		words[i] = float(offset+i*4, value)
+	}
+	return words
+}
+
+func newTestBlock() *UniformBlockDescriptor {
+	return NewUniformBlockDescriptor("Test", []UniformDescriptor{
+		{Name: "uScale", Type: gl.FLOAT, Count: 1},
+		{Name: "uCount", Type: gl.INT, Count: 1},
+		{Name: "uFlag", Type: gl.BOOL, Count: 1},
+		{Name: "uPosition", Type: gl.FLOAT_VEC3, Count: 1},
+		{Name: "uIntensity", Type: gl.FLOAT, Count: 1},
+		{Name: "uNormal", Type: gl.FLOAT_MAT3, Count: 1},
+		{Name: "uWeights", Type: gl.FLOAT, Count: 3},
+		{Name: "uBones", Type: gl.FLOAT_MAT4, Count: 2},
+	})
+}
+
+func TestUniformBlockDescriptorOffsets(t *testing.T) {
+	descriptor := newTestBlock()
+	expected := map[string]int32{
+		"uScale":      0,
+		"uCount":      4,
+		"uFlag":       8,
+		"uPosition":   16,
+		"uIntensity":  28,
+		"uNormal":     32,
+		"uWeights[0]": 80,
+		"uBones[0]":   128,
+	}
+	for name, offset := range expected {
+		if descriptor.Offsets[name] != offset {
+			t.Errorf("expected `%s` at offset %d, got %d", name, offset, descriptor.Offsets[name])
+		}
+	}
+	if descriptor.Size != 256 {
+		t.Errorf("expected a block size of 256, got %d", descriptor.Size)
+	}
+}
+
+func TestUniformBufferPacking(t *testing.T) {
+	bones := []mgl32.Mat4{mgl32.Ident4(), mgl32.Translate3D(1, 2, 3)}
+	tests := []struct {
+		name     string
+		uniforms map[string]interface{}
+		words    []word
+	}{
+		{
+			name: "scalars",
+			uniforms: map[string]interface{}{
+				"uScale": float32(0.5),
+				"uCount": int32(-2),
+				"uFlag":  true,
+			},
+			words: []word{
+				float(0, 0.5),
+				{4, 0xfffffffe},
+				{8, 1},
+			},
+		},
+		{
+			name: "vec3 followed by a float",
+			uniforms: map[string]interface{}{
+				"uPosition":  mgl32.Vec3{1, 2, 3},
+				"uIntensity": float32(4),
+			},
+			// the float packs into the padding of the vec3
+			words: floats(16, 1, 2, 3, 4),
+		},
+		{
+			name: "mat3",
+			uniforms: map[string]interface{}{
+				"uNormal": mgl32.Mat3{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			},
+			// each column is padded to a vec4
+			words: append(append(
+				floats(32, 1, 2, 3),
+				floats(48, 4, 5, 6)...),
+				floats(64, 7, 8, 9)...),
+		},
+		{
+			name: "float array",
+			uniforms: map[string]interface{}{
+				"uWeights": []float32{0.25, 0.5, 0.75},
+			},
+			// each element is padded to a vec4
+			words: []word{
+				float(80, 0.25),
+				float(96, 0.5),
+				float(112, 0.75),
+			},
+		},
+		{
+			name: "mat4 array",
+			uniforms: map[string]interface{}{
+				"uBones": bones,
+			},
+			words: append(
+				floats(128, bones[0][:]...),
+				floats(192, bones[1][:]...)...),
+		},
+	}
+	for _, test := range tests {
+		buffer := NewUniformBuffer(newTestBlock(), 0)
+		for name, value := range test.uniforms {
+			err := buffer.Set(name, value)
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+		expected := make([]byte, 256)
+		for _, w := range test.words {
+			binary.LittleEndian.PutUint32(expected[w.offset:], w.bits)
+		}
+		if !bytes.Equal(buffer.data, expected) {
+			t.Errorf("%s: expected data\n% x\ngot\n% x", test.name, expected, buffer.data)
+		}
+	}
+}
+
+func TestUniformBufferOutOfRange(t *testing.T) {
+	buffer := NewUniformBuffer(newTestBlock(), 0)
+	err := buffer.Set("uBones", []mgl32.Mat4{mgl32.Ident4(), mgl32.Ident4(), mgl32.Ident4()})
+	if err == nil {
+		t.Errorf("expected an error writing past the end of the block")
+	}
+	err = buffer.Set("uMissing", float32(1))
+	if err == nil {
+		t.Errorf("expected an error setting a uniform outside of the block")
+	}
+}
+
+func TestUniformBufferTypeMismatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		err   string
+	}{
+		{"uBones", float32(1), "float32 cannot be packed to a uniform of type mat4"},
+		{"uPosition", mgl32.Vec4{}, "mgl32.Vec4 cannot be packed to a uniform of type vec3"},
+		{"uCount", float32(1), "float32 cannot be packed to a uniform of type int"},
+		{"uScale", int32(1), "int32 cannot be packed to a uniform of type float"},
+		{"uWeights", []float32{1, 2, 3, 4}, "4 elements exceed the float[3] array"},
+		{"uNormal", []mgl32.Mat3{mgl32.Ident3(), mgl32.Ident3()}, "2 elements exceed the mat3[1] array"},
+		{"uFlag", true, ""},
+		{"uBones", mgl32.Ident4(), ""},
+		{"uWeights", []float32{1, 2}, ""},
+	}
+	for _, test := range tests {
+		buffer := NewUniformBuffer(newTestBlock(), 0)
+		err := buffer.Set(test.name, test.value)
+		if test.err == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.err, err)
+		}
+		if test.err != "" && !bytes.Equal(buffer.data, make([]byte, len(buffer.data))) {
+			t.Errorf("%s: expected a mismatched value not to be packed", test.name)
+		}
+	}
+}
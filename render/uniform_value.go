@@ -0,0 +1,227 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// uniformValue represents a uniform value flattened into its components.
+type uniformValue struct {
+	// typ is the GL type of each element, or zero for a raw pointer.
+	typ uint32
+	// count is the number of elements, or zero for a raw pointer.
+	count  int32
+	floats *float32
+	ints   *int32
+	uints  *uint32
+}
+
+// newUniformValue flattens a uniform value. Supported values are float32,
+// int, int32, uint32, bool, the mgl32 vector and matrix types, and slices of
+// those for arrays. Pointers to float32, int32 and uint32 are passed through
+// as is and sized by the uniform they are buffered to.
+func newUniformValue(arg interface{}) (*uniformValue, error) {
+	switch value := arg.(type) {
+	case *float32:
+		return &uniformValue{floats: value}, nil
+	case *int32:
+		return &uniformValue{ints: value}, nil
+	case *uint32:
+		return &uniformValue{uints: value}, nil
+	case *Texture:
+		return nil, fmt.Errorf("textures must be set through a command")
+	}
+	v := reflect.ValueOf(arg)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("value is nil")
+	}
+	if v.Kind() != reflect.Slice {
+		typ, floats, ints, uints, err := flattenUniform(arg)
+		if err != nil {
+			return nil, err
+		}
+		return newFlatUniformValue(typ, 1, floats, ints, uints), nil
+	}
+	if v.Len() == 0 {
+		return nil, fmt.Errorf("%T is empty", arg)
+	}
+	var typ uint32
+	var floats []float32
+	var ints []int32
+	var uints []uint32
+	for i := 0; i < v.Len(); i++ {
+		t, f, n, u, err := flattenUniform(v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && t != typ {
+			return nil, fmt.Errorf("%T mixes element types", arg)
+		}
+		typ = t
+		floats = append(floats, f...)
+		ints = append(ints, n...)
+		uints = append(uints, u...)
+	}
+	return newFlatUniformValue(typ, int32(v.Len()), floats, ints, uints), nil
+}
+
+func newFlatUniformValue(typ uint32, count int32, floats []float32, ints []int32, uints []uint32) *uniformValue {
+	value := &uniformValue{
+		typ:   typ,
+		count: count,
+	}
+	switch {
+	case len(floats) > 0:
+		value.floats = &floats[0]
+	case len(ints) > 0:
+		value.ints = &ints[0]
+	case len(uints) > 0:
+		value.uints = &uints[0]
+	}
+	return value
+}
+
+// flattenUniform returns the GL type and components of a single element.
+func flattenUniform(arg interface{}) (uint32, []float32, []int32, []uint32, error) {
+	switch value := arg.(type) {
+	case float32:
+		return gl.FLOAT, []float32{value}, nil, nil, nil
+	case int:
+		return gl.INT, nil, []int32{int32(value)}, nil, nil
+	case int32:
+		return gl.INT, nil, []int32{value}, nil, nil
+	case uint32:
+		return gl.UNSIGNED_INT, nil, nil, []uint32{value}, nil
+	case bool:
+		if value {
+			return gl.BOOL, nil, []int32{1}, nil, nil
+		}
+		return gl.BOOL, nil, []int32{0}, nil, nil
+	case mgl32.Vec2:
+		return gl.FLOAT_VEC2, value[:], nil, nil, nil
+	case mgl32.Vec3:
+		return gl.FLOAT_VEC3, value[:], nil, nil, nil
+	case mgl32.Vec4:
+		return gl.FLOAT_VEC4, value[:], nil, nil, nil
+	case mgl32.Mat3:
+		return gl.FLOAT_MAT3, value[:], nil, nil, nil
+	case mgl32.Mat4:
+		return gl.FLOAT_MAT4, value[:], nil, nil, nil
+	}
+	return 0, nil, nil, nil, fmt.Errorf("%T is not a supported uniform type", arg)
+}
+
+// matches returns whether the value can be buffered to a uniform of the
+// provided type. Raw pointers only need to match the component type.
+func (v *uniformValue) matches(typ uint32) bool {
+	switch typ {
+	case gl.FLOAT, gl.FLOAT_VEC2, gl.FLOAT_VEC3, gl.FLOAT_VEC4, gl.FLOAT_MAT3, gl.FLOAT_MAT4:
+		return v.floats != nil && (v.typ == 0 || v.typ == typ)
+	case gl.INT, gl.SAMPLER_2D, gl.SAMPLER_CUBE:
+		return v.ints != nil && (v.typ == 0 || v.typ == gl.INT)
+	case gl.BOOL:
+		return v.ints != nil && (v.typ == 0 || v.typ == gl.BOOL || v.typ == gl.INT)
+	case gl.UNSIGNED_INT:
+		return v.uints != nil && (v.typ == 0 || v.typ == gl.UNSIGNED_INT)
+	}
+	return false
+}
+
+// components returns a copy of the bits of every component buffered to count
+// elements of a uniform of the provided type.
+func (v *uniformValue) components(typ uint32, count int32) []uint32 {
+	n := int(uniformComponents(typ) * count)
+	var p unsafe.Pointer
+	switch {
+	case v.floats != nil:
+		p = unsafe.Pointer(v.floats)
+	case v.ints != nil:
+		p = unsafe.Pointer(v.ints)
+	case v.uints != nil:
+		p = unsafe.Pointer(v.uints)
+	}
+	if p == nil || n == 0 {
+		return nil
+	}
+	words := make([]uint32, n)
+	copy(words, (*[1 << 28]uint32)(p)[:n:n])
+	return words
+}
+
+// uniformComponents returns the number of components of a uniform type.
+func uniformComponents(typ uint32) int32 {
+	switch typ {
+	case gl.FLOAT_VEC2:
+		return 2
+	case gl.FLOAT_VEC3:
+		return 3
+	case gl.FLOAT_VEC4:
+		return 4
+	case gl.FLOAT_MAT3:
+		return 9
+	case gl.FLOAT_MAT4:
+		return 16
+	}
+	return 1
+}
+
+// wordsEqual returns whether both component slices hold the same bits. Nil
+// slices are never equal, so a location without a cached value is uploaded.
+func wordsEqual(a []uint32, b []uint32) bool {
+	if a == nil || b == nil || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// glslTypeName returns the GLSL name of a uniform or attribute type.
+func glslTypeName(typ uint32) string {
+	switch typ {
+	case gl.FLOAT:
+		return "float"
+	case gl.FLOAT_VEC2:
+		return "vec2"
+	case gl.FLOAT_VEC3:
+		return "vec3"
+	case gl.FLOAT_VEC4:
+		return "vec4"
+	case gl.FLOAT_MAT2:
+		return "mat2"
+	case gl.FLOAT_MAT3:
+		return "mat3"
+	case gl.FLOAT_MAT4:
+		return "mat4"
+	case gl.INT:
+		return "int"
+	case gl.INT_VEC2:
+		return "ivec2"
+	case gl.INT_VEC3:
+		return "ivec3"
+	case gl.INT_VEC4:
+		return "ivec4"
+	case gl.UNSIGNED_INT:
+		return "uint"
+	case gl.UNSIGNED_INT_VEC2:
+		return "uvec2"
+	case gl.UNSIGNED_INT_VEC3:
+		return "uvec3"
+	case gl.UNSIGNED_INT_VEC4:
+		return "uvec4"
+	case gl.BOOL:
+		return "bool"
+	case gl.SAMPLER_2D:
+		return "sampler2D"
+	case gl.SAMPLER_CUBE:
+		return "samplerCube"
+	}
+	return fmt.Sprintf("0x%x", typ)
+}
@@ -2,32 +2,22 @@ package render
 
 import (
 	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/unchartedsoftware/plog"
 )
 
 // NewVertFragShader instantiates a new shader object.
 func NewVertFragShader(vert, frag string) (*Shader, error) {
+	return newVertFragShader(vert, frag, nil)
+}
 
-	log.Infof("--- %s, %s ---", vert, frag)
-
-	shader := &Shader{}
-
-	vertex, err := shader.CreateShader(vert, gl.VERTEX_SHADER)
-	if err != nil {
-		return nil, err
-	}
-
-	fragment, err := shader.CreateShader(frag, gl.FRAGMENT_SHADER)
-	if err != nil {
-		return nil, err
-	}
-
-	shader.AttachShader(vertex)
-	shader.AttachShader(fragment)
+// NewVertFragShaderWithDefines instantiates a new shader object with the
+// provided defines injected into both stages.
+func NewVertFragShaderWithDefines(vert, frag string, defines map[string]string) (*Shader, error) {
+	return newVertFragShader(vert, frag, DefaultPreprocessor.WithDefines(defines))
+}
 
-	err = shader.LinkProgram()
-	if err != nil {
-		return nil, err
-	}
-	return shader, nil
+func newVertFragShader(vert, frag string, preprocessor *Preprocessor) (*Shader, error) {
+	return newShader(map[uint32]string{
+		gl.VERTEX_SHADER:   vert,
+		gl.FRAGMENT_SHADER: frag,
+	}, preprocessor, nil)
 }
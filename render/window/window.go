@@ -0,0 +1,60 @@
+package window
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+
+	"github.com/kbirk/cauldron/render"
+)
+
+// Window represents a visible glfw window and its OpenGL 4.1 core context.
+type Window struct {
+	*glfw.Window
+}
+
+// New initializes glfw and opens a new window of the provided size.
+func New(width int, height int, title string) (*Window, error) {
+	// init glfw
+	err := glfw.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize glfw: %v", err)
+	}
+
+	// set window hints
+	glfw.WindowHint(glfw.Resizable, glfw.False)
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	if render.Debug {
+		glfw.WindowHint(glfw.OpenGLDebugContext, glfw.True)
+	}
+
+	// create window
+	window, err := glfw.CreateWindow(width, height, title, nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil, fmt.Errorf("failed to create window: %v", err)
+	}
+	return &Window{
+		Window: window,
+	}, nil
+}
+
+// MakeCurrent makes the window context current on the calling thread.
+func (w *Window) MakeCurrent() error {
+	w.MakeContextCurrent()
+	return nil
+}
+
+// FramebufferSize returns the size of the window framebuffer in pixels.
+func (w *Window) FramebufferSize() (int, int) {
+	return w.GetFramebufferSize()
+}
+
+// Destroy destroys the window and terminates glfw.
+func (w *Window) Destroy() {
+	w.Window.Destroy()
+	glfw.Terminate()
+}
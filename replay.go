@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/unchartedsoftware/plog"
+
+	"github.com/kbirk/cauldron/render"
+	"github.com/kbirk/cauldron/render/software"
+	"github.com/kbirk/cauldron/render/trace"
+	"github.com/kbirk/cauldron/render/window"
+)
+
+// replay implements the `cauldron replay <trace>` command.
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	output := flags.String("png", "", "replay against the software backend and write the last frame to the provided png file")
+	assetDir := flags.String("assets", "resources", "directory of assets overriding the embedded defaults, empty to only use the embedded defaults")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: cauldron replay [-png <output>] [-assets <dir>] <trace>")
+	}
+	setAssets(*assetDir)
+
+	// open trace
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if *output != "" {
+		return replaySoftware(file, *output)
+	}
+	return replayWindow(file)
+}
+
+// replayWindow replays one frame per swap into a window sized to the trace,
+// leaving the last frame on screen until the window is closed.
+func replayWindow(file io.Reader) error {
+	player, err := trace.NewPlayer(file, render.CurrentBackend())
+	if err != nil {
+		return err
+	}
+	header := player.Header()
+
+	// create window
+	window, err := window.New(int(header.Width), int(header.Height), "cauldron replay")
+	if err != nil {
+		return err
+	}
+	defer window.Destroy()
+	window.SetKeyCallback(handleKey)
+
+	// make context current and init glow
+	err = render.InitContext(window)
+	if err != nil {
+		return err
+	}
+
+	// replay frames
+	for !window.ShouldClose() {
+		glfw.PollEvents()
+		err := player.ReplayFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		window.SwapBuffers()
+	}
+	log.Infof("replayed %d frames", player.Frames())
+
+	// hold the last frame
+	for !window.ShouldClose() {
+		glfw.WaitEvents()
+	}
+	return nil
+}
+
+// replaySoftware replays all frames against the software backend and writes
+// the last frame to a png file.
+func replaySoftware(file io.Reader, output string) error {
+	backend := software.NewBackend(1, 1)
+	err := backend.RegisterDefaultShaders("shaders")
+	if err != nil {
+		return err
+	}
+	player, err := trace.NewPlayer(file, backend)
+	if err != nil {
+		return err
+	}
+	header := player.Header()
+	backend.Resize(int(header.Width), int(header.Height))
+
+	// replay frames
+	for {
+		err := player.ReplayFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof("replayed %d frames", player.Frames())
+
+	// write last frame
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return png.Encode(out, backend.Image())
+}
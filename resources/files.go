@@ -0,0 +1,28 @@
+// Code generated by gen.go. DO NOT EDIT.
+
+package resources
+
+var files = map[string]string{
+	"shaders/flat.frag":           "#version 410\n\nuniform vec4 uColor;\n\nout vec4 oColor;\n\nvoid main() {\n\toColor = uColor;\n}\n",
+	"shaders/flat.vert":           "#version 410\n\nlayout(location=0) in vec3 aPosition;\n\nuniform mat4 uModel;\n\n#include \"include/camera.glsl\"\n\nvoid main() {\n\tgl_Position = uProjection * uView * uModel * vec4(aPosition, 1);\n}\n",
+	"shaders/include/camera.glsl": "layout(std140) uniform Camera {\n\tmat4 uProjection;\n\tmat4 uView;\n};\n",
+	"shaders/include/easing.glsl": "float cube(float v) {\n\treturn v*v*v;\n}\n\nfloat easeOut(float t) {\n\tt -= 1.0;\n\treturn 1.0 + t*t*t*t*t;\n}\n",
+	"shaders/include/rand.glsl":   "float rand(vec2 co) {\n\treturn fract(sin(dot(co.xy ,vec2(12.9898,78.233))) * 43758.5453);\n}\n",
+	"shaders/particle.frag":       "#version 410\n\nuniform vec4 uColor;\n\nin float vSize;\nout vec4 oColor;\n\n#include \"include/rand.glsl\"\n\nvoid main() {\n#ifdef FADE\n\tfloat r = rand(uColor.rg * vSize) * 0.5;\n\tfloat factor = min(1.0, 0.2 * vSize);\n\tfloat intensity = max(0.4, 1.0 - factor);\n\tfloat alpha = max(0, 1.0 - factor);\n\toColor = vec4(uColor.rgb * (intensity + r), uColor.a * alpha);\n#else\n\tfloat r = rand(uColor.rg * vSize);\n\toColor = vec4(uColor.rgb * (vSize + r), uColor.a);\n#endif\n}\n",
+	"shaders/particle.vert":       "#version 410\n\nlayout(location=0) in vec2 aPosition;\nlayout(location=1) in vec2 aOffset;\nlayout(location=3) in float aSize;\n#ifdef SIMULATED\nlayout(location=4) in float aAge;\n#else\nlayout(location=2) in vec2 aVelocity;\nlayout(location=5) in vec2 aOrigin;\nlayout(location=6) in float aStart;\n#endif\n\nuniform mat4 uModel;\n\n#include \"include/camera.glsl\"\n\n#ifdef SIMULATED\nuniform float uLifetime;\n#else\nuniform float uTime;\n#ifdef RISE\nuniform vec2 uRise;\n#else\nuniform vec2 uGravity;\n#endif\n#endif\n\nout float vSize;\n\nvoid main() {\n#ifndef SIMULATED\n\t// time relative to the start of the effect of the instance\n\tfloat t = uTime - aStart;\n#endif\n#ifdef SIMULATED\n\t// the offset is stepped on the GPU, particles shrink with age\n\tvec2 origin = vec2(0);\n\tvec2 displacement = vec2(0);\n\tfloat size = aAge > 0.0 ? max(0.0, aSize - (aSize * (aAge / uLifetime))) : 0.0;\n\tvSize = size / 4;\n#elif defined(RISE)\n\tvec2 origin = aOrigin;\n\tvec2 displacement = (aVelocity * t) + uRise * (t * 0.2 * aSize);\n\tfloat size = aSize * 0.5 * t;\n\tvSize = size;\n#else\n\tvec2 origin = aOrigin;\n\tvec2 displacement = (aVelocity * t) + (0.5 * uGravity * aSize * (t*t));\n\tfloat size = max(0, aSize - (aSize * t));\n\tvSize = size / 4;\n#endif\n\tvec2 wPosition = origin + (aPosition * size) + aOffset + displacement;\n\tgl_Position = uProjection * uView * uModel * vec4(wPosition, 0, 1);\n}\n",
+	"shaders/particle_step.vert":  "#version 410\n\nlayout(location=0) in vec2 aPosition;\nlayout(location=1) in vec2 aVelocity;\nlayout(location=2) in float aAge;\nlayout(location=3) in float aSize;\n\nuniform float uDelta;\nuniform float uLifetime;\nuniform vec2 uOrigin;\nuniform float uSpeed;\nuniform float uSpread;\nuniform vec2 uGravity;\nuniform vec2 uWind;\nuniform float uDrag;\n\nout vec2 vPosition;\nout vec2 vVelocity;\nout float vAge;\nout float vSize;\n\n#include \"include/rand.glsl\"\n\nvoid main() {\n\tvec2 position = aPosition;\n\tvec2 velocity = aVelocity;\n\tfloat age = aAge + uDelta;\n\tif (age >= uLifetime) {\n\t\t// respawn at the origin in a random direction within the spread\n\t\tfloat angle = 1.5707963 + (rand(aVelocity + vec2(aAge, aSize)) - 0.5) * uSpread;\n\t\tfloat speed = (0.5 + 0.5 * rand(aPosition + vec2(aSize))) * uSpeed;\n\t\tposition = uOrigin;\n\t\tvelocity = vec2(cos(angle), sin(angle)) * speed;\n\t\tage -= uLifetime;\n\t} else if (age > 0.0) {\n\t\t// particles with a negative age have not been emitted yet\n\t\tvec2 acceleration = (uGravity * aSize) + uWind - (velocity * uDrag);\n\t\tvelocity += acceleration * uDelta;\n\t\tposition += velocity * uDelta;\n\t}\n\tvPosition = position;\n\tvVelocity = velocity;\n\tvAge = age;\n\tvSize = aSize;\n}\n",
+	"shaders/post/blur.frag":      "#version 410\n\nuniform sampler2D uTexture;\nuniform vec2 uDirection;\nuniform float uSigma;\n\nin vec2 vTexCoord;\n\nout vec4 oColor;\n\nvoid main() {\n\t// weights of a gaussian kernel truncated at three standard deviations,\n\t// sampled along the direction of the pass\n\tint radius = int(ceil(uSigma * 3.0));\n\tvec4 sum = texture(uTexture, vTexCoord);\n\tfloat total = 1.0;\n\tfor (int i = 1; i <= radius; i++) {\n\t\tfloat weight = exp(-float(i * i) / (2.0 * uSigma * uSigma));\n\t\tvec2 offset = uDirection * float(i);\n\t\tsum += texture(uTexture, vTexCoord + offset) * weight;\n\t\tsum += texture(uTexture, vTexCoord - offset) * weight;\n\t\ttotal += 2.0 * weight;\n\t}\n\toColor = sum / total;\n}\n",
+	"shaders/post/bright.frag":    "#version 410\n\nuniform sampler2D uTexture;\nuniform float uThreshold;\n\nin vec2 vTexCoord;\n\nout vec4 oColor;\n\nvoid main() {\n\t// keep the part of the color brighter than the threshold\n\tvec3 color = texture(uTexture, vTexCoord).rgb;\n\tfloat brightness = max(color.r, max(color.g, color.b));\n\tfloat contribution = max(brightness - uThreshold, 0.0) / max(brightness, 0.0001);\n\toColor = vec4(color * contribution, 1.0);\n}\n",
+	"shaders/post/composite.frag": "#version 410\n\nuniform sampler2D uTexture;\n\n#ifdef BLOOM\nuniform sampler2D uBloom;\nuniform float uIntensity;\n#endif\n\n#ifdef TONEMAP\nuniform float uExposure;\n#endif\n\n#ifdef VIGNETTE\nuniform float uRadius;\nuniform float uSoftness;\nuniform float uStrength;\n#endif\n\nin vec2 vTexCoord;\n\nout vec4 oColor;\n\nvoid main() {\n\tvec3 color = texture(uTexture, vTexCoord).rgb;\n#ifdef BLOOM\n\tcolor += texture(uBloom, vTexCoord).rgb * uIntensity;\n#endif\n#ifdef TONEMAP\n\t// exponential tone mapping rolls highlights off instead of clipping\n\tcolor = vec3(1.0) - exp(-color * uExposure);\n#endif\n#ifdef VIGNETTE\n\t// distance from the center, one at the corners\n\tfloat dist = length(vTexCoord - vec2(0.5)) * 1.41421356;\n\tcolor *= 1.0 - uStrength * smoothstep(uRadius, uRadius + uSoftness, dist);\n#endif\n\toColor = vec4(color, 1.0);\n}\n",
+	"shaders/shockwave.frag":      "#version 410\n\nuniform vec4 uColor;\n\nin float vOpacity;\nin float vTime;\n\nout vec4 oColor;\n\n#include \"include/easing.glsl\"\n\nvoid main() {\n\tfloat intensity = max(0, 1.0 - (vTime  / 0.5));\n\tfloat opacity = cube(vOpacity);\n\toColor = vec4(uColor.rgb * intensity, uColor.a * intensity * opacity);\n}\n",
+	"shaders/shockwave.vert":      "#version 410\n\nlayout(location=0) in vec2 aPosition;\nlayout(location=1) in vec2 aOrigin;\nlayout(location=2) in float aStart;\n\nuniform mat4 uModel;\n\n#include \"include/camera.glsl\"\n\nuniform float uForce;\nuniform float uTime;\n\nout float vOpacity;\nout float vTime;\n\n#include \"include/easing.glsl\"\n\nvoid main() {\n\t// time relative to the start of the effect of the instance\n\tfloat t = uTime - aStart;\n\tvec2 wPosition = aOrigin + vec2(\n\t\taPosition.x * uForce * easeOut(t),\n\t\taPosition.y * uForce * easeOut(t) / 3);\n\tvOpacity = length(aPosition);\n\tvTime = t;\n\tgl_Position = uProjection * uView * uModel * vec4(wPosition, 0, 1);\n}\n",
+	"shaders/spark.frag":          "#version 410\n\nuniform vec4 uColor;\n\nin vec2 gCoord;\nout vec4 oColor;\n\nvoid main() {\n\tfloat falloff = max(0, 1.0 - length(gCoord));\n\toColor = vec4(uColor.rgb, uColor.a * falloff);\n}\n",
+	"shaders/spark.geom":          "#version 410\n\nlayout(points) in;\nlayout(triangle_strip, max_vertices=4) out;\n\nuniform mat4 uModel;\n\n#include \"include/camera.glsl\"\n\nin vec2 vVelocity[];\nin float vSize[];\n\nout vec2 gCoord;\n\nvoid main() {\n\tif (vSize[0] <= 0) {\n\t\treturn;\n\t}\n\t// expand the point into a quad stretched along its direction of travel\n\tvec2 direction = vec2(0, 1);\n\tif (length(vVelocity[0]) > 0) {\n\t\tdirection = normalize(vVelocity[0]);\n\t}\n\tvec2 forward = direction * vSize[0] * 3;\n\tvec2 side = vec2(-direction.y, direction.x) * vSize[0];\n\tvec2 center = gl_in[0].gl_Position.xy;\n\tmat4 mvp = uProjection * uView * uModel;\n\tfor (int i = 0; i < 4; i++) {\n\t\tgCoord = vec2(float(i / 2) * 2 - 1, float(i % 2) * 2 - 1);\n\t\tvec2 wPosition = center + (forward * gCoord.x) + (side * gCoord.y);\n\t\tgl_Position = mvp * vec4(wPosition, 0, 1);\n\t\tEmitVertex();\n\t}\n\tEndPrimitive();\n}\n",
+	"shaders/spark.vert":          "#version 410\n\nlayout(location=0) in vec2 aVelocity;\nlayout(location=1) in float aSize;\n\nuniform float uTime;\nuniform vec2 uGravity;\n\nout vec2 vVelocity;\nout float vSize;\n\nvoid main() {\n\tvec2 displacement = (aVelocity * uTime) + (0.5 * uGravity * (uTime*uTime));\n\tvVelocity = aVelocity + (uGravity * uTime);\n\tvSize = max(0, aSize - (aSize * uTime));\n\tgl_Position = vec4(displacement, 0, 1);\n}\n",
+	"shaders/texture.frag":        "#version 410\n\nuniform sampler2D uTexture;\nuniform float uOpacity;\n\nin vec2 vTexCoord;\n\nout vec4 oColor;\n\nvoid main() {\n\toColor = texture(uTexture, vTexCoord) * uOpacity;\n}\n",
+	"shaders/texture.vert":        "#version 410\n\nlayout(location=0) in vec3 aPosition;\nlayout(location=1) in vec2 aTexCoord;\n\nout vec2 vTexCoord;\n\nvoid main() {\n\tvTexCoord = aTexCoord;\n\tgl_Position = vec4(aPosition, 1);\n}\n",
+	"shaders/trail.frag":          "#version 410\n\nuniform vec4 uColor;\nuniform float uTime;\n\nin float vProgress;\nout vec4 oColor;\n\nvoid main() {\n\tfloat fade = max(0, 1.0 - uTime);\n\toColor = vec4(uColor.rgb, uColor.a * vProgress * fade);\n}\n",
+	"shaders/trail.tesc":          "#version 410\n\nlayout(vertices=4) out;\n\nuniform float uSegments;\n\nvoid main() {\n\tgl_out[gl_InvocationID].gl_Position = gl_in[gl_InvocationID].gl_Position;\n\tif (gl_InvocationID == 0) {\n\t\t// a single line subdivided into segments\n\t\tgl_TessLevelOuter[0] = 1;\n\t\tgl_TessLevelOuter[1] = uSegments;\n\t}\n}\n",
+	"shaders/trail.tese":          "#version 410\n\nlayout(isolines, equal_spacing) in;\n\nuniform mat4 uModel;\n\n#include \"include/camera.glsl\"\n\nuniform float uTime;\n\nout float vProgress;\n\n#include \"include/easing.glsl\"\n\nvoid main() {\n\t// the curve grows from its first control point as time passes\n\tfloat t = gl_TessCoord.x * easeOut(min(uTime, 1.0));\n\tfloat s = 1.0 - t;\n\tvec2 p0 = gl_in[0].gl_Position.xy;\n\tvec2 p1 = gl_in[1].gl_Position.xy;\n\tvec2 p2 = gl_in[2].gl_Position.xy;\n\tvec2 p3 = gl_in[3].gl_Position.xy;\n\tvec2 wPosition = (s*s*s * p0) + (3*s*s*t * p1) + (3*s*t*t * p2) + (t*t*t * p3);\n\tvProgress = gl_TessCoord.x;\n\tgl_Position = uProjection * uView * uModel * vec4(wPosition, 0, 1);\n}\n",
+	"shaders/trail.vert":          "#version 410\n\nlayout(location=0) in vec2 aPosition;\n\nvoid main() {\n\tgl_Position = vec4(aPosition, 0, 1);\n}\n",
+}
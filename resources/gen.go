@@ -0,0 +1,56 @@
+//go:build ignore
+// +build ignore
+
+// gen embeds the files under the shaders directory into files.go. It is run
+// by `go generate` from the resources directory.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func main() {
+	files := make(map[string][]byte)
+	err := filepath.Walk("shaders", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(path)] = data
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen.go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package resources\n\n")
+	fmt.Fprintf(&buf, "var files = map[string]string{\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%q: %q,\n", name, files[name])
+	}
+	fmt.Fprintf(&buf, "}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile("files.go", src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,20 @@
+// Package resources embeds the default shaders into the binary.
+package resources
+
+//go:generate go run gen.go
+
+import (
+	"github.com/kbirk/cauldron/render"
+)
+
+// FileSystem returns the embedded default resources, keyed by their path
+// relative to the resources directory, such as `shaders/flat.vert`.
+func FileSystem() render.FileSystem {
+	fsys := make(render.MapFS, len(files))
+	for name, data := range files {
+		fsys[name] = &render.MapFile{
+			Data: []byte(data),
+		}
+	}
+	return fsys
+}
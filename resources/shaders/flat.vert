@@ -3,8 +3,8 @@
 layout(location=0) in vec3 aPosition;
 
 uniform mat4 uModel;
-uniform mat4 uView;
-uniform mat4 uProjection;
+
+#include "include/camera.glsl"
 
 void main() {
 	gl_Position = uProjection * uView * uModel * vec4(aPosition, 1);
@@ -0,0 +1,4 @@
+layout(std140) uniform Camera {
+	mat4 uProjection;
+	mat4 uView;
+};
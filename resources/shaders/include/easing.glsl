@@ -0,0 +1,8 @@
+float cube(float v) {
+	return v*v*v;
+}
+
+float easeOut(float t) {
+	t -= 1.0;
+	return 1.0 + t*t*t*t*t;
+}
@@ -2,21 +2,54 @@
 
 layout(location=0) in vec2 aPosition;
 layout(location=1) in vec2 aOffset;
-layout(location=2) in vec2 aVelocity;
 layout(location=3) in float aSize;
+#ifdef SIMULATED
+layout(location=4) in float aAge;
+#else
+layout(location=2) in vec2 aVelocity;
+layout(location=5) in vec2 aOrigin;
+layout(location=6) in float aStart;
+#endif
 
 uniform mat4 uModel;
-uniform mat4 uView;
-uniform mat4 uProjection;
+
+#include "include/camera.glsl"
+
+#ifdef SIMULATED
+uniform float uLifetime;
+#else
 uniform float uTime;
+#ifdef RISE
+uniform vec2 uRise;
+#else
 uniform vec2 uGravity;
+#endif
+#endif
 
 out float vSize;
 
 void main() {
-	vec2 displacement = (aVelocity * uTime) + (0.5 * uGravity * aSize * (uTime*uTime));
-	float size = max(0, aSize - (aSize * uTime));
-	vec2 wPosition = (aPosition * size) + aOffset + displacement;
-	gl_Position = uProjection * uView * uModel * vec4(wPosition, 0, 1);
+#ifndef SIMULATED
+	// time relative to the start of the effect of the instance
+	float t = uTime - aStart;
+#endif
+#ifdef SIMULATED
+	// the offset is stepped on the GPU, particles shrink with age
+	vec2 origin = vec2(0);
+	vec2 displacement = vec2(0);
+	float size = aAge > 0.0 ? max(0.0, aSize - (aSize * (aAge / uLifetime))) : 0.0;
+	vSize = size / 4;
+#elif defined(RISE)
+	vec2 origin = aOrigin;
+	vec2 displacement = (aVelocity * t) + uRise * (t * 0.2 * aSize);
+	float size = aSize * 0.5 * t;
+	vSize = size;
+#else
+	vec2 origin = aOrigin;
+	vec2 displacement = (aVelocity * t) + (0.5 * uGravity * aSize * (t*t));
+	float size = max(0, aSize - (aSize * t));
 	vSize = size / 4;
+#endif
+	vec2 wPosition = origin + (aPosition * size) + aOffset + displacement;
+	gl_Position = uProjection * uView * uModel * vec4(wPosition, 0, 1);
 }
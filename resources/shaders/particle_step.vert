@@ -0,0 +1,45 @@
+#version 410
+
+layout(location=0) in vec2 aPosition;
+layout(location=1) in vec2 aVelocity;
+layout(location=2) in float aAge;
+layout(location=3) in float aSize;
+
+uniform float uDelta;
+uniform float uLifetime;
+uniform vec2 uOrigin;
+uniform float uSpeed;
+uniform float uSpread;
+uniform vec2 uGravity;
+uniform vec2 uWind;
+uniform float uDrag;
+
+out vec2 vPosition;
+out vec2 vVelocity;
+out float vAge;
+out float vSize;
+
+#include "include/rand.glsl"
+
+void main() {
+	vec2 position = aPosition;
+	vec2 velocity = aVelocity;
+	float age = aAge + uDelta;
+	if (age >= uLifetime) {
+		// respawn at the origin in a random direction within the spread
+		float angle = 1.5707963 + (rand(aVelocity + vec2(aAge, aSize)) - 0.5) * uSpread;
+		float speed = (0.5 + 0.5 * rand(aPosition + vec2(aSize))) * uSpeed;
+		position = uOrigin;
+		velocity = vec2(cos(angle), sin(angle)) * speed;
+		age -= uLifetime;
+	} else if (age > 0.0) {
+		// particles with a negative age have not been emitted yet
+		vec2 acceleration = (uGravity * aSize) + uWind - (velocity * uDrag);
+		velocity += acceleration * uDelta;
+		position += velocity * uDelta;
+	}
+	vPosition = position;
+	vVelocity = velocity;
+	vAge = age;
+	vSize = aSize;
+}
@@ -0,0 +1,25 @@
+#version 410
+
+uniform sampler2D uTexture;
+uniform vec2 uDirection;
+uniform float uSigma;
+
+in vec2 vTexCoord;
+
+out vec4 oColor;
+
+void main() {
+	// weights of a gaussian kernel truncated at three standard deviations,
+	// sampled along the direction of the pass
+	int radius = int(ceil(uSigma * 3.0));
+	vec4 sum = texture(uTexture, vTexCoord);
+	float total = 1.0;
+	for (int i = 1; i <= radius; i++) {
+		float weight = exp(-float(i * i) / (2.0 * uSigma * uSigma));
+		vec2 offset = uDirection * float(i);
+		sum += texture(uTexture, vTexCoord + offset) * weight;
+		sum += texture(uTexture, vTexCoord - offset) * weight;
+		total += 2.0 * weight;
+	}
+	oColor = sum / total;
+}
@@ -0,0 +1,16 @@
+#version 410
+
+uniform sampler2D uTexture;
+uniform float uThreshold;
+
+in vec2 vTexCoord;
+
+out vec4 oColor;
+
+void main() {
+	// keep the part of the color brighter than the threshold
+	vec3 color = texture(uTexture, vTexCoord).rgb;
+	float brightness = max(color.r, max(color.g, color.b));
+	float contribution = max(brightness - uThreshold, 0.0) / max(brightness, 0.0001);
+	oColor = vec4(color * contribution, 1.0);
+}
@@ -0,0 +1,39 @@
+#version 410
+
+uniform sampler2D uTexture;
+
+#ifdef BLOOM
+uniform sampler2D uBloom;
+uniform float uIntensity;
+#endif
+
+#ifdef TONEMAP
+uniform float uExposure;
+#endif
+
+#ifdef VIGNETTE
+uniform float uRadius;
+uniform float uSoftness;
+uniform float uStrength;
+#endif
+
+in vec2 vTexCoord;
+
+out vec4 oColor;
+
+void main() {
+	vec3 color = texture(uTexture, vTexCoord).rgb;
+#ifdef BLOOM
+	color += texture(uBloom, vTexCoord).rgb * uIntensity;
+#endif
+#ifdef TONEMAP
+	// exponential tone mapping rolls highlights off instead of clipping
+	color = vec3(1.0) - exp(-color * uExposure);
+#endif
+#ifdef VIGNETTE
+	// distance from the center, one at the corners
+	float dist = length(vTexCoord - vec2(0.5)) * 1.41421356;
+	color *= 1.0 - uStrength * smoothstep(uRadius, uRadius + uSoftness, dist);
+#endif
+	oColor = vec4(color, 1.0);
+}
@@ -1,18 +1,16 @@
 #version 410
 
 uniform vec4 uColor;
-uniform float uTime;
 
 in float vOpacity;
+in float vTime;
 
 out vec4 oColor;
 
-float cube(float v) {
-	return v*v*v;
-}
+#include "include/easing.glsl"
 
 void main() {
-	float intensity = max(0, 1.0 - (uTime  / 0.5));
+	float intensity = max(0, 1.0 - (vTime  / 0.5));
 	float opacity = cube(vOpacity);
 	oColor = vec4(uColor.rgb * intensity, uColor.a * intensity * opacity);
 }
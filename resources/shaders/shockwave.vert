@@ -1,24 +1,28 @@
 #version 410
 
 layout(location=0) in vec2 aPosition;
+layout(location=1) in vec2 aOrigin;
+layout(location=2) in float aStart;
 
 uniform mat4 uModel;
-uniform mat4 uView;
-uniform mat4 uProjection;
+
+#include "include/camera.glsl"
+
 uniform float uForce;
 uniform float uTime;
 
 out float vOpacity;
+out float vTime;
 
-float easeOut(float t) {
-	t -= 1.0;
-	return 1.0 + t*t*t*t*t;
-}
+#include "include/easing.glsl"
 
 void main() {
-	vec2 wPosition = vec2(
-		aPosition.x * uForce * easeOut(uTime),
-		aPosition.y * uForce * easeOut(uTime) / 3);
+	// time relative to the start of the effect of the instance
+	float t = uTime - aStart;
+	vec2 wPosition = aOrigin + vec2(
+		aPosition.x * uForce * easeOut(t),
+		aPosition.y * uForce * easeOut(t) / 3);
 	vOpacity = length(aPosition);
+	vTime = t;
 	gl_Position = uProjection * uView * uModel * vec4(wPosition, 0, 1);
 }
@@ -0,0 +1,11 @@
+#version 410
+
+uniform vec4 uColor;
+
+in vec2 gCoord;
+out vec4 oColor;
+
+void main() {
+	float falloff = max(0, 1.0 - length(gCoord));
+	oColor = vec4(uColor.rgb, uColor.a * falloff);
+}
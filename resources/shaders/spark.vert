@@ -0,0 +1,17 @@
+#version 410
+
+layout(location=0) in vec2 aVelocity;
+layout(location=1) in float aSize;
+
+uniform float uTime;
+uniform vec2 uGravity;
+
+out vec2 vVelocity;
+out float vSize;
+
+void main() {
+	vec2 displacement = (aVelocity * uTime) + (0.5 * uGravity * (uTime*uTime));
+	vVelocity = aVelocity + (uGravity * uTime);
+	vSize = max(0, aSize - (aSize * uTime));
+	gl_Position = vec4(displacement, 0, 1);
+}
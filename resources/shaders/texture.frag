@@ -0,0 +1,12 @@
+#version 410
+
+uniform sampler2D uTexture;
+uniform float uOpacity;
+
+in vec2 vTexCoord;
+
+out vec4 oColor;
+
+void main() {
+	oColor = texture(uTexture, vTexCoord) * uOpacity;
+}
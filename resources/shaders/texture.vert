@@ -0,0 +1,11 @@
+#version 410
+
+layout(location=0) in vec3 aPosition;
+layout(location=1) in vec2 aTexCoord;
+
+out vec2 vTexCoord;
+
+void main() {
+	vTexCoord = aTexCoord;
+	gl_Position = vec4(aPosition, 1);
+}
@@ -0,0 +1,12 @@
+#version 410
+
+uniform vec4 uColor;
+uniform float uTime;
+
+in float vProgress;
+out vec4 oColor;
+
+void main() {
+	float fade = max(0, 1.0 - uTime);
+	oColor = vec4(uColor.rgb, uColor.a * vProgress * fade);
+}
@@ -0,0 +1,7 @@
+#version 410
+
+layout(location=0) in vec2 aPosition;
+
+void main() {
+	gl_Position = vec4(aPosition, 0, 1);
+}